@@ -0,0 +1,36 @@
+//go:build linux
+
+package main
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// availableCPUs returns the CPUs this process is allowed to run on, which
+// honours restrictions applied with taskset, numactl or cgroups
+func availableCPUs() ([]int, error) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return nil, err
+	}
+
+	var cpus []int
+	for cpu := 0; cpu < len(set)*64; cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// pinToCPU locks the calling goroutine to its OS thread and binds that
+// thread to a single CPU. The goroutine must not unlock the thread afterwards.
+func pinToCPU(cpu int) error {
+	runtime.LockOSThread()
+
+	var set unix.CPUSet
+	set.Set(cpu)
+	return unix.SchedSetaffinity(0, &set)
+}
@@ -0,0 +1,43 @@
+//go:build linux
+
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestPinToCPU tests that a pinned goroutine is bound to exactly one CPU
+func TestPinToCPU(t *testing.T) {
+	cpus, err := availableCPUs()
+	if err != nil {
+		t.Fatalf("Failed to get available CPUs: %v", err)
+	}
+	if len(cpus) == 0 {
+		t.Fatal("Expected at least one available CPU")
+	}
+
+	target := cpus[len(cpus)-1]
+	done := make(chan error)
+	go func() {
+		if err := pinToCPU(target); err != nil {
+			done <- err
+			return
+		}
+
+		var set unix.CPUSet
+		if err := unix.SchedGetaffinity(0, &set); err != nil {
+			done <- err
+			return
+		}
+		if set.Count() != 1 || !set.IsSet(target) {
+			t.Errorf("Expected thread to be pinned to CPU %d only", target)
+		}
+		done <- nil
+	}()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Failed to pin to CPU %d: %v", target, err)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// errPinningUnsupported is returned on platforms without thread affinity support
+var errPinningUnsupported = errors.New("worker pinning is only supported on Linux")
+
+// availableCPUs is not supported on this platform
+func availableCPUs() ([]int, error) {
+	return nil, errPinningUnsupported
+}
+
+// pinToCPU is not supported on this platform
+func pinToCPU(cpu int) error {
+	return errPinningUnsupported
+}
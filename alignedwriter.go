@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// directIOAlignment is the buffer address and write size alignment required
+// by O_DIRECT on common Linux filesystems and NVMe devices
+const directIOAlignment = 4096
+
+// FlushWriter is the buffered writer interface used by the result collector
+type FlushWriter interface {
+	Write(p []byte) (int, error)
+	Flush() error
+}
+
+// AlignedWriter buffers output in a block-aligned buffer and only issues
+// writes of whole buffers, which is what O_DIRECT output requires
+type AlignedWriter struct {
+	file   *os.File
+	out    io.Writer // Destination for writes, the file itself unless wrapped
+	buf    []byte
+	n      int
+	direct bool
+}
+
+// NewAlignedWriter creates an aligned writer with a buffer of at least size
+// bytes, rounded up to a multiple of the alignment. When direct is set the file
+// must have been opened with openDirect.
+func NewAlignedWriter(file *os.File, size int, direct bool) *AlignedWriter {
+	size = (size + directIOAlignment - 1) / directIOAlignment * directIOAlignment
+	if size == 0 {
+		size = directIOAlignment
+	}
+	return &AlignedWriter{
+		file:   file,
+		out:    file,
+		buf:    alignedBuffer(size),
+		direct: direct,
+	}
+}
+
+// alignedBuffer allocates a buffer whose first byte sits on an alignment boundary
+func alignedBuffer(size int) []byte {
+	raw := make([]byte, size+directIOAlignment)
+	offset := 0
+	if rem := int(uintptr(unsafe.Pointer(&raw[0])) & (directIOAlignment - 1)); rem != 0 {
+		offset = directIOAlignment - rem
+	}
+	return raw[offset : offset+size : offset+size]
+}
+
+// Write copies p into the buffer, writing the buffer out each time it fills up
+func (w *AlignedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		copied := copy(w.buf[w.n:], p)
+		w.n += copied
+		written += copied
+		p = p[copied:]
+
+		if w.n == len(w.buf) {
+			if _, err := w.out.Write(w.buf); err != nil {
+				return written, err
+			}
+			w.n = 0
+		}
+	}
+	return written, nil
+}
+
+// Flush writes out any buffered data. A trailing partial block cannot be
+// written with O_DIRECT, so direct I/O is switched off before writing it.
+func (w *AlignedWriter) Flush() error {
+	if w.n == 0 {
+		return nil
+	}
+	if w.direct && w.n%directIOAlignment != 0 {
+		if err := disableDirectIO(w.file); err != nil {
+			return err
+		}
+		w.direct = false
+	}
+	if _, err := w.out.Write(w.buf[:w.n]); err != nil {
+		return err
+	}
+	w.n = 0
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+// TestAlignedWriter tests that buffered data is written out completely and in order
+func TestAlignedWriter(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "aligned")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	w := NewAlignedWriter(tempFile, 5000, false)
+	if len(w.buf) != 2*directIOAlignment {
+		t.Errorf("Expected buffer size to be rounded up to %d, got %d", 2*directIOAlignment, len(w.buf))
+	}
+	if uintptr(unsafe.Pointer(&w.buf[0]))%directIOAlignment != 0 {
+		t.Errorf("Buffer is not aligned to %d bytes", directIOAlignment)
+	}
+
+	// Write enough lines to fill the buffer several times with a partial tail
+	var expected bytes.Buffer
+	line := []byte("0x0d747F8AdFdE4beF87CF21FEa682083C7149268f\n")
+	for i := 0; i < 1000; i++ {
+		if _, err := w.Write(line); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		expected.Write(line)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	content, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to read temp file: %v", err)
+	}
+	if !bytes.Equal(content, expected.Bytes()) {
+		t.Errorf("Expected %d bytes of output, got %d", expected.Len(), len(content))
+	}
+}
+
+// BenchmarkAlignedWriter measures rows buffered into whole blocks and
+// written to a file
+func BenchmarkAlignedWriter(b *testing.B) {
+	file, err := os.Create(filepath.Join(b.TempDir(), "aligned"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+	line := []byte("0x0d747F8AdFdE4beF87CF21FEa682083C7149268f\n")
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	w := NewAlignedWriter(file, 1<<20, false)
+	for i := 0; i < b.N; i++ {
+		w.Write(line)
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+}
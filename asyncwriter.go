@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// asyncChunkSize is the size of the chunks handed to the writer goroutine
+const asyncChunkSize = 64 * 1024
+
+// AsyncWriter moves output writes onto a dedicated goroutine fed by a
+// bounded queue of chunks, so slow disks don't stall result collection
+type AsyncWriter struct {
+	dst     FlushWriter
+	queue   chan []byte
+	free    chan []byte
+	acks    chan error
+	done    chan struct{}
+	current []byte
+
+	// First error of the writer goroutine, returned by later writes
+	mu  sync.Mutex
+	err error
+
+	// Queue metrics, only touched by the producer
+	enqueued   int
+	depthTotal int
+	maxDepth   int
+	waited     time.Duration
+}
+
+// NewAsyncWriter creates an async writer with queueSize chunks in flight and
+// starts its writer goroutine
+func NewAsyncWriter(dst FlushWriter, queueSize int) *AsyncWriter {
+	w := &AsyncWriter{
+		dst:   dst,
+		queue: make(chan []byte, queueSize),
+		free:  make(chan []byte, queueSize+1),
+		acks:  make(chan error),
+		done:  make(chan struct{}),
+	}
+
+	// Preallocate every chunk so steady-state writing never allocates
+	for i := 0; i < queueSize; i++ {
+		w.free <- make([]byte, 0, asyncChunkSize)
+	}
+	w.current = make([]byte, 0, asyncChunkSize)
+
+	go w.run()
+	return w
+}
+
+// run writes queued chunks to the destination. A nil chunk requests a flush.
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	var err error
+	for chunk := range w.queue {
+		if chunk == nil {
+			if err == nil {
+				err = w.dst.Flush()
+			}
+			w.acks <- err
+			continue
+		}
+		if err == nil {
+			if _, err = w.dst.Write(chunk); err != nil {
+				w.mu.Lock()
+				w.err = err
+				w.mu.Unlock()
+			}
+		}
+		w.free <- chunk[:0]
+	}
+}
+
+// Write appends p to the current chunk, handing full chunks to the writer
+// goroutine. It fails once a chunk has failed to write, so that the caller
+// stops before the final Flush.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	err := w.err
+	w.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	written := len(p)
+	for len(p) > 0 {
+		n := copy(w.current[len(w.current):cap(w.current)], p)
+		w.current = w.current[:len(w.current)+n]
+		p = p[n:]
+		if len(w.current) == cap(w.current) {
+			w.enqueue()
+		}
+	}
+	return written, nil
+}
+
+// enqueue hands the current chunk to the writer goroutine and takes a free one,
+// waiting if the queue is full
+func (w *AsyncWriter) enqueue() {
+	depth := len(w.queue)
+	w.enqueued++
+	w.depthTotal += depth
+	if depth > w.maxDepth {
+		w.maxDepth = depth
+	}
+
+	w.queue <- w.current
+	select {
+	case w.current = <-w.free:
+	default:
+		start := time.Now()
+		w.current = <-w.free
+		w.waited += time.Since(start)
+	}
+}
+
+// Flush waits until everything written so far has reached the destination
+// and returns the first write error, if any
+func (w *AsyncWriter) Flush() error {
+	if len(w.current) > 0 {
+		w.enqueue()
+	}
+	w.queue <- nil
+	return <-w.acks
+}
+
+// Close flushes the remaining output and stops the writer goroutine
+func (w *AsyncWriter) Close() error {
+	err := w.Flush()
+	close(w.queue)
+	<-w.done
+	return err
+}
+
+// Stats summarizes the queue depth observed while writing
+func (w *AsyncWriter) Stats() string {
+	average := 0.0
+	if w.enqueued > 0 {
+		average = float64(w.depthTotal) / float64(w.enqueued)
+	}
+	return fmt.Sprintf("%d chunks written, max queue depth %d/%d, average depth %.1f, collector waited %s",
+		w.enqueued, w.maxDepth, cap(w.queue), average, w.waited)
+}
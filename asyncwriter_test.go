@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+func (failingWriter) Flush() error                { return nil }
+
+// TestAsyncWriter tests that output written through the queue arrives complete and in order
+func TestAsyncWriter(t *testing.T) {
+	var output bytes.Buffer
+	dst := bufio.NewWriter(&output)
+	w := NewAsyncWriter(dst, 2)
+
+	// Write enough data to cycle through every chunk several times
+	var expected bytes.Buffer
+	for i := 0; i < 20000; i++ {
+		line := []byte("0x0d747F8AdFdE4beF87CF21FEa682083C7149268f\n")
+		line[2] = byte('a' + i%26)
+		w.Write(line)
+		expected.Write(line)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !bytes.Equal(output.Bytes(), expected.Bytes()) {
+		t.Errorf("Expected %d bytes of output, got %d", expected.Len(), output.Len())
+	}
+	if !strings.Contains(w.Stats(), "max queue depth") {
+		t.Errorf("Unexpected stats output: %s", w.Stats())
+	}
+}
+
+// TestAsyncWriterError tests that write errors surface on flush
+func TestAsyncWriterError(t *testing.T) {
+	w := NewAsyncWriter(failingWriter{}, 1)
+	w.Write([]byte("address\n"))
+	if err := w.Close(); err == nil {
+		t.Error("Expected write error to be reported on close")
+	}
+
+	// Writes fail once the writer goroutine has failed
+	w = NewAsyncWriter(failingWriter{}, 1)
+	chunk := make([]byte, asyncChunkSize)
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		_, err = w.Write(chunk)
+	}
+	if err == nil {
+		t.Error("Expected a write to fail after the destination failed")
+	}
+}
+
+// BenchmarkAsyncWriter measures rows passing through the write queue
+func BenchmarkAsyncWriter(b *testing.B) {
+	line := []byte("0x0d747F8AdFdE4beF87CF21FEa682083C7149268f\n")
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	w := NewAsyncWriter(bufio.NewWriter(io.Discard), 64)
+	for i := 0; i < b.N; i++ {
+		w.Write(line)
+	}
+	if err := w.Close(); err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// auditGenesis is the previous-line hash of the first entry of a log
+var auditGenesis = strings.Repeat("0", sha256.Size*2)
+
+// AuditEntry records one operation that wrote sensitive material. Each
+// entry is a JSON line holding the SHA-256 of the line before it, so
+// editing, inserting or removing an entry breaks the chain at the next one.
+type AuditEntry struct {
+	Time         time.Time          `json:"time"`
+	User         string             `json:"user"`
+	Host         string             `json:"host"`
+	PID          int                `json:"pid"`
+	Command      string             `json:"command"`
+	Produced     string             `json:"produced"` // What was written, e.g. private keys
+	Destinations []AuditDestination `json:"destinations"`
+	Prev         string             `json:"prev"` // SHA-256 of the previous line
+}
+
+// AuditDestination is where sensitive material went. Files are identified
+// by the SHA-256 of their contents; secrets shown on the console only by
+// their fingerprint.
+type AuditDestination struct {
+	Path        string `json:"path"`
+	SHA256      string `json:"sha256,omitempty"`
+	Bytes       int64  `json:"bytes,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// newAuditEntry creates an entry for the current user, host and process
+func newAuditEntry(command, produced string, destinations ...AuditDestination) AuditEntry {
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	host, _ := os.Hostname()
+	return AuditEntry{
+		Time:         time.Now().UTC(),
+		User:         username,
+		Host:         host,
+		PID:          os.Getpid(),
+		Command:      command,
+		Produced:     produced,
+		Destinations: destinations,
+	}
+}
+
+// fileDestination identifies a written file by its size and SHA-256
+func fileDestination(path string) (AuditDestination, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return AuditDestination{}, err
+	}
+	defer file.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return AuditDestination{}, err
+	}
+	return AuditDestination{Path: path, SHA256: hex.EncodeToString(h.Sum(nil)), Bytes: n}, nil
+}
+
+// appendAudit chains entry onto the log at path, creating it if needed,
+// and syncs it to disk. It returns the hash of the new line, which anchors
+// the whole log when recorded elsewhere.
+func appendAudit(path string, entry AuditEntry) (string, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	entries, head, err := verifyAuditLog(file)
+	if err != nil {
+		return "", fmt.Errorf("refusing to extend a broken audit log: %w", err)
+	}
+	if entries == 0 {
+		head = auditGenesis
+	}
+	entry.Prev = head
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return "", err
+	}
+	if err := file.Sync(); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:]), file.Close()
+}
+
+// verifyAuditLog checks the chain of an audit log. It returns the number
+// of entries and the hash of the last line.
+func verifyAuditLog(r io.Reader) (int, string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	head := auditGenesis
+	entries := 0
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		entries++
+		var entry AuditEntry
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&entry); err != nil {
+			return entries, head, fmt.Errorf("entry %d is not a valid audit entry: %w", entries, err)
+		}
+		if entry.Prev != head {
+			return entries, head, fmt.Errorf("entry %d does not follow entry %d; the log was altered", entries, entries-1)
+		}
+		sum := sha256.Sum256(line)
+		head = hex.EncodeToString(sum[:])
+	}
+	return entries, head, scanner.Err()
+}
+
+// runAudit checks the chain of audit logs written with --audit-log
+func runAudit(args []string) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fatalf("Usage: addrmint audit verify FILE")
+	}
+	fs := flag.NewFlagSet("audit verify", flag.ExitOnError)
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint audit verify FILE\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	prepareConsole()
+	setupColor(*noColor)
+	if fs.NArg() != 1 {
+		fatalf("audit verify takes exactly one log file")
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fatalf("Failed to open audit log: %v", err)
+	}
+	defer file.Close()
+	entries, head, err := verifyAuditLog(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, fmt.Sprintf("Audit log is broken: %v", err)))
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%s entries, chain intact\n", formatCount(entries))
+	fmt.Println(head)
+	return 0
+}
+
+// recordAudit appends entry to the log at path, failing the run if it
+// cannot be recorded
+func recordAudit(path string, entry AuditEntry) {
+	head, err := appendAudit(path, entry)
+	if err != nil {
+		fatalf("Failed to write audit log: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Recorded %s in audit log %s (head %s)\n", entry.Produced, path, head)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	keys := filepath.Join(dir, "keys.txt")
+	if err := os.WriteFile(keys, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	destination, err := fileDestination(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if destination.Bytes != 7 || destination.SHA256 != "b37e50cedcd3e3f1ff64f4afc0422084ae694253cf399326868e07a35f4a45fb" {
+		t.Errorf("Unexpected destination %+v", destination)
+	}
+
+	var heads []string
+	for _, produced := range []string{"private keys", "hash key", "private keys"} {
+		head, err := appendAudit(path, newAuditEntry("test", produced, destination))
+		if err != nil {
+			t.Fatal(err)
+		}
+		heads = append(heads, head)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, head, err := verifyAuditLog(bytes.NewReader(data))
+	if err != nil || entries != 3 || head != heads[2] {
+		t.Errorf("Expected 3 chained entries ending in %s, got %d ending in %s: %v", heads[2], entries, head, err)
+	}
+
+	// Editing or removing an entry breaks the chain
+	lines := strings.SplitAfter(string(data), "\n")
+	tampered := map[string]string{
+		"edited":  lines[0] + strings.Replace(lines[1], "hash key", "nothing", 1) + lines[2],
+		"removed": lines[0] + lines[2],
+		"garbage": lines[0] + "{}\n",
+	}
+	for name, log := range tampered {
+		if _, _, err := verifyAuditLog(strings.NewReader(log)); err == nil {
+			t.Errorf("%s: expected the chain to break", name)
+		}
+		if err := os.WriteFile(path, []byte(log), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := appendAudit(path, newAuditEntry("test", "private keys")); err == nil {
+			t.Errorf("%s: expected appending to a broken log to fail", name)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Avro block codecs for --avro-codec
+const (
+	avroCodecNull    = "null"
+	avroCodecDeflate = "deflate"
+	avroCodecSnappy  = "snappy"
+)
+
+// avroBlockBytes is the encoded size at which a block of records is
+// compressed and written
+const avroBlockBytes = 256 * 1024
+
+// AvroWriter writes records of an Avro object container file, with the
+// schema embedded in the header and a field per column. The index is
+// written as a long and every other column as a string. Flush writes the
+// buffered records as a block.
+type AvroWriter struct {
+	out     io.Writer
+	layout  *rowLayout
+	columns []outputColumn
+	codec   string
+	sync    [16]byte
+
+	value   []byte // Reused buffer for the value of one column
+	block   []byte // Encoded records of the current block
+	records int64  // Records in block
+	scratch []byte // Compression output, reused across blocks
+	deflate *flate.Writer
+}
+
+// avroSchema returns the record schema of the given columns
+func avroSchema(columns []outputColumn) []byte {
+	type field struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	fields := make([]field, len(columns))
+	for i, c := range columns {
+		fields[i] = field{Name: c.name, Type: "string"}
+		if c.kind == columnIndex {
+			fields[i].Type = "long"
+		}
+	}
+	schema, _ := json.Marshal(struct {
+		Type      string  `json:"type"`
+		Name      string  `json:"name"`
+		Namespace string  `json:"namespace"`
+		Fields    []field `json:"fields"`
+	}{"record", "Address", "addrmint", fields})
+	return schema
+}
+
+// NewAvroWriter writes the container header to out and returns a writer
+// for the records that follow it
+func NewAvroWriter(out io.Writer, layout *rowLayout, columns []outputColumn, codec string) (*AvroWriter, error) {
+	if codec != avroCodecNull && codec != avroCodecDeflate && codec != avroCodecSnappy {
+		return nil, fmt.Errorf("unknown Avro codec %q", codec)
+	}
+	aw := &AvroWriter{out: out, layout: layout, columns: columns, codec: codec}
+	rand.Read(aw.sync[:])
+	if codec == avroCodecDeflate {
+		aw.deflate, _ = flate.NewWriter(nil, flate.DefaultCompression)
+	}
+
+	// Magic, then the file metadata as a map of bytes, then the sync marker
+	header := []byte("Obj\x01")
+	header = appendAvroLong(header, 2)
+	header = appendAvroBytes(header, []byte("avro.schema"))
+	header = appendAvroBytes(header, avroSchema(columns))
+	header = appendAvroBytes(header, []byte("avro.codec"))
+	header = appendAvroBytes(header, []byte(codec))
+	header = appendAvroLong(header, 0)
+	header = append(header, aw.sync[:]...)
+	if _, err := out.Write(header); err != nil {
+		return nil, err
+	}
+	return aw, nil
+}
+
+// WriteRecord encodes one record into the current block, writing the block
+// once it grows past avroBlockBytes
+func (aw *AvroWriter) WriteRecord(record Record) error {
+	for _, c := range aw.columns {
+		if c.kind == columnIndex {
+			aw.block = appendAvroLong(aw.block, int64(aw.layout.index(&record)))
+			continue
+		}
+		var err error
+		if aw.value, err = aw.layout.appendValue(aw.value[:0], c, &record); err != nil {
+			return err
+		}
+		aw.block = appendAvroBytes(aw.block, aw.value)
+	}
+	aw.records++
+	if len(aw.block) >= avroBlockBytes {
+		return aw.Flush()
+	}
+	return nil
+}
+
+// Flush compresses the buffered records and writes them as one block
+func (aw *AvroWriter) Flush() error {
+	if aw.records == 0 {
+		return nil
+	}
+	data := aw.block
+	switch aw.codec {
+	case avroCodecDeflate:
+		buf := bytes.NewBuffer(aw.scratch[:0])
+		aw.deflate.Reset(buf)
+		aw.deflate.Write(aw.block)
+		if err := aw.deflate.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	case avroCodecSnappy:
+		// Snappy blocks are followed by the CRC-32 of the uncompressed data
+		data = snappyEncode(aw.scratch[:0], aw.block)
+		data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(aw.block))
+	}
+	aw.scratch = data[:0]
+
+	header := appendAvroLong(nil, aw.records)
+	header = appendAvroLong(header, int64(len(data)))
+	for _, part := range [][]byte{header, data, aw.sync[:]} {
+		if _, err := aw.out.Write(part); err != nil {
+			return err
+		}
+	}
+	aw.block = aw.block[:0]
+	aw.records = 0
+	return nil
+}
+
+// Close writes the buffered records. The container needs no trailer.
+func (aw *AvroWriter) Close() error {
+	return aw.Flush()
+}
+
+// appendAvroLong appends v as a zig-zag varint
+func appendAvroLong(b []byte, v int64) []byte {
+	return binary.AppendUvarint(b, uint64(v<<1^v>>63))
+}
+
+// appendAvroBytes appends v as length-prefixed bytes, which is also how
+// strings are encoded
+func appendAvroBytes(b []byte, v []byte) []byte {
+	return append(appendAvroLong(b, int64(len(v))), v...)
+}
+
+// snappyEncode appends the Snappy block encoding of src to dst. Matches are
+// found greedily through a hash table of 4-byte sequences.
+func snappyEncode(dst, src []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	const tableBits = 14
+	var table [1 << tableBits]int32
+	hash := func(i int) uint32 {
+		return binary.LittleEndian.Uint32(src[i:]) * 0x1e35a7bd >> (32 - tableBits)
+	}
+
+	literal := 0 // Start of the bytes not yet emitted
+	for i := 0; i+4 <= len(src); {
+		h := hash(i)
+		candidate := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if candidate < 0 || i-candidate > 65535 || !bytes.Equal(src[candidate:candidate+4], src[i:i+4]) {
+			i++
+			continue
+		}
+		dst = appendSnappyLiteral(dst, src[literal:i])
+		length := 4
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+		dst = appendSnappyCopy(dst, i-candidate, length)
+		i += length
+		literal = i
+	}
+	return appendSnappyLiteral(dst, src[literal:])
+}
+
+// appendSnappyLiteral appends a literal element holding lit
+func appendSnappyLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	switch n := len(lit) - 1; {
+	case n < 60:
+		dst = append(dst, byte(n)<<2)
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, lit...)
+}
+
+// appendSnappyCopy appends copy elements with 2-byte offsets for a match of
+// length bytes at offset back
+func appendSnappyCopy(dst []byte, offset, length int) []byte {
+	for length > 0 {
+		n := min(length, 64) // Copies hold at most 64 bytes
+		dst = append(dst, byte(n-1)<<2|2, byte(offset), byte(offset>>8))
+		length -= n
+	}
+	return dst
+}
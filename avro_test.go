@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// avroReader decodes an object container file written by AvroWriter
+type avroReader struct {
+	data []byte
+	t    *testing.T
+}
+
+func (r *avroReader) long() int64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.t.Fatalf("Malformed long")
+	}
+	r.data = r.data[n:]
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *avroReader) bytes() []byte {
+	n := r.long()
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
+
+// snappyDecode decodes the literal and 2-byte offset copy elements that
+// snappyEncode produces
+func snappyDecode(t *testing.T, src []byte) []byte {
+	size, n := binary.Uvarint(src)
+	src = src[n:]
+	var out []byte
+	for len(src) > 0 {
+		tag := src[0]
+		src = src[1:]
+		switch tag & 3 {
+		case 0:
+			length := int(tag >> 2)
+			if length >= 60 {
+				k := length - 59
+				length = 0
+				for i := 0; i < k; i++ {
+					length |= int(src[i]) << (8 * i)
+				}
+				src = src[k:]
+			}
+			out = append(out, src[:length+1]...)
+			src = src[length+1:]
+		case 2:
+			length := int(tag>>2) + 1
+			offset := int(binary.LittleEndian.Uint16(src))
+			src = src[2:]
+			for i := 0; i < length; i++ {
+				out = append(out, out[len(out)-offset])
+			}
+		default:
+			t.Fatalf("Unexpected element tag %d", tag)
+		}
+	}
+	if uint64(len(out)) != size {
+		t.Fatalf("Decoded %d bytes, header says %d", len(out), size)
+	}
+	return out
+}
+
+// readAvro returns the schema fields and the records of a container file,
+// each record's values joined by commas
+func readAvro(t *testing.T, data []byte) ([]string, []string) {
+	if !bytes.HasPrefix(data, []byte("Obj\x01")) {
+		t.Fatalf("Missing Avro magic")
+	}
+	r := &avroReader{data: data[4:], t: t}
+	meta := make(map[string][]byte)
+	for n := r.long(); n != 0; n = r.long() {
+		for i := int64(0); i < n; i++ {
+			key := string(r.bytes())
+			meta[key] = r.bytes()
+		}
+	}
+	sync := r.data[:16]
+	r.data = r.data[16:]
+
+	var schema struct {
+		Fields []struct{ Name, Type string }
+	}
+	if err := json.Unmarshal(meta["avro.schema"], &schema); err != nil {
+		t.Fatal(err)
+	}
+	var fields []string
+	for _, f := range schema.Fields {
+		fields = append(fields, f.Name+":"+f.Type)
+	}
+
+	var rows []string
+	for len(r.data) > 0 {
+		count := r.long()
+		block := r.bytes()
+		if !bytes.Equal(r.data[:16], sync) {
+			t.Fatalf("Block not followed by the sync marker")
+		}
+		r.data = r.data[16:]
+		switch string(meta["avro.codec"]) {
+		case avroCodecDeflate:
+			block, _ = io.ReadAll(flate.NewReader(bytes.NewReader(block)))
+		case avroCodecSnappy:
+			checksum := binary.BigEndian.Uint32(block[len(block)-4:])
+			block = snappyDecode(t, block[:len(block)-4])
+			if crc32.ChecksumIEEE(block) != checksum {
+				t.Fatalf("Block checksum mismatch")
+			}
+		}
+		records := &avroReader{data: block, t: t}
+		for i := int64(0); i < count; i++ {
+			values := make([]string, len(schema.Fields))
+			for j, f := range schema.Fields {
+				if f.Type == "long" {
+					values[j] = strconv.FormatInt(records.long(), 10)
+				} else {
+					values[j] = string(records.bytes())
+				}
+			}
+			rows = append(rows, strings.Join(values, ","))
+		}
+		if len(records.data) != 0 {
+			t.Fatalf("Block has %d bytes after its records", len(records.data))
+		}
+	}
+	return fields, rows
+}
+
+func TestAvroWriter(t *testing.T) {
+	// Values are encoded from the record, so commas in them are kept
+	layout := &rowLayout{linked: []string{"entity_name", "country"}, indices: make([]int, 20000)}
+	var records []Record
+	var rows []string
+	for i := range layout.indices {
+		layout.indices[i] = i * 7
+		records = append(records, Record{index: i, address: "0xFFaD25c5463eCb08ee91650a6530578598142dC6", linked: &LinkedColumns{columns: []string{"Yilmaz, Mateo", "KR"}}})
+		rows = append(rows, strconv.Itoa(i*7)+",Yilmaz, Mateo,KR,0xFFaD25c5463eCb08ee91650a6530578598142dC6")
+	}
+	columns := layout.outputColumns(false)
+
+	for _, codec := range []string{avroCodecNull, avroCodecDeflate, avroCodecSnappy} {
+		var out bytes.Buffer
+		aw, err := NewAvroWriter(&out, layout, columns, codec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range records {
+			if err := aw.WriteRecord(records[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		fields, got := readAvro(t, out.Bytes())
+		if want := []string{"index:long", "entity_name:string", "country:string", "address:string"}; !slices.Equal(fields, want) {
+			t.Errorf("%s: expected fields %v, got %v", codec, want, fields)
+		}
+		if !slices.Equal(got, rows) {
+			t.Errorf("%s: records differ from the written ones", codec)
+		}
+		if size := len(strings.Join(rows, "\n")); codec != avroCodecNull && out.Len() > size/2 {
+			t.Errorf("%s: %d bytes for %d bytes of repetitive rows", codec, out.Len(), size)
+		}
+	}
+
+	if _, err := NewAvroWriter(io.Discard, layout, columns, "zstd"); err == nil {
+		t.Error("Expected an error for an unknown codec")
+	}
+}
+
+func TestSnappyEncode(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		[]byte("abc"),
+		bytes.Repeat([]byte("a"), 1000),
+		bytes.Repeat([]byte("0123456789abcdef"), 10000),
+	}
+	random := make([]byte, 100000)
+	for i := range random {
+		random[i] = byte(i * 2654435761 >> 13)
+	}
+	inputs = append(inputs, random)
+	for _, input := range inputs {
+		if got := snappyDecode(t, snappyEncode(nil, input)); !bytes.Equal(got, input) {
+			t.Errorf("Round trip of %d bytes failed", len(input))
+		}
+	}
+}
+
+func TestOutputColumns(t *testing.T) {
+	tests := []struct {
+		layout  rowLayout
+		hashMap bool
+		want    string
+	}{
+		{want: "address"},
+		{layout: rowLayout{generateHash: true}, want: "hash,address"},
+		{layout: rowLayout{generateHash: true}, hashMap: true, want: "hash"},
+		{layout: rowLayout{hashOnly: true}, want: "keyed_hash"},
+		{layout: rowLayout{indices: []int{}, linked: solanaColumnNames(solanaAccountMultisig, "2-of-3")}, want: "index,threshold,signer_1,signer_2,signer_3,address"},
+		{layout: rowLayout{linked: append([]string{ensNameColumn}, entityLabelNames...), keys: true}, want: "ens_name,entity_name,country,kyc_tier,private_key,public_key,address"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(columnNames(tt.layout.outputColumns(tt.hashMap)), ",")
+		if got != tt.want {
+			t.Errorf("Expected %s, got %s", tt.want, got)
+		}
+	}
+
+	// Formats that name their fields add the index, network and HD path
+	layout := rowLayout{path: true, generateHash: true}
+	if got := strings.Join(columnNames(layout.structuredColumns()), ","); got != "index,network,path,hash,address" {
+		t.Errorf("Unexpected structured columns %s", got)
+	}
+}
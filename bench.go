@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// BenchConfig is one network and backend combination to benchmark
+type BenchConfig struct {
+	network     string
+	backend     string
+	hashBackend string
+}
+
+// BenchResult is the measured cost of generating a fixed batch
+type BenchResult struct {
+	config  BenchConfig
+	count   int
+	elapsed time.Duration
+	cpu     time.Duration // Zero when the platform cannot report CPU time
+	allocs  uint64
+	bytes   uint64
+	errors  int
+}
+
+// benchConfigs returns every combination covered by the self-test vectors
+func benchConfigs() []BenchConfig {
+	var configs []BenchConfig
+	seen := make(map[string]bool)
+	for _, v := range selftestVectors {
+		if name := v.config(); !seen[name] {
+			seen[name] = true
+			configs = append(configs, BenchConfig{v.network, v.backend, v.hashBackend})
+		}
+	}
+	return configs
+}
+
+// name identifies the configuration in the report
+func (c BenchConfig) name() string {
+	return SelftestVector{network: c.network, backend: c.backend, hashBackend: c.hashBackend}.config()
+}
+
+// benchmark generates count addresses for the configuration on workers
+// goroutines and measures wall time, CPU time and allocations
+func benchmark(config BenchConfig, count, workers int) BenchResult {
+	result := BenchResult{config: config, count: count}
+	errs := make([]int, workers)
+	var wg sync.WaitGroup
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	cpuBefore, cpuOK := processCPUTime()
+	start := time.Now()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			var generators workerGenerators
+			var buf []byte
+			job := Job{network: config.network, backend: config.backend, hashBackend: config.hashBackend}
+			for i := w; i < count; i += workers {
+				buf = addrmint.DeriveSeed(buf, selftestSeed, i, &job.seed)
+				if _, err := generateAddress(&job, &generators); err != nil {
+					errs[w]++
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	result.elapsed = time.Since(start)
+	if cpuAfter, ok := processCPUTime(); ok && cpuOK {
+		result.cpu = cpuAfter - cpuBefore
+	}
+	runtime.ReadMemStats(&after)
+	result.allocs = after.Mallocs - before.Mallocs
+	result.bytes = after.TotalAlloc - before.TotalAlloc
+	result.errors = sum(errs)
+	return result
+}
+
+// rate returns the addresses generated per second
+func (r BenchResult) rate() float64 {
+	return float64(r.count) / r.elapsed.Seconds()
+}
+
+// benchRows formats the results as report rows, with the cost of each
+// configuration relative to the fastest one
+func benchRows(results []BenchResult) [][]string {
+	fastest := 0.0
+	for _, r := range results {
+		fastest = max(fastest, r.rate())
+	}
+
+	rows := [][]string{{"network", "addresses/sec", "relative cost", "allocs/addr", "bytes/addr", "cpu/addr"}}
+	for _, r := range results {
+		cpu := "n/a"
+		if r.cpu > 0 {
+			cpu = (r.cpu / time.Duration(r.count)).String()
+		}
+		rows = append(rows, []string{
+			r.config.name(),
+			formatRate(r.rate()),
+			strconv.FormatFloat(fastest/r.rate(), 'f', 2, 64) + "x",
+			strconv.FormatFloat(float64(r.allocs)/float64(r.count), 'f', 1, 64),
+			formatCount(int(r.bytes / uint64(r.count))),
+			cpu,
+		})
+	}
+	return rows
+}
+
+// writeBenchTable writes report rows as an aligned table
+func writeBenchTable(w io.Writer, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// runBench implements the bench subcommand and returns the exit code
+func runBench(args []string) int {
+	if len(args) > 0 && args[0] == "compare" {
+		return runBenchCompare(args[1:])
+	}
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	count := fs.Int("count", 5000, "Number of addresses to generate for each network and backend")
+	workers := fs.Int("workers", 1, "Number of worker goroutines for each batch")
+	format := fs.String("format", "table", "Report format (table, csv)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	numberFormatFlag := fs.String("number-format", numberFormatGrouped, "Number format for the report (grouped, si, raw)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint bench [--count N] [--workers N] [--format table|csv]\n")
+		fmt.Fprintf(fs.Output(), "       addrmint bench compare [--threshold PERCENT] OLD NEW\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+	if err := setupNumberFormat(*numberFormatFlag); err != nil {
+		fatalf("Invalid --number-format: %v", err)
+	}
+	if *count < 1 || *workers < 1 {
+		fatalf("Count and workers must be positive")
+	}
+	if *format != "table" && *format != "csv" {
+		fatalf("Format must be table or csv")
+	}
+	if *format == "csv" {
+		// CSV reports are meant for tools, so numbers are never grouped
+		numberFormat = numberFormatRaw
+	}
+
+	printBanner("AddrMint v%s - Benchmark", version)
+	fmt.Fprintf(os.Stderr, "Platform: %s/%s, %d CPUs\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	fmt.Fprintf(os.Stderr, "Hash backends: %s, %s\n", hashBackendInfo(hashBackendGeth), hashBackendInfo(hashBackendReused))
+
+	var results []BenchResult
+	failed := false
+	for _, config := range benchConfigs() {
+		fmt.Fprintf(os.Stderr, "Generating %s %s addresses...\n", formatCount(*count), config.name())
+		result := benchmark(config, *count, *workers)
+		if result.errors > 0 {
+			printWarning("%s: %s addresses failed to generate", config.name(), formatCount(result.errors))
+			failed = true
+		}
+		results = append(results, result)
+	}
+
+	rows := benchRows(results)
+	var err error
+	if *format == "csv" {
+		writer := csv.NewWriter(os.Stdout)
+		writer.WriteAll(rows)
+		err = writer.Error()
+	} else {
+		err = writeBenchTable(os.Stdout, rows)
+	}
+	if err != nil {
+		fatalf("Failed to write report: %v", err)
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
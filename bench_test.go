@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBenchConfigs(t *testing.T) {
+	expected := []string{"ethereum/geth", "ethereum/reused", "bitcoin", "solana/sdk", "solana/native", "ton"}
+	configs := benchConfigs()
+	if len(configs) != len(expected) {
+		t.Fatalf("Expected %d configurations, got %d", len(expected), len(configs))
+	}
+	for i, config := range configs {
+		if config.name() != expected[i] {
+			t.Errorf("Configuration %d: expected %s, got %s", i, expected[i], config.name())
+		}
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	result := benchmark(BenchConfig{"ethereum", backendSDK, hashBackendReused}, 50, 3)
+	if result.count != 50 || result.errors != 0 {
+		t.Errorf("Expected 50 addresses without errors, got %d with %d errors", result.count, result.errors)
+	}
+	if result.elapsed <= 0 || result.allocs == 0 {
+		t.Errorf("Expected elapsed time and allocations to be measured, got %s and %d", result.elapsed, result.allocs)
+	}
+}
+
+func TestBenchRows(t *testing.T) {
+	defer setupNumberFormat(numberFormatGrouped)
+	setupNumberFormat(numberFormatRaw)
+
+	results := []BenchResult{
+		{config: BenchConfig{network: "bitcoin"}, count: 1000, elapsed: 2 * time.Second, allocs: 15000, bytes: 2000000},
+		{config: BenchConfig{network: "ton"}, count: 1000, elapsed: time.Second, allocs: 40000, bytes: 1200000, cpu: 500 * time.Millisecond},
+	}
+	rows := benchRows(results)
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d rows", len(rows))
+	}
+	expected := [][]string{
+		{"bitcoin", "500.00", "2.00x", "15.0", "2000", "n/a"},
+		{"ton", "1000.00", "1.00x", "40.0", "1200", "500µs"},
+	}
+	for i, want := range expected {
+		for j := range want {
+			if rows[i+1][j] != want[j] {
+				t.Errorf("Row %d column %s: expected %q, got %q", i, rows[0][j], want[j], rows[i+1][j])
+			}
+		}
+	}
+}
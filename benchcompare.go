@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BenchSamples holds the measurements of one benchmark, one entry per run
+type BenchSamples struct {
+	nsPerOp     []float64
+	allocsPerOp []float64
+}
+
+// BenchFile is a parsed benchmark result file with its benchmarks in the
+// order they first appear
+type BenchFile struct {
+	names   []string
+	samples map[string]*BenchSamples
+}
+
+// add records one run of a benchmark
+func (f *BenchFile) add(name string, nsPerOp, allocsPerOp float64) {
+	s, ok := f.samples[name]
+	if !ok {
+		s = &BenchSamples{}
+		f.samples[name] = s
+		f.names = append(f.names, name)
+	}
+	s.nsPerOp = append(s.nsPerOp, nsPerOp)
+	if allocsPerOp >= 0 {
+		s.allocsPerOp = append(s.allocsPerOp, allocsPerOp)
+	}
+}
+
+// parseBenchFile reads the output of go test -bench, where each benchmark
+// may appear several times with -count, or a CSV report of addrmint bench.
+// The GOMAXPROCS suffix is dropped from Go benchmark names so results from
+// machines with different CPU counts line up.
+func parseBenchFile(r io.Reader) (*BenchFile, error) {
+	f := &BenchFile{samples: make(map[string]*BenchSamples)}
+	reader := bufio.NewReader(r)
+	if head, _ := reader.Peek(len("network,")); string(head) == "network," {
+		return f, parseBenchCSV(reader, f)
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndexByte(name, '-'); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		nsPerOp, allocsPerOp := -1.0, -1.0
+		// Measurements follow the iteration count as value and unit pairs
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("benchmark %s: invalid %s value %q", name, fields[i+1], fields[i])
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				nsPerOp = value
+			case "allocs/op":
+				allocsPerOp = value
+			}
+		}
+		if nsPerOp < 0 {
+			return nil, fmt.Errorf("benchmark %s has no ns/op", name)
+		}
+		f.add(name, nsPerOp, allocsPerOp)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(f.names) == 0 {
+		return nil, fmt.Errorf("no benchmark results found")
+	}
+	return f, nil
+}
+
+// parseBenchCSV reads an addrmint bench CSV report into f, turning the
+// rate of each configuration into the time per address
+func parseBenchCSV(r io.Reader, f *BenchFile) error {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return err
+	}
+	header := rows[0]
+	rateColumn, allocsColumn := slices.Index(header, "addresses/sec"), slices.Index(header, "allocs/addr")
+	if rateColumn < 0 || allocsColumn < 0 {
+		return fmt.Errorf("not an addrmint bench report: missing addresses/sec or allocs/addr")
+	}
+	for _, row := range rows[1:] {
+		rate, err := strconv.ParseFloat(row[rateColumn], 64)
+		if err != nil || rate <= 0 {
+			return fmt.Errorf("%s: invalid rate %q", row[0], row[rateColumn])
+		}
+		allocs, err := strconv.ParseFloat(row[allocsColumn], 64)
+		if err != nil {
+			return fmt.Errorf("%s: invalid allocations %q", row[0], row[allocsColumn])
+		}
+		f.add("bench/"+row[0], 1e9/rate, allocs)
+	}
+	if len(f.names) == 0 {
+		return fmt.Errorf("no benchmark results found")
+	}
+	return nil
+}
+
+// median returns the median of values, or -1 if there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return -1
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	if n := len(sorted); n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[len(sorted)/2]
+}
+
+// BenchChange is how one benchmark moved between two result files
+type BenchChange struct {
+	name                 string
+	oldNs, newNs         float64 // Median time per operation, -1 when absent
+	oldAllocs, newAllocs float64 // Median allocations per operation, -1 when absent
+	regressed            bool
+}
+
+// compareBenchFiles pairs up the benchmarks of two files. A benchmark
+// regressed when its median time grew by more than threshold percent or
+// its median allocations grew at all. Benchmarks found in only one file
+// are listed without counting as regressions.
+func compareBenchFiles(old, new *BenchFile, threshold float64) []BenchChange {
+	var changes []BenchChange
+	names := slices.Clone(old.names)
+	for _, name := range new.names {
+		if old.samples[name] == nil {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		change := BenchChange{name: name, oldNs: -1, newNs: -1, oldAllocs: -1, newAllocs: -1}
+		if s := old.samples[name]; s != nil {
+			change.oldNs, change.oldAllocs = median(s.nsPerOp), median(s.allocsPerOp)
+		}
+		if s := new.samples[name]; s != nil {
+			change.newNs, change.newAllocs = median(s.nsPerOp), median(s.allocsPerOp)
+		}
+		if change.oldNs > 0 && change.newNs >= 0 {
+			change.regressed = (change.newNs-change.oldNs)/change.oldNs*100 > threshold ||
+				(change.oldAllocs >= 0 && change.newAllocs > change.oldAllocs)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// benchCompareRows formats the changes as report rows
+func benchCompareRows(changes []BenchChange) [][]string {
+	value := func(v float64, decimals int) string {
+		if v < 0 {
+			return "-"
+		}
+		return strconv.FormatFloat(v, 'f', decimals, 64)
+	}
+	rows := [][]string{{"benchmark", "old ns/op", "new ns/op", "delta", "old allocs/op", "new allocs/op", ""}}
+	for _, c := range changes {
+		delta := "-"
+		if c.oldNs > 0 && c.newNs >= 0 {
+			delta = fmt.Sprintf("%+.1f%%", (c.newNs-c.oldNs)/c.oldNs*100)
+		}
+		verdict := ""
+		switch {
+		case c.regressed:
+			verdict = "REGRESSION"
+		case c.oldNs < 0:
+			verdict = "added"
+		case c.newNs < 0:
+			verdict = "removed"
+		}
+		rows = append(rows, []string{c.name, value(c.oldNs, 1), value(c.newNs, 1), delta, value(c.oldAllocs, 1), value(c.newAllocs, 1), verdict})
+	}
+	return rows
+}
+
+// runBenchCompare implements bench compare, which diffs two benchmark
+// result files and returns 1 if any benchmark regressed
+func runBenchCompare(args []string) int {
+	fs := flag.NewFlagSet("bench compare", flag.ExitOnError)
+	threshold := fs.Float64("threshold", 10, "Percent slowdown of a benchmark's median time that counts as a regression")
+	format := fs.String("format", "table", "Report format (table, csv)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint bench compare [--threshold PERCENT] OLD NEW\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+	if fs.NArg() != 2 {
+		fatalf("bench compare takes an old and a new result file")
+	}
+	if *threshold < 0 {
+		fatalf("--threshold cannot be negative")
+	}
+	if *format != "table" && *format != "csv" {
+		fatalf("Format must be table or csv")
+	}
+
+	var files [2]*BenchFile
+	for i, path := range fs.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			fatalf("Failed to open results: %v", err)
+		}
+		files[i], err = parseBenchFile(file)
+		file.Close()
+		if err != nil {
+			fatalf("Failed to read %s: %v", path, err)
+		}
+	}
+
+	changes := compareBenchFiles(files[0], files[1], *threshold)
+	rows := benchCompareRows(changes)
+	var err error
+	if *format == "csv" {
+		writer := csv.NewWriter(os.Stdout)
+		writer.WriteAll(rows)
+		err = writer.Error()
+	} else {
+		err = writeBenchTable(os.Stdout, rows)
+	}
+	if err != nil {
+		fatalf("Failed to write report: %v", err)
+	}
+
+	regressed := 0
+	for _, c := range changes {
+		if c.regressed {
+			regressed++
+		}
+	}
+	if regressed > 0 {
+		fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, fmt.Sprintf("%d of %d benchmarks regressed", regressed, len(changes))))
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "No regressions in %d benchmarks\n", len(changes))
+	return 0
+}
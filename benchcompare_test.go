@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const oldBenchOutput = `goos: linux
+goarch: amd64
+pkg: github.com/cipherowl-ai/AddrMint/pkg/addrmint
+BenchmarkGenerators/ethereum-8   	   30000	     40000 ns/op	     736 B/op	      15 allocs/op
+BenchmarkGenerators/ethereum-8   	   30000	     42000 ns/op	     736 B/op	      15 allocs/op
+BenchmarkGenerators/ethereum-8   	   30000	     90000 ns/op	     736 B/op	      15 allocs/op
+BenchmarkGenerators/ton-8        	   50000	     20000 ns/op	    1208 B/op	      40 allocs/op
+BenchmarkAsyncWriter-8           	 1000000	      1000 ns/op	  43.00 MB/s	       0 allocs/op
+BenchmarkRemoved-8               	 1000000	       100 ns/op
+PASS
+`
+
+const newBenchOutput = `BenchmarkGenerators/ethereum-16  	   30000	     41000 ns/op	     736 B/op	      15 allocs/op
+BenchmarkGenerators/ton-16       	   50000	     20500 ns/op	    1240 B/op	      41 allocs/op
+BenchmarkAsyncWriter-16          	 1000000	      1200 ns/op	  35.83 MB/s	       0 allocs/op
+BenchmarkAdded-16                	 1000000	       100 ns/op
+`
+
+func TestCompareBenchFiles(t *testing.T) {
+	old, err := parseBenchFile(strings.NewReader(oldBenchOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := parseBenchFile(strings.NewReader(newBenchOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples := old.samples["BenchmarkGenerators/ethereum"]; samples == nil || len(samples.nsPerOp) != 3 {
+		t.Fatalf("Expected 3 ethereum samples with the CPU suffix dropped, got %+v", old.samples)
+	}
+
+	changes := compareBenchFiles(old, new, 10)
+	expected := []struct {
+		name      string
+		oldNs     float64
+		regressed bool
+	}{
+		{"BenchmarkGenerators/ethereum", 42000, false}, // The 90000 outlier does not move the median
+		{"BenchmarkGenerators/ton", 20000, true},       // One more allocation
+		{"BenchmarkAsyncWriter", 1000, true},           // 20% slower
+		{"BenchmarkRemoved", 100, false},
+		{"BenchmarkAdded", -1, false},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, want := range expected {
+		c := changes[i]
+		if c.name != want.name || c.oldNs != want.oldNs || c.regressed != want.regressed {
+			t.Errorf("Change %d: expected %s from %.0f (regressed %t), got %s from %.0f (regressed %t)",
+				i, want.name, want.oldNs, want.regressed, c.name, c.oldNs, c.regressed)
+		}
+	}
+
+	rows := benchCompareRows(changes)
+	if rows[1][3] != "-2.4%" || rows[3][6] != "REGRESSION" || rows[4][6] != "removed" || rows[5][6] != "added" {
+		t.Errorf("Unexpected report rows %v", rows)
+	}
+	// A looser threshold lets the slower writer pass
+	if changes := compareBenchFiles(old, new, 25); changes[2].regressed {
+		t.Error("Expected a 20% slowdown to pass a 25% threshold")
+	}
+}
+
+func TestParseBenchCSV(t *testing.T) {
+	report := "network,addresses/sec,relative cost,allocs/addr,bytes/addr,cpu/addr\n" +
+		"bitcoin,500.00,2.00x,15.0,2000,n/a\n" +
+		"ton,1000.00,1.00x,40.0,1200,500µs\n"
+	f, err := parseBenchFile(strings.NewReader(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := f.samples["bench/bitcoin"]; s == nil || s.nsPerOp[0] != 2e6 || s.allocsPerOp[0] != 15 {
+		t.Errorf("Expected bitcoin at 2ms and 15 allocations per address, got %+v", s)
+	}
+	if _, err := parseBenchFile(strings.NewReader("PASS\n")); err == nil {
+		t.Error("Expected a file without benchmarks to be rejected")
+	}
+}
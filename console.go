@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// ANSI escape sequences for colored output
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorCyan   = "\x1b[36m"
+)
+
+// useColor controls whether messages on stderr are colorized
+var useColor = false
+
+// setupColor enables colors when stderr is a terminal, unless disabled with
+// --no-color or the NO_COLOR environment variable (https://no-color.org)
+func setupColor(noColor bool) {
+	useColor = !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr)
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize wraps s in the given color when colors are enabled
+func colorize(color, s string) string {
+	if !useColor {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// printBanner prints a highlighted banner line to stderr
+func printBanner(format string, args ...interface{}) {
+	fmt.Fprintln(os.Stderr, colorize(colorBold+colorCyan, fmt.Sprintf(format, args...)))
+}
+
+// printWarning prints a warning to stderr, with secrets redacted
+func printWarning(format string, args ...interface{}) {
+	fmt.Fprintln(os.Stderr, colorize(colorBold+colorYellow, "Warning: "+redact(fmt.Sprintf(format, args...))))
+}
+
+// fatalf prints an error to stderr, with secrets redacted, and exits
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, "Error: "+redact(fmt.Sprintf(format, args...))))
+	os.Exit(1)
+}
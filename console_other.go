@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// prepareConsole reports whether the terminal can render the Unicode
+// progress bar, which is always the case outside Windows
+func prepareConsole() bool {
+	return true
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestColorize tests that colors are only applied when enabled
+func TestColorize(t *testing.T) {
+	defer func(enabled bool) { useColor = enabled }(useColor)
+
+	useColor = false
+	if got := colorize(colorRed, "error"); got != "error" {
+		t.Errorf("Expected plain text without color, got %q", got)
+	}
+
+	useColor = true
+	if got := colorize(colorRed, "error"); got != colorRed+"error"+colorReset {
+		t.Errorf("Expected colored text, got %q", got)
+	}
+}
+
+// TestSetupColorNoColor tests that NO_COLOR and --no-color disable colors
+func TestSetupColorNoColor(t *testing.T) {
+	defer func(enabled bool) { useColor = enabled }(useColor)
+
+	t.Setenv("NO_COLOR", "1")
+	setupColor(false)
+	if useColor {
+		t.Error("Expected NO_COLOR to disable colors")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	setupColor(true)
+	if useColor {
+		t.Error("Expected --no-color to disable colors")
+	}
+}
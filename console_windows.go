@@ -0,0 +1,28 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// cpUTF8 is the Windows code page identifier for UTF-8
+const cpUTF8 = 65001
+
+// prepareConsole switches the Windows console to UTF-8 output and enables
+// virtual terminal processing. It reports whether the console can render
+// the Unicode progress bar.
+func prepareConsole() bool {
+	handle := windows.Handle(os.Stderr.Fd())
+
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// Not a console (e.g. redirected to a file), the bytes pass through untouched
+		return true
+	}
+	windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+
+	return windows.SetConsoleOutputCP(cpUTF8) == nil
+}
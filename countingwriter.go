@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+)
+
+// CountingWriter counts the rows (newline terminated lines) and bytes that
+// have been written to the underlying writer, so progress reflects written
+// output rather than results that are still buffered
+type CountingWriter struct {
+	w     io.Writer
+	rows  atomic.Int64
+	bytes atomic.Int64
+}
+
+// NewCountingWriter wraps w with a row counter
+func NewCountingWriter(w io.Writer) *CountingWriter {
+	return &CountingWriter{w: w}
+}
+
+// Write writes p to the underlying writer and counts the rows that made it
+func (cw *CountingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.rows.Add(int64(bytes.Count(p[:n], []byte{'\n'})))
+	cw.bytes.Add(int64(n))
+	return n, err
+}
+
+// Rows returns the number of rows written so far
+func (cw *CountingWriter) Rows() int64 {
+	return cw.rows.Load()
+}
+
+// Bytes returns the number of bytes written so far
+func (cw *CountingWriter) Bytes() int64 {
+	return cw.bytes.Load()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"runtime/debug"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+// maxDiffReports is the most mismatches listed per network
+const maxDiffReports = 5
+
+// DiffImplementation is one independent way of deriving a network's
+// addresses from a seed
+type DiffImplementation struct {
+	name     string
+	generate func(seed *[32]byte) (string, error)
+}
+
+// DiffNetwork lists the implementations cross-checked for a network
+type DiffNetwork struct {
+	network         string
+	implementations []DiffImplementation
+}
+
+// backendImplementation derives addresses through the worker code path
+// with the given crypto and hash backends
+func backendImplementation(name, network, backend, hashBackend string) DiffImplementation {
+	var generators workerGenerators
+	job := Job{network: network, backend: backend, hashBackend: hashBackend}
+	return DiffImplementation{name: name, generate: func(seed *[32]byte) (string, error) {
+		job.seed = *seed
+		return generateAddress(&job, &generators)
+	}}
+}
+
+// referenceEthereumAddress derives an Ethereum address with the pure-Go
+// btcec curve and x/crypto Keccak-256, independently of go-ethereum's
+// secp256k1, which is libsecp256k1 through cgo in cgo builds
+func referenceEthereumAddress(seed *[32]byte) (string, error) {
+	key, err := referencePrivateKey(seed)
+	if err != nil {
+		return "", err
+	}
+	keccak := sha3.NewLegacyKeccak256()
+	keccak.Write(key.PubKey().SerializeUncompressed()[1:])
+	return common.BytesToAddress(keccak.Sum(nil)[12:]).Hex(), nil
+}
+
+// referenceBitcoinAddress derives a legacy P2PKH address by hand:
+// Base58Check of version 0 and the HASH160 of the compressed public key,
+// independently of btcutil's address encoding
+func referenceBitcoinAddress(seed *[32]byte) (string, error) {
+	key, err := referencePrivateKey(seed)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(key.PubKey().SerializeCompressed())
+	hasher := ripemd160.New()
+	hasher.Write(digest[:])
+	payload := hasher.Sum([]byte{0x00})
+	first := sha256.Sum256(payload)
+	checksum := sha256.Sum256(first[:])
+	return base58.Encode(append(payload, checksum[:4]...)), nil
+}
+
+// referencePrivateKey rejects seeds outside the secp256k1 order like the
+// generators do, instead of reducing them as btcec would
+func referencePrivateKey(seed *[32]byte) (*btcec.PrivateKey, error) {
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetBytes(seed); overflow != 0 || scalar.IsZero() {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	return btcec.PrivKeyFromScalar(&scalar), nil
+}
+
+// diffNetworks returns every network with more than one implementation:
+// the interchangeable backends, and a reference derivation for the
+// networks whose generators share a single curve library
+func diffNetworks() []DiffNetwork {
+	return []DiffNetwork{
+		{"ethereum", []DiffImplementation{
+			backendImplementation("geth", "ethereum", backendSDK, hashBackendGeth),
+			backendImplementation("reused", "ethereum", backendSDK, hashBackendReused),
+			{name: "reference", generate: referenceEthereumAddress},
+		}},
+		{"bitcoin", []DiffImplementation{
+			backendImplementation("btcutil", "bitcoin", backendSDK, hashBackendGeth),
+			{name: "reference", generate: referenceBitcoinAddress},
+		}},
+		{"solana", []DiffImplementation{
+			backendImplementation("sdk", "solana", backendSDK, hashBackendGeth),
+			backendImplementation("native", "solana", backendNative, hashBackendGeth),
+		}},
+	}
+}
+
+// differential derives count addresses from baseSeed with every
+// implementation of each network, reports disagreements to w and returns
+// their number. Implementations agree on a seed when they derive the same
+// address or all reject it.
+func differential(w io.Writer, networks []DiffNetwork, baseSeed string, count int) int {
+	var buf []byte
+	var seed [32]byte
+	failed := 0
+
+	for _, n := range networks {
+		mismatches := 0
+		results := make([]string, len(n.implementations))
+		for i := 0; i < count; i++ {
+			buf = addrmint.DeriveSeed(buf, baseSeed, i, &seed)
+			rejected, agree := 0, true
+			for j, impl := range n.implementations {
+				addr, err := impl.generate(&seed)
+				if err != nil {
+					rejected++
+					addr = "error: " + err.Error()
+				}
+				results[j] = addr
+				agree = agree && addr == results[0]
+			}
+			if agree || rejected == len(n.implementations) {
+				continue
+			}
+			if mismatches++; mismatches <= maxDiffReports {
+				fmt.Fprintf(w, "%s %-9s #%d:", colorize(colorBold+colorRed, "DIFF"), n.network, i)
+				for j, impl := range n.implementations {
+					fmt.Fprintf(w, " %s=%s", impl.name, results[j])
+				}
+				fmt.Fprintln(w)
+			}
+		}
+
+		names := make([]string, len(n.implementations))
+		for j, impl := range n.implementations {
+			names[j] = impl.name
+		}
+		if mismatches > 0 {
+			failed += mismatches
+			fmt.Fprintf(w, "%s %-9s %s of %s addresses differ across %v\n",
+				colorize(colorBold+colorRed, "FAIL"), n.network, formatCount(mismatches), formatCount(count), names)
+		} else {
+			fmt.Fprintf(w, "%s %-9s %s addresses agree across %v\n", colorize(colorBold, "PASS"), n.network, formatCount(count), names)
+		}
+	}
+	return failed
+}
+
+// secp256k1Info describes the secp256k1 implementation go-ethereum was
+// built with
+func secp256k1Info() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "CGO_ENABLED" && setting.Value == "1" {
+				return "libsecp256k1 (cgo)"
+			}
+		}
+	}
+	return "decred secp256k1 (pure Go)"
+}
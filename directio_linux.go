@@ -0,0 +1,27 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openDirect creates the output file with O_DIRECT so writes bypass the
+// page cache, with permissions perm if it does not exist
+func openDirect(name string, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|syscall.O_DIRECT, perm)
+}
+
+// disableDirectIO clears O_DIRECT on an open file so unaligned writes succeed
+func disableDirectIO(file *os.File) error {
+	fd := int(file.Fd())
+	flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFL, 0)
+	if err != nil {
+		return err
+	}
+	_, err = unix.FcntlInt(uintptr(fd), unix.F_SETFL, flags&^unix.O_DIRECT)
+	return err
+}
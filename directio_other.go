@@ -0,0 +1,21 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// errDirectIOUnsupported is returned on platforms without O_DIRECT
+var errDirectIOUnsupported = errors.New("direct I/O is only supported on Linux")
+
+// openDirect is not supported on this platform
+func openDirect(name string, perm os.FileMode) (*os.File, error) {
+	return nil, errDirectIOUnsupported
+}
+
+// disableDirectIO is not supported on this platform
+func disableDirectIO(file *os.File) error {
+	return errDirectIOUnsupported
+}
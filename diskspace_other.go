@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package main
+
+// freeSpace is not supported on this platform
+func freeSpace(dir string) (int64, error) {
+	return 0, errFreeSpaceUnsupported
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// minSeedShareBytes is the shortest seed share accepted, so each share
+// alone is out of reach of brute force
+const minSeedShareBytes = 16
+
+// seedEscrowSalt is the HKDF salt that combines seed shares
+const seedEscrowSalt = "addrmint/seed-escrow"
+
+// loadSeedShares reads the hex encoded seed shares of --seed-shares, one
+// per file. Shares must be distinct so that one operator cannot stand in
+// for another by copying their file.
+func loadSeedShares(paths []string) ([][]byte, error) {
+	if len(paths) < 2 {
+		return nil, fmt.Errorf("split knowledge needs at least 2 shares, got %d", len(paths))
+	}
+	var shares [][]byte
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		share, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(share) < minSeedShareBytes {
+			return nil, fmt.Errorf("%s does not contain a hex share of at least %d bytes", path, minSeedShareBytes)
+		}
+		for _, other := range shares {
+			if hmac.Equal(share, other) {
+				return nil, fmt.Errorf("%s repeats another share", path)
+			}
+		}
+		shares = append(shares, share)
+	}
+	return shares, nil
+}
+
+// combineSeedShares derives the base seed from all shares with
+// HKDF-Extract, taking each share with a 4-byte length prefix. Shares are
+// sorted first, so the order they are listed in does not matter. Without
+// every share the base seed is unpredictable.
+func combineSeedShares(shares [][]byte) string {
+	sorted := slices.Clone(shares)
+	slices.SortFunc(sorted, func(a, b []byte) int { return strings.Compare(string(a), string(b)) })
+
+	extract := hmac.New(sha256.New, []byte(seedEscrowSalt))
+	for _, share := range sorted {
+		extract.Write(binary.BigEndian.AppendUint32(nil, uint32(len(share))))
+		extract.Write(share)
+	}
+	return hex.EncodeToString(extract.Sum(nil))
+}
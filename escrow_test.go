@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeedShares(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	alice := write("alice.key", strings.Repeat("a1", 32))
+	bob := write("bob.key", strings.Repeat("b2", 32))
+	carol := write("carol.key", strings.Repeat("c3", 16))
+
+	shares, err := loadSeedShares([]string{alice, bob})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := combineSeedShares(shares)
+	if len(seed) != 64 {
+		t.Errorf("Expected a 32-byte hex seed, got %q", seed)
+	}
+
+	// The listed order does not matter, every share does
+	swapped, _ := loadSeedShares([]string{bob, alice})
+	if got := combineSeedShares(swapped); got != seed {
+		t.Errorf("Expected the same seed for swapped shares, got %s and %s", seed, got)
+	}
+	three, _ := loadSeedShares([]string{alice, bob, carol})
+	if got := combineSeedShares(three); got == seed {
+		t.Error("Expected a third share to change the seed")
+	}
+
+	invalid := [][]string{
+		{alice},
+		{alice, write("copy.key", strings.Repeat("a1", 32))},
+		{alice, write("short.key", "abcdef")},
+		{alice, write("text.key", "not a share")},
+		{alice, filepath.Join(dir, "missing.key")},
+	}
+	for _, paths := range invalid {
+		if _, err := loadSeedShares(paths); err == nil {
+			t.Errorf("Expected shares %v to be rejected", paths)
+		}
+	}
+}
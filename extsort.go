@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// stringOverhead approximates the memory used by a string header in a slice
+const stringOverhead = 16
+
+// mergeFanIn is the most run files merged at once, keeping open files well
+// below the usual descriptor limits
+var mergeFanIn = 256
+
+// ExternalSorter sorts more lines than fit in memory. Lines are buffered
+// until the memory limit is reached, then sorted and spilled to a run file
+// in the temp directory; Merge streams all runs back in sorted order.
+type ExternalSorter struct {
+	tempDir     string
+	memoryLimit int64
+	lines       []string
+	used        int64
+	runs        []string // Paths of the spilled run files
+	cipher      *TempCipher
+}
+
+// NewExternalSorter creates a sorter that spills to tempDir whenever the
+// buffered lines exceed memoryLimit bytes
+func NewExternalSorter(tempDir string, memoryLimit int64) *ExternalSorter {
+	return &ExternalSorter{tempDir: tempDir, memoryLimit: memoryLimit}
+}
+
+// SetCipher encrypts the run files, which are plaintext by default
+func (s *ExternalSorter) SetCipher(c *TempCipher) {
+	s.cipher = c
+}
+
+// Add buffers a line, spilling a sorted run to disk when memory is full
+func (s *ExternalSorter) Add(line string) error {
+	s.lines = append(s.lines, line)
+	s.used += int64(len(line)) + stringOverhead
+	if s.used >= s.memoryLimit {
+		return s.spill()
+	}
+	return nil
+}
+
+// Runs returns the number of run files spilled to disk so far
+func (s *ExternalSorter) Runs() int {
+	return len(s.runs)
+}
+
+// spill sorts the buffered lines and writes them to a new run file
+func (s *ExternalSorter) spill() error {
+	sort.Strings(s.lines)
+
+	file, err := os.CreateTemp(s.tempDir, "addrmint-sort-*")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, file.Name())
+	defer file.Close()
+
+	sealed := s.cipher.Writer(file)
+	writer := bufio.NewWriterSize(sealed, 64*1024)
+	for _, line := range s.lines {
+		writer.WriteString(line)
+		writer.WriteByte('\n')
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = sealed.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write sort run: %w", err)
+	}
+
+	s.lines = s.lines[:0]
+	s.used = 0
+	return file.Close()
+}
+
+// Merge calls emit for every line in sorted order, duplicates included
+func (s *ExternalSorter) Merge(emit func(string) error) error {
+	// Everything fit in memory, no need to touch the disk
+	if len(s.runs) == 0 {
+		sort.Strings(s.lines)
+		for _, line := range s.lines {
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(s.lines) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+
+	// Merge runs in groups until few enough remain to open at once
+	for len(s.runs) > mergeFanIn {
+		if err := s.mergeGroup(); err != nil {
+			return err
+		}
+	}
+	return mergeRuns(s.runs, s.cipher, emit)
+}
+
+// mergeGroup merges the oldest mergeFanIn runs into a single new run
+func (s *ExternalSorter) mergeGroup() error {
+	group := s.runs[:mergeFanIn]
+
+	file, err := os.CreateTemp(s.tempDir, "addrmint-sort-*")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	s.runs = append(s.runs, file.Name())
+
+	sealed := s.cipher.Writer(file)
+	writer := bufio.NewWriterSize(sealed, 64*1024)
+	err = mergeRuns(group, s.cipher, func(line string) error {
+		writer.WriteString(line)
+		return writer.WriteByte('\n')
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = sealed.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write sort run: %w", err)
+	}
+
+	for _, path := range group {
+		os.Remove(path)
+	}
+	s.runs = s.runs[mergeFanIn:]
+	return file.Close()
+}
+
+// mergeRuns calls emit for every line of the sorted run files in order,
+// using a min-heap of the runs' current lines. The runs are decrypted with c.
+func mergeRuns(paths []string, c *TempCipher, emit func(string) error) error {
+	var h runHeap
+	defer func() { h.close() }()
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		run := &sortRun{file: file, scanner: bufio.NewScanner(c.Reader(file))}
+		if !run.next() {
+			err := run.scanner.Err()
+			file.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		h = append(h, run)
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		run := h[0]
+		if err := emit(run.line); err != nil {
+			return err
+		}
+		if run.next() {
+			heap.Fix(&h, 0)
+			continue
+		}
+		if err := run.scanner.Err(); err != nil {
+			return err
+		}
+		run.file.Close()
+		heap.Pop(&h)
+	}
+	return nil
+}
+
+// Close removes the run files
+func (s *ExternalSorter) Close() error {
+	var firstErr error
+	for _, path := range s.runs {
+		if err := os.Remove(path); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.runs = nil
+	return firstErr
+}
+
+// sortRun is one spilled run being merged
+type sortRun struct {
+	file    *os.File
+	scanner *bufio.Scanner
+	line    string
+}
+
+// next advances to the run's next line
+func (r *sortRun) next() bool {
+	if !r.scanner.Scan() {
+		return false
+	}
+	r.line = r.scanner.Text()
+	return true
+}
+
+// runHeap orders runs by their current line
+type runHeap []*sortRun
+
+func (h runHeap) Len() int           { return len(h) }
+func (h runHeap) Less(i, j int) bool { return h[i].line < h[j].line }
+func (h runHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*sortRun)) }
+
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	run := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return run
+}
+
+// close closes the files of runs that are still open
+func (h runHeap) close() {
+	for _, run := range h {
+		run.file.Close()
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+)
+
+func TestExternalSorter(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf("0x%040x", rng.Intn(2000)))
+	}
+
+	// Force multi-pass merges with the small limits
+	defer func(fanIn int) { mergeFanIn = fanIn }(mergeFanIn)
+	mergeFanIn = 4
+
+	// Run files are encrypted the second time round
+	cipher, err := NewTempCipher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, encrypt := range []bool{false, true} {
+		for _, limit := range []int64{1 << 30, 4096, 512} {
+			dir := t.TempDir()
+			sorter := NewExternalSorter(dir, limit)
+			if encrypt {
+				sorter.SetCipher(cipher)
+			}
+			for _, line := range lines {
+				if err := sorter.Add(line); err != nil {
+					t.Fatalf("Add failed: %v", err)
+				}
+			}
+
+			var merged []string
+			if err := sorter.Merge(func(line string) error {
+				merged = append(merged, line)
+				return nil
+			}); err != nil {
+				t.Fatalf("Merge failed (limit %d, encrypted %v): %v", limit, encrypt, err)
+			}
+
+			expected := append([]string(nil), lines...)
+			sort.Strings(expected)
+			if len(merged) != len(expected) {
+				t.Fatalf("Expected %d lines, got %d (limit %d, encrypted %v)", len(expected), len(merged), limit, encrypt)
+			}
+			for i := range expected {
+				if merged[i] != expected[i] {
+					t.Fatalf("Line %d: expected %s, got %s (limit %d)", i, expected[i], merged[i], limit)
+				}
+			}
+
+			// Small limits must have spilled, and closing removes the runs
+			if limit < 1<<20 && sorter.Runs() == 0 {
+				t.Errorf("Expected runs to be spilled with limit %d", limit)
+			}
+			if err := sorter.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("Expected temp dir to be empty after Close, found %d files", len(entries))
+			}
+		}
+	}
+}
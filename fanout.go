@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// fanoutOutput names the output file or stdout among the sinks
+const fanoutOutput = "output"
+
+// fanoutSink is one destination of a FanoutWriter
+type fanoutSink struct {
+	name       string
+	w          io.Writer
+	skipErrors bool  // Drop the sink on failure instead of failing the run
+	err        error // Why a skipped sink was dropped
+}
+
+// FanoutWriter writes the same output to several sinks, so one generation
+// pass can feed a file, Redis and NATS at once. Each sink has its own
+// --on-error style policy: abort fails the write, skip drops the sink with
+// a warning and leaves the others running. It implements FlushWriter and
+// flushes the sinks that buffer.
+type FanoutWriter struct {
+	sinks []*fanoutSink
+}
+
+// Add adds a sink with the abort or skip failure policy
+func (f *FanoutWriter) Add(name string, w io.Writer, policy string) {
+	f.sinks = append(f.sinks, &fanoutSink{name: name, w: w, skipErrors: policy == onErrorSkip})
+}
+
+// Writer returns the output itself when it is the only sink, so plain runs
+// write without the fan-out. Other sinks keep it so errors name the sink.
+func (f *FanoutWriter) Writer() io.Writer {
+	if len(f.sinks) == 1 && f.sinks[0].name == fanoutOutput {
+		return f.sinks[0].w
+	}
+	return f
+}
+
+// Write writes p to every sink that has not been dropped
+func (f *FanoutWriter) Write(p []byte) (int, error) {
+	for _, s := range f.sinks {
+		if s.err != nil {
+			continue
+		}
+		if _, err := s.w.Write(p); err != nil {
+			if err := f.fail(s, err); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// Flush flushes every sink that buffers and has not been dropped
+func (f *FanoutWriter) Flush() error {
+	for _, s := range f.sinks {
+		buffered, ok := s.w.(FlushWriter)
+		if !ok || s.err != nil {
+			continue
+		}
+		if err := buffered.Flush(); err != nil {
+			if err := f.fail(s, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fail applies a sink's failure policy, returning the error that should
+// fail the write or nil if the sink was dropped
+func (f *FanoutWriter) fail(s *fanoutSink, err error) error {
+	if !s.skipErrors {
+		return fmt.Errorf("%s: %w", s.name, err)
+	}
+	s.err = err
+	printWarning("Dropped the %s sink after it failed: %v", s.name, err)
+	return nil
+}
+
+// Dropped returns the names of the sinks dropped after failures
+func (f *FanoutWriter) Dropped() []string {
+	var names []string
+	for _, s := range f.sinks {
+		if s.err != nil {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// flakySink fails every write after the first n bytes
+type flakySink struct {
+	bytes.Buffer
+	n       int
+	flushed bool
+}
+
+func (w *flakySink) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.n {
+		return 0, errors.New("connection reset")
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *flakySink) Flush() error {
+	w.flushed = true
+	return nil
+}
+
+func TestFanoutWriter(t *testing.T) {
+	var output bytes.Buffer
+	flaky := &flakySink{n: 10}
+	steady := &flakySink{n: 1 << 20}
+
+	fanout := &FanoutWriter{}
+	fanout.Add(fanoutOutput, &output, onErrorAbort)
+	fanout.Add("redis", flaky, onErrorSkip)
+	fanout.Add("nats", steady, onErrorAbort)
+	if fanout.Writer() != fanout {
+		t.Fatal("Expected the fan-out for several sinks")
+	}
+
+	for _, row := range []string{"row0\n", "row1\n", "row2\n"} {
+		if _, err := fanout.Write([]byte(row)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fanout.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != "row0\nrow1\nrow2\n" || steady.String() != output.String() {
+		t.Errorf("Expected every row in the output and steady sink, got %q and %q", output.String(), steady.String())
+	}
+	if flaky.String() != "row0\nrow1\n" || flaky.flushed {
+		t.Errorf("Expected the flaky sink to be dropped after two rows, got %q", flaky.String())
+	}
+	if !steady.flushed {
+		t.Error("Expected the steady sink to be flushed")
+	}
+	if dropped := fanout.Dropped(); !slices.Equal(dropped, []string{"redis"}) {
+		t.Errorf("Expected redis to be dropped, got %v", dropped)
+	}
+
+	// A sink that aborts fails the write and names itself
+	abort := &FanoutWriter{}
+	abort.Add("redis", &flakySink{}, onErrorAbort)
+	if _, err := abort.Write([]byte("row\n")); err == nil || !strings.HasPrefix(err.Error(), "redis: ") {
+		t.Errorf("Expected a redis error, got %v", err)
+	}
+
+	// The output alone is written directly
+	alone := &FanoutWriter{}
+	alone.Add(fanoutOutput, &output, onErrorAbort)
+	if alone.Writer() != &output {
+		t.Error("Expected the output itself when it is the only sink")
+	}
+}
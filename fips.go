@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/fips140"
+	"fmt"
+	"strings"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// minFIPSHMACKeyBytes is the shortest HMAC key FIPS 140-3 approves (112 bits)
+const minFIPSHMACKeyBytes = 14
+
+// AlgorithmUse records one algorithm a run relies on. Approved algorithms
+// are FIPS 140-3 approved and implemented by Go's FIPS 140-3 module.
+type AlgorithmUse struct {
+	Name     string `json:"name"`
+	Purpose  string `json:"purpose"`
+	Approved bool   `json:"approved"`
+}
+
+// ComplianceManifest records the FIPS status of a --fips run. A run is
+// compliant when the module was enabled and every algorithm is approved.
+type ComplianceManifest struct {
+	FIPSModule bool           `json:"fips_module"`
+	Compliant  bool           `json:"compliant"`
+	Algorithms []AlgorithmUse `json:"algorithms"`
+}
+
+// runAlgorithms lists the algorithms a run uses. The address algorithms
+// are fixed by each network; the seed, hashing and encryption ones follow
+// the options.
+func runAlgorithms(network, scheme, source string, btcTypes *addrmint.BitcoinTypeMix, generateHash, hashOnly, encryptTemp bool) []AlgorithmUse {
+	if scheme == addrmint.DerivationIndex {
+		// Contract addresses hash the deployer with a nonce or salt, with no keys involved
+		return optionAlgorithms([]AlgorithmUse{{"Keccak-256", "contract address", false}}, generateHash, hashOnly, encryptTemp)
+	}
+	var algorithms []AlgorithmUse
+	switch source {
+	case seedSourceShares:
+		algorithms = append(algorithms, AlgorithmUse{"HKDF-SHA256", "seed share combination", true})
+	case seedSourceMnemonic:
+		// BIP-39 salts with "mnemonic" and the passphrase, not the random
+		// salt SP 800-132 requires
+		algorithms = append(algorithms, AlgorithmUse{"PBKDF2-HMAC-SHA512", "mnemonic seed", false})
+	}
+	switch scheme {
+	case addrmint.DerivationV2:
+		algorithms = append(algorithms, AlgorithmUse{"HKDF-SHA256", "seed derivation", true})
+	case addrmint.DerivationBIP32, addrmint.DerivationSLIP10:
+		algorithms = append(algorithms, AlgorithmUse{"HMAC-SHA512", "seed derivation", true})
+	default:
+		// v1 hashes the base seed with the index, which is not an
+		// SP 800-108 or SP 800-56C key derivation
+		algorithms = append(algorithms, AlgorithmUse{"SHA-256", "v1 seed derivation", false})
+	}
+	switch network {
+	case "ethereum":
+		algorithms = append(algorithms,
+			AlgorithmUse{"secp256k1", "key derivation", false},
+			AlgorithmUse{"Keccak-256", "address", false})
+	case "bitcoin":
+		algorithms = append(algorithms,
+			AlgorithmUse{"secp256k1", "key derivation", false},
+			AlgorithmUse{"SHA-256", "address", true},
+			AlgorithmUse{"RIPEMD-160", "address", false})
+		if btcTypes == nil {
+			break
+		}
+		for _, t := range btcTypes.Types() {
+			if t == addrmint.BitcoinTaproot {
+				algorithms = append(algorithms, AlgorithmUse{"BIP-340 tagged SHA-256", "taproot output key", true})
+			}
+		}
+	case "solana":
+		algorithms = append(algorithms, AlgorithmUse{"Ed25519", "key derivation", true})
+	case "ton":
+		algorithms = append(algorithms,
+			AlgorithmUse{"Ed25519", "key derivation", true},
+			AlgorithmUse{"SHA-256", "address", true})
+	}
+	return optionAlgorithms(algorithms, generateHash, hashOnly, encryptTemp)
+}
+
+// optionAlgorithms appends the hashing and encryption algorithms the
+// options call for to algorithms
+func optionAlgorithms(algorithms []AlgorithmUse, generateHash, hashOnly, encryptTemp bool) []AlgorithmUse {
+	if generateHash {
+		algorithms = append(algorithms, AlgorithmUse{"SHA-256", "address hash", true})
+	}
+	if hashOnly {
+		algorithms = append(algorithms, AlgorithmUse{"HMAC-SHA256", "keyed hash", true})
+	}
+	if encryptTemp {
+		algorithms = append(algorithms, AlgorithmUse{"AES-256-GCM", "temp file encryption", true})
+	}
+	return algorithms
+}
+
+// fipsCompliance checks the run against FIPS 140-3
+func fipsCompliance(algorithms []AlgorithmUse) *ComplianceManifest {
+	compliance := &ComplianceManifest{FIPSModule: fips140.Enabled(), Algorithms: algorithms}
+	compliance.Compliant = compliance.FIPSModule
+	for _, a := range algorithms {
+		compliance.Compliant = compliance.Compliant && a.Approved
+	}
+	return compliance
+}
+
+// unapprovedAlgorithms names the algorithms that are not approved
+func unapprovedAlgorithms(algorithms []AlgorithmUse) string {
+	var names []string
+	for _, a := range algorithms {
+		if !a.Approved {
+			names = append(names, fmt.Sprintf("%s (%s)", a.Name, a.Purpose))
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/fips140"
+	"testing"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+func TestRunAlgorithms(t *testing.T) {
+	taproot, err := addrmint.ParseBitcoinTypeMix("segwit=1,taproot=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		network    string
+		btcTypes   *addrmint.BitcoinTypeMix
+		unapproved string
+	}{
+		{"ethereum", nil, "secp256k1 (key derivation), Keccak-256 (address)"},
+		{"bitcoin", taproot, "secp256k1 (key derivation), RIPEMD-160 (address)"},
+		{"solana", nil, ""},
+		{"ton", nil, ""},
+	}
+	for _, tt := range tests {
+		algorithms := runAlgorithms(tt.network, addrmint.DerivationV2, seedSourceInteger, tt.btcTypes, true, false, true)
+		if got := unapprovedAlgorithms(algorithms); got != tt.unapproved {
+			t.Errorf("%s: expected unapproved %q, got %q", tt.network, tt.unapproved, got)
+		}
+		compliance := fipsCompliance(algorithms)
+		if want := fips140.Enabled() && tt.unapproved == ""; compliance.Compliant != want {
+			t.Errorf("%s: expected compliant %v, got %v", tt.network, want, compliance.Compliant)
+		}
+	}
+
+	algorithms := runAlgorithms("solana", addrmint.DerivationV1, seedSourceInteger, nil, false, false, false)
+	if got := unapprovedAlgorithms(algorithms); got != "SHA-256 (v1 seed derivation)" {
+		t.Errorf("Expected v1 seed derivation to be unapproved, got %q", got)
+	}
+	algorithms = runAlgorithms("solana", addrmint.DerivationV2, seedSourceRandom, nil, false, true, false)
+	if len(algorithms) != 3 || algorithms[0].Name != "HKDF-SHA256" || algorithms[2].Name != "HMAC-SHA256" {
+		t.Errorf("Expected HKDF-SHA256, Ed25519 and HMAC-SHA256, got %v", algorithms)
+	}
+	algorithms = runAlgorithms("solana", addrmint.DerivationSLIP10, seedSourceMnemonic, nil, false, false, false)
+	if len(algorithms) != 3 || algorithms[0].Name != "PBKDF2-HMAC-SHA512" || algorithms[1].Name != "HMAC-SHA512" {
+		t.Errorf("Expected mnemonics to stretch with PBKDF2-HMAC-SHA512 and HD paths to derive with HMAC-SHA512, got %v", algorithms)
+	}
+	if got := unapprovedAlgorithms(algorithms); got != "PBKDF2-HMAC-SHA512 (mnemonic seed)" {
+		t.Errorf("Expected the mnemonic seed to be unapproved, got %q", got)
+	}
+	algorithms = runAlgorithms("solana", addrmint.DerivationV2, seedSourceShares, nil, false, false, false)
+	if len(algorithms) != 3 || algorithms[0].Name != "HKDF-SHA256" || algorithms[0].Purpose != "seed share combination" {
+		t.Errorf("Expected seed shares to combine with HKDF-SHA256, got %v", algorithms)
+	}
+}
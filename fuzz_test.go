@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"testing"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// FuzzDeriveSeed checks that the job path derives the same seed as a
+// direct SHA-256 and produces an address for every network
+func FuzzDeriveSeed(f *testing.F) {
+	f.Add("2a", 0)
+	f.Add("", -1)
+	f.Add("c8c5e5a7f326a2b5", 1<<40)
+
+	var generators workerGenerators
+	f.Fuzz(func(t *testing.T, baseSeed string, index int) {
+		var job Job
+		addrmint.DeriveSeed(nil, baseSeed, index, &job.seed)
+		if want := sha256.Sum256([]byte(baseSeed + strconv.Itoa(index))); job.seed != want {
+			t.Fatalf("addrmint.DeriveSeed(%q, %d) = %x, want %x", baseSeed, index, job.seed, want)
+		}
+
+		for _, network := range []string{"ethereum", "bitcoin", "solana", "ton"} {
+			job.network = network
+			if _, err := generateAddress(&job, &generators); err != nil {
+				// Only a derived scalar outside the curve order may fail
+				t.Logf("%s: %v", network, err)
+			}
+		}
+	})
+}
+
+// FuzzValidateAddress checks that the address parsers and the casing repair
+// never panic and behave consistently
+func FuzzValidateAddress(f *testing.F) {
+	for _, v := range selftestVectors {
+		f.Add(v.network, v.expected)
+	}
+	f.Add("ethereum", "0x")
+	f.Add("bitcoin", "bc1")
+	f.Add("ton", "")
+
+	f.Fuzz(func(t *testing.T, network, addr string) {
+		if err := validateAddress(network, addr); err == nil {
+			if err := validateAddress(network, addr); err != nil {
+				t.Fatalf("validateAddress(%s, %q) is not deterministic: %v", network, addr, err)
+			}
+		}
+
+		// Fixing is idempotent and never turns a valid address invalid
+		fixed := fixAddress(network, addr)
+		if again := fixAddress(network, fixed); again != fixed {
+			t.Fatalf("fixAddress(%s, %q) is not idempotent: %q != %q", network, addr, again, fixed)
+		}
+		if validateAddress(network, addr) == nil && validateAddress(network, fixed) != nil {
+			t.Fatalf("fixAddress(%s, %q) broke a valid address: %q", network, addr, fixed)
+		}
+	})
+}
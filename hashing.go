@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+)
+
+// hashKeySize is the size in bytes of generated hash keys
+const hashKeySize = 32
+
+// KeyedHasher computes HMAC-SHA256 digests of addresses so that hashed
+// corpora can only be matched by someone holding the key. With more than one
+// iteration each round hashes the previous round's digest, which multiplies
+// the cost of brute-forcing the address space.
+type KeyedHasher struct {
+	mac        hash.Hash
+	iterations int
+	in         []byte // Reused input buffer, avoids converting strings per call
+	sum        []byte // Reused digest buffer
+}
+
+// NewKeyedHasher creates a hasher for the given key
+func NewKeyedHasher(key []byte) *KeyedHasher {
+	return &KeyedHasher{
+		mac:        hmac.New(sha256.New, key),
+		iterations: 1,
+		in:         make([]byte, 0, 128),
+		sum:        make([]byte, 0, sha256.Size),
+	}
+}
+
+// SetIterations sets the number of HMAC rounds per address (at least 1)
+func (h *KeyedHasher) SetIterations(iterations int) {
+	h.iterations = max(iterations, 1)
+}
+
+// Sum computes the digest of s into out
+func (h *KeyedHasher) Sum(s string, out *[sha256.Size]byte) {
+	h.in = append(h.in[:0], s...)
+	h.mac.Reset()
+	h.mac.Write(h.in)
+	h.sum = h.mac.Sum(h.sum[:0])
+	for i := 1; i < h.iterations; i++ {
+		h.mac.Reset()
+		h.mac.Write(h.sum)
+		h.sum = h.mac.Sum(h.sum[:0])
+	}
+	copy(out[:], h.sum)
+}
+
+// AppendHex appends the hex encoded digest of s to dst
+func (h *KeyedHasher) AppendHex(dst []byte, s string) []byte {
+	var sum [sha256.Size]byte
+	h.Sum(s, &sum)
+	return hex.AppendEncode(dst, sum[:])
+}
+
+// keyFingerprint identifies a hash key without revealing it
+func keyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadOrCreateSaltFile reads a hex encoded hash key from path, or generates a
+// new random key and saves it there if the file does not exist yet. The
+// returned flag reports whether the key was created.
+func loadOrCreateSaltFile(path string) ([]byte, bool, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) == 0 {
+			return nil, false, fmt.Errorf("%s does not contain a non-empty hex key", path)
+		}
+		return key, false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+
+	key := make([]byte, hashKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, false, err
+	}
+	// Exclusive create so concurrent runs can't overwrite each other's salt
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, hex.EncodeToString(key)); err != nil {
+		return nil, false, err
+	}
+	return key, true, file.Close()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyedHasher(t *testing.T) {
+	// RFC 4231 test case 2
+	h := NewKeyedHasher([]byte("Jefe"))
+	expected := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+
+	// Hash twice to make sure the state is reset between addresses
+	for i := 0; i < 2; i++ {
+		if got := string(h.AppendHex(nil, "what do ya want for nothing?")); got != expected {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+	}
+
+	// The digest is appended after existing content
+	if got := string(h.AppendHex([]byte("x"), "what do ya want for nothing?")); got != "x"+expected {
+		t.Errorf("Expected prefix to be kept, got %s", got)
+	}
+
+	// A different key gives an unrelated digest
+	if got := string(NewKeyedHasher([]byte("other")).AppendHex(nil, "what do ya want for nothing?")); got == expected {
+		t.Errorf("Expected different keys to produce different hashes")
+	}
+}
+
+func TestKeyedHasherIterations(t *testing.T) {
+	key := []byte("Jefe")
+	address := "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"
+
+	// Each round is the HMAC of the previous round's raw digest
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(address))
+	digest := mac.Sum(nil)
+	for i := 1; i < 3; i++ {
+		mac.Reset()
+		mac.Write(digest)
+		digest = mac.Sum(nil)
+	}
+
+	h := NewKeyedHasher(key)
+	h.SetIterations(3)
+	if got, expected := string(h.AppendHex(nil, address)), hex.EncodeToString(digest); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// Fewer than one iteration behaves like a single HMAC
+	single := string(NewKeyedHasher(key).AppendHex(nil, address))
+	h.SetIterations(0)
+	if got := string(h.AppendHex(nil, address)); got != single {
+		t.Errorf("Expected %s, got %s", single, got)
+	}
+}
+
+func TestKeyedHasherAllocations(t *testing.T) {
+	h := NewKeyedHasher(make([]byte, hashKeySize))
+	line := make([]byte, 0, 128)
+	allocs := testing.AllocsPerRun(1000, func() {
+		line = h.AppendHex(line[:0], "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f")
+	})
+	if allocs != 0 {
+		t.Errorf("Expected keyed hashing to perform 0 allocations, got %.1f", allocs)
+	}
+}
+
+func TestLoadOrCreateSaltFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "salt.hex")
+
+	// The first run creates the file with a new random key
+	key, created, err := loadOrCreateSaltFile(path)
+	if err != nil {
+		t.Fatalf("loadOrCreateSaltFile failed: %v", err)
+	}
+	if !created || len(key) != hashKeySize {
+		t.Fatalf("Expected a new %d byte key, got %d bytes (created=%t)", hashKeySize, len(key), created)
+	}
+
+	// Later runs reuse the same key
+	again, created, err := loadOrCreateSaltFile(path)
+	if err != nil {
+		t.Fatalf("loadOrCreateSaltFile failed: %v", err)
+	}
+	if created || !bytes.Equal(again, key) {
+		t.Errorf("Expected the saved key to be reused")
+	}
+	if keyFingerprint(again) != keyFingerprint(key) {
+		t.Errorf("Expected matching fingerprints")
+	}
+
+	// Files that don't hold a hex key are rejected
+	bad := filepath.Join(t.TempDir(), "bad.hex")
+	os.WriteFile(bad, []byte("not hex\n"), 0600)
+	if _, _, err := loadOrCreateSaltFile(bad); err == nil {
+		t.Errorf("Expected an error for an invalid salt file")
+	}
+}
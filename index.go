@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// An offset index is a binary sidecar of an output file holding the byte
+// offset of every interval-th row, so that readers can seek to any row
+// without scanning the rows before it. All integers are little-endian
+// uint64s:
+//
+//	magic "AMOFFIDX", interval, rows, bytes
+//	offset of row 0, offset of row interval, offset of row 2*interval, ...
+//
+// rows and bytes describe the complete output and are filled in when the
+// index is closed, so an index whose bytes differ from the size of its
+// output is stale or from an unfinished run.
+const (
+	offsetIndexMagic       = "AMOFFIDX"
+	offsetIndexHeaderSize  = 32
+	defaultOffsetIndexRows = 4096
+)
+
+// OffsetIndexWriter passes writes through to the output and records the
+// offset of every interval-th row that starts in them
+type OffsetIndexWriter struct {
+	out      io.Writer
+	file     *os.File
+	entries  *bufio.Writer
+	interval int64
+	rows     int64 // Complete rows written so far
+	bytes    int64 // Bytes written so far
+	midRow   bool  // The last byte written was not a newline
+	err      error // First error writing the index
+}
+
+// NewOffsetIndexWriter creates the index file at path for the rows written
+// to out
+func NewOffsetIndexWriter(path string, out io.Writer, interval int) (*OffsetIndexWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	// The header is rewritten with the totals on Close
+	if _, err := file.Write(make([]byte, offsetIndexHeaderSize)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &OffsetIndexWriter{out: out, file: file, entries: bufio.NewWriter(file), interval: int64(interval)}, nil
+}
+
+// Write writes p to the output and indexes the rows that made it
+func (w *OffsetIndexWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	for written := p[:n]; len(written) > 0; {
+		if !w.midRow && w.rows%w.interval == 0 {
+			w.entries.Write(binary.LittleEndian.AppendUint64(nil, uint64(w.bytes)))
+		}
+		end := bytes.IndexByte(written, '\n')
+		if end < 0 {
+			w.bytes += int64(len(written))
+			w.midRow = true
+			break
+		}
+		w.bytes += int64(end + 1)
+		w.rows++
+		w.midRow = false
+		written = written[end+1:]
+	}
+	return n, err
+}
+
+// Close writes the totals to the header and closes the index file
+func (w *OffsetIndexWriter) Close() error {
+	err := w.entries.Flush()
+	if err == nil {
+		header := append([]byte(offsetIndexMagic), make([]byte, offsetIndexHeaderSize-len(offsetIndexMagic))...)
+		binary.LittleEndian.PutUint64(header[8:], uint64(w.interval))
+		binary.LittleEndian.PutUint64(header[16:], uint64(w.rows))
+		binary.LittleEndian.PutUint64(header[24:], uint64(w.bytes))
+		_, err = w.file.WriteAt(header, 0)
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// OffsetIndex is an offset index read back from its file
+type OffsetIndex struct {
+	Interval int64
+	Rows     int64
+	Bytes    int64
+	Offsets  []int64 // Offset of row i*Interval
+}
+
+// ReadOffsetIndex reads the offset index at path
+func ReadOffsetIndex(path string) (*OffsetIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < offsetIndexHeaderSize || string(data[:8]) != offsetIndexMagic || (len(data)-offsetIndexHeaderSize)%8 != 0 {
+		return nil, errors.New("not an offset index")
+	}
+	index := &OffsetIndex{
+		Interval: int64(binary.LittleEndian.Uint64(data[8:])),
+		Rows:     int64(binary.LittleEndian.Uint64(data[16:])),
+		Bytes:    int64(binary.LittleEndian.Uint64(data[24:])),
+	}
+	if index.Interval <= 0 {
+		return nil, errors.New("offset index has no interval")
+	}
+	for entry := data[offsetIndexHeaderSize:]; len(entry) > 0; entry = entry[8:] {
+		index.Offsets = append(index.Offsets, int64(binary.LittleEndian.Uint64(entry)))
+	}
+	return index, nil
+}
+
+// Locate returns the offset of the nearest indexed row at or before row,
+// and how many rows past it row is
+func (x *OffsetIndex) Locate(row int64) (offset, skip int64) {
+	entry := min(row/x.Interval, int64(len(x.Offsets))-1)
+	if entry < 0 {
+		return 0, row
+	}
+	return x.Offsets[entry], row - entry*x.Interval
+}
+
+// copyRows copies count rows of in starting at row from, seeking with index
+// if it is not nil, and returns how many were copied
+func copyRows(in io.ReadSeeker, out io.Writer, index *OffsetIndex, from, count int64) (int64, error) {
+	skip := from
+	if index != nil {
+		var offset int64
+		offset, skip = index.Locate(from)
+		if _, err := in.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	reader := bufio.NewReaderSize(in, 64*1024)
+	copied := int64(0)
+	for count < 0 || copied < count {
+		line, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// A row longer than the buffer is read in pieces
+			if skip == 0 {
+				if _, err := out.Write(line); err != nil {
+					return copied, err
+				}
+			}
+			continue
+		}
+		if len(line) > 0 {
+			if skip > 0 {
+				skip--
+			} else {
+				if _, err := out.Write(line); err != nil {
+					return copied, err
+				}
+				copied++
+			}
+		}
+		if err == io.EOF {
+			return copied, nil
+		}
+		if err != nil {
+			return copied, err
+		}
+	}
+	return copied, nil
+}
+
+// runRows implements the rows subcommand, which prints a range of rows of
+// an output file, seeking with its offset index when it has one
+func runRows(args []string) int {
+	fs := flag.NewFlagSet("rows", flag.ExitOnError)
+	from := fs.Int64("from", 0, "First row to print, counting from 0")
+	count := fs.Int64("count", -1, "Number of rows to print (default: to the end)")
+	tail := fs.Int64("tail", 0, "Print the last N rows instead; requires the index")
+	indexFile := fs.String("index", "", "Offset index of the file (default: FILE.idx if it exists)")
+	output := fs.String("output", "", "Output file path (default: stdout)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint rows (--from N [--count N] | --tail N) [--index FILE] [--output FILE] FILE\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if fs.NArg() != 1 {
+		fatalf("rows takes exactly one input file")
+	}
+	if *from < 0 || *tail < 0 {
+		fatalf("--from and --tail cannot be negative")
+	}
+	path := fs.Arg(0)
+	in, err := os.Open(path)
+	if err != nil {
+		fatalf("Failed to open input file: %v", err)
+	}
+	defer in.Close()
+
+	// Without an index the rows before --from are scanned
+	var index *OffsetIndex
+	if *indexFile == "" && fileExists(path+".idx") {
+		*indexFile = path + ".idx"
+	}
+	if *indexFile != "" {
+		if index, err = ReadOffsetIndex(*indexFile); err != nil {
+			fatalf("Failed to read offset index %s: %v", *indexFile, err)
+		}
+		info, err := in.Stat()
+		if err != nil {
+			fatalf("Failed to read input file: %v", err)
+		}
+		if info.Size() != index.Bytes {
+			fatalf("Offset index %s is stale: it describes %s bytes, but %s has %s", *indexFile, formatCount(int(index.Bytes)), path, formatCount(int(info.Size())))
+		}
+	}
+	if *tail > 0 {
+		if index == nil {
+			fatalf("--tail requires an offset index")
+		}
+		*from, *count = max(index.Rows-*tail, 0), *tail
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	writer := bufio.NewWriterSize(out, 64*1024)
+	copied, err := copyRows(in, writer, index, *from, *count)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		fatalf("Failed to copy rows: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Copied %s rows starting at row %s\n", formatCount(int(copied)), formatCount(int(*from)))
+	return 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOffsetIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.idx")
+	var output bytes.Buffer
+	w, err := NewOffsetIndexWriter(path, &output, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for i := 0; i < 10; i++ {
+		rows = append(rows, fmt.Sprintf("row%d\n", i*i))
+	}
+	// Writes split rows at arbitrary points, as buffered writers do
+	all := []byte(strings.Join(rows, ""))
+	for _, chunk := range [][]byte{all[:3], all[3:7], all[7:30], all[30:]} {
+		w.Write(chunk)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := ReadOffsetIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Interval != 3 || index.Rows != 10 || index.Bytes != int64(len(all)) {
+		t.Errorf("Unexpected header %+v", index)
+	}
+	var want []int64
+	for i := 0; i < len(rows); i += 3 {
+		want = append(want, int64(len(strings.Join(rows[:i], ""))))
+	}
+	if !slices.Equal(index.Offsets, want) {
+		t.Errorf("Expected offsets %v, got %v", want, index.Offsets)
+	}
+
+	// Seeking must give the same rows as scanning
+	for _, tt := range []struct{ from, count int64 }{{0, 2}, {4, 3}, {9, 5}, {7, -1}, {12, 1}} {
+		var seeked, scanned bytes.Buffer
+		n, err := copyRows(bytes.NewReader(all), &seeked, index, tt.from, tt.count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		copyRows(bytes.NewReader(all), &scanned, nil, tt.from, tt.count)
+		end := min(int(tt.from)+int(tt.count), len(rows))
+		if tt.count < 0 {
+			end = len(rows)
+		}
+		expected := strings.Join(rows[min(int(tt.from), len(rows)):end], "")
+		if seeked.String() != expected || scanned.String() != expected || n != int64(max(end-int(tt.from), 0)) {
+			t.Errorf("Rows %d+%d: expected %q, got %q seeking and %q scanning", tt.from, tt.count, expected, seeked.String(), scanned.String())
+		}
+	}
+}
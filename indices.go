@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxIndexRange bounds a single range in an indices file so that a typo
+// can't expand into billions of indices
+const maxIndexRange = 1 << 30
+
+// parseIndexRange parses "N" as the single index N, or "START-END" as the
+// indices from START up to but not including END
+func parseIndexRange(s string) (int, int, error) {
+	startText, endText, isRange := strings.Cut(s, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(startText))
+	if err != nil || start < 0 {
+		return 0, 0, fmt.Errorf("invalid index %q", startText)
+	}
+	if !isRange {
+		return start, start + 1, nil
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(endText))
+	if err != nil || end <= start {
+		return 0, 0, fmt.Errorf("invalid range %q: end must be an index after the start", s)
+	}
+	return start, end, nil
+}
+
+// parseIndices reads one index or range per line. Only the first
+// comma-separated field is used, so --error-file output can be read back
+// directly. Blank lines and lines starting with # are ignored. The indices
+// are returned sorted and without duplicates.
+func parseIndices(in io.Reader) ([]int, error) {
+	var indices []int
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), ",")
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		start, end, err := parseIndexRange(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if end-start > maxIndexRange {
+			return nil, fmt.Errorf("line %d: range %q is larger than %d indices", line, text, maxIndexRange)
+		}
+		for i := start; i < end; i++ {
+			indices = append(indices, i)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(indices)
+	return slices.Compact(indices), nil
+}
+
+// loadIndices reads an indices file
+func loadIndices(path string) ([]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseIndices(file)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseIndices(t *testing.T) {
+	input := "# failed indices\n7\n2-5\n\n3,\"unsupported network\"\n 10 - 12 \n"
+	indices, err := parseIndices(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseIndices failed: %v", err)
+	}
+	if expected := []int{2, 3, 4, 7, 10, 11}; !slices.Equal(indices, expected) {
+		t.Errorf("Expected %v, got %v", expected, indices)
+	}
+}
+
+func TestParseIndicesInvalid(t *testing.T) {
+	for _, input := range []string{"-1", "abc", "5-5", "9-3", "1-", "0-2000000000"} {
+		if _, err := parseIndices(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestResultCollectorIndices(t *testing.T) {
+	var output bytes.Buffer
+	rc := NewResultCollector(2, 1, nil, false)
+	rc.SetWriter(bufio.NewWriter(&output))
+	rc.SetLayout(&rowLayout{indices: []int{4, 17}})
+
+	pb := NewProgressBar(2, 10)
+	rc.AddResult(Record{index: 1, address: "address17"}, pb)
+	rc.AddResult(Record{index: 0, address: "address4"}, pb)
+
+	if expected := "4,address4\n17,address17\n"; output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+}
+
+func TestParseIndexRange(t *testing.T) {
+	tests := []struct {
+		input      string
+		start, end int
+	}{
+		{"7", 7, 8},
+		{"1000000-2000000", 1000000, 2000000},
+		{" 0 - 5000000000 ", 0, 5000000000},
+	}
+	for _, tt := range tests {
+		start, end, err := parseIndexRange(tt.input)
+		if err != nil || start != tt.start || end != tt.end {
+			t.Errorf("%q: expected %d-%d, got %d-%d (%v)", tt.input, tt.start, tt.end, start, end, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import "fmt"
+
+// InvariantChecker asserts what the result collector guarantees: every
+// index of the run arrives from the workers exactly once, rows are emitted
+// in index order without gaps, and the output receives one row for each
+// index that did not fail. It is meant for long soak runs, where a
+// collector regression would otherwise only show up as a corrupt corpus.
+type InvariantChecker struct {
+	total    int
+	received []uint64 // Bit set of the indices that arrived
+	emitted  int      // Indices emitted so far, which must be 0 to emitted-1
+}
+
+// NewInvariantChecker creates a checker for a run of total indices
+func NewInvariantChecker(total int) *InvariantChecker {
+	return &InvariantChecker{total: total, received: make([]uint64, (total+63)/64)}
+}
+
+// Received records that a worker delivered index
+func (c *InvariantChecker) Received(index int) error {
+	if index < 0 || index >= c.total {
+		return fmt.Errorf("index %d is outside the run of %d indices", index, c.total)
+	}
+	word, bit := index/64, uint64(1)<<(index%64)
+	if c.received[word]&bit != 0 {
+		return fmt.Errorf("index %d was delivered twice", index)
+	}
+	c.received[word] |= bit
+	return nil
+}
+
+// Emitted records that the row of index was written out
+func (c *InvariantChecker) Emitted(index int) error {
+	if index != c.emitted {
+		return fmt.Errorf("index %d was emitted where index %d was due", index, c.emitted)
+	}
+	if c.received[index/64]&(uint64(1)<<(index%64)) == 0 {
+		return fmt.Errorf("index %d was emitted before it was delivered", index)
+	}
+	c.emitted++
+	return nil
+}
+
+// Finish checks that every index was emitted and that rows rows reached the
+// output for the failed indices skipped along the way
+func (c *InvariantChecker) Finish(rows int64, failed int) error {
+	if c.emitted != c.total {
+		return fmt.Errorf("%d of %d indices were emitted; index %d never was", c.emitted, c.total, c.emitted)
+	}
+	if expected := int64(c.total - failed); rows != expected {
+		return fmt.Errorf("the output received %d rows for %d generated addresses", rows, expected)
+	}
+	return nil
+}
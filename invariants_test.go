@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// TestResultCollectorOrdering feeds shuffled batches through the collector
+// with the invariant checker attached and checks that rows come out once
+// each, in index order
+func TestResultCollectorOrdering(t *testing.T) {
+	const total = 5000
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, batchSize := range []int{1, 7, 64} {
+		var output bytes.Buffer
+		writer := bufio.NewWriter(&output)
+		rc := NewResultCollector(total, 1, nil, false)
+		rc.SetWriter(writer)
+		checker := NewInvariantChecker(total)
+		rc.SetInvariants(checker)
+
+		order := rng.Perm(total)
+		pb := NewProgressBar(total, 10)
+		for start := 0; start < total; start += batchSize {
+			var batch []Record
+			for _, index := range order[start:min(start+batchSize, total)] {
+				batch = append(batch, Record{index: index, address: fmt.Sprintf("address%d", index)})
+			}
+			rc.AddResults(batch, pb)
+		}
+		writer.Flush()
+
+		rows := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		if len(rows) != total {
+			t.Fatalf("Batch size %d: expected %d rows, got %d", batchSize, total, len(rows))
+		}
+		for i, row := range rows {
+			if row != fmt.Sprintf("address%d", i) {
+				t.Fatalf("Batch size %d: row %d is %q", batchSize, i, row)
+			}
+		}
+		if err := checker.Finish(int64(len(rows)), 0); err != nil {
+			t.Errorf("Batch size %d: %v", batchSize, err)
+		}
+	}
+}
+
+func TestInvariantChecker(t *testing.T) {
+	checker := NewInvariantChecker(3)
+	for _, index := range []int{1, 0} {
+		if err := checker.Received(index); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := checker.Received(1); err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Errorf("Expected a duplicate delivery to be caught, got %v", err)
+	}
+	if err := checker.Received(3); err == nil {
+		t.Error("Expected an index outside the run to be caught")
+	}
+	if err := checker.Emitted(1); err == nil || !strings.Contains(err.Error(), "index 0 was due") {
+		t.Errorf("Expected an out-of-order row to be caught, got %v", err)
+	}
+	if err := checker.Emitted(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.Emitted(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.Emitted(2); err == nil || !strings.Contains(err.Error(), "before it was delivered") {
+		t.Errorf("Expected a row emitted before delivery to be caught, got %v", err)
+	}
+	if err := checker.Finish(2, 0); err == nil || !strings.Contains(err.Error(), "index 2 never was") {
+		t.Errorf("Expected a missing index to be caught, got %v", err)
+	}
+
+	checker.Received(2)
+	checker.Emitted(2)
+	if err := checker.Finish(2, 1); err != nil {
+		t.Errorf("Expected 2 rows with 1 failure to pass, got %v", err)
+	}
+	if err := checker.Finish(3, 1); err == nil {
+		t.Error("Expected a surplus row to be caught")
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxJoinPartitions bounds the number of spill files per side of a join
+const maxJoinPartitions = 256
+
+// labelOverhead approximates the in-memory cost of a label row beyond its
+// CSV size, used to decide whether the label file fits in memory
+const labelOverhead = 2
+
+// errMissingLabelKey is returned for label rows too short to hold the key
+var errMissingLabelKey = errors.New("label row has no key column")
+
+// JoinStats counts the outcome of a join
+type JoinStats struct {
+	rows       int
+	matched    int
+	unmatched  int
+	duplicates int // Label rows ignored because their key was already seen
+	partitions int
+}
+
+// Joiner enriches corpus rows with the label columns of their address
+type Joiner struct {
+	keyColumn int
+	columns   []string // Label columns other than the key
+	left      bool     // Keep corpus rows without labels
+	out       *csv.Writer
+	stats     JoinStats
+}
+
+// corpusColumns names the columns of a corpus row: the address is the last
+// field, preceded by the hash for --generate-hash output
+func corpusColumns(fields int) []string {
+	switch fields {
+	case 1:
+		return []string{"address"}
+	case 2:
+		return []string{"hash", "address"}
+	}
+	columns := make([]string, fields)
+	for i := range columns {
+		columns[i] = fmt.Sprintf("column%d", i+1)
+	}
+	columns[fields-1] = "address"
+	return columns
+}
+
+// newJoiner reads the label header and prepares a joiner keyed on keyName
+func newJoiner(header []string, keyName string, left bool, out *csv.Writer) (*Joiner, error) {
+	j := &Joiner{keyColumn: -1, left: left, out: out}
+	for i, name := range header {
+		if name == keyName {
+			j.keyColumn = i
+		} else {
+			j.columns = append(j.columns, name)
+		}
+	}
+	if j.keyColumn < 0 {
+		return nil, fmt.Errorf("label file has no %q column", keyName)
+	}
+	return j, nil
+}
+
+// labelValues splits a label record into its key and the other columns
+func (j *Joiner) labelValues(record []string) (string, []string) {
+	values := make([]string, 0, len(record)-1)
+	values = append(values, record[:j.keyColumn]...)
+	values = append(values, record[j.keyColumn+1:]...)
+	return strings.TrimSpace(record[j.keyColumn]), values
+}
+
+// loadLabels reads label records into a map from key to label values
+func (j *Joiner) loadLabels(labels *csv.Reader) (map[string][]string, error) {
+	rows := make(map[string][]string)
+	for {
+		record, err := labels.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) <= j.keyColumn {
+			return nil, errMissingLabelKey
+		}
+		key, values := j.labelValues(record)
+		if _, exists := rows[key]; exists {
+			j.stats.duplicates++
+			continue
+		}
+		rows[key] = values
+	}
+}
+
+// joinCorpus streams corpus rows, writing each with its labels
+func (j *Joiner) joinCorpus(corpus io.Reader, labels map[string][]string, wroteHeader *bool) error {
+	empty := make([]string, len(j.columns))
+	scanner := bufio.NewScanner(corpus)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if !*wroteHeader {
+			if err := j.out.Write(append(corpusColumns(len(fields)), j.columns...)); err != nil {
+				return err
+			}
+			*wroteHeader = true
+		}
+		j.stats.rows++
+
+		values, ok := labels[strings.TrimSpace(fields[len(fields)-1])]
+		if ok {
+			j.stats.matched++
+		} else {
+			j.stats.unmatched++
+			if !j.left {
+				continue
+			}
+			values = empty
+		}
+		if err := j.out.Write(append(fields, values...)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// joinPartitioned spills both inputs into partitions by address hash so that
+// only one partition of labels is held in memory at a time. The spill files
+// are encrypted with c.
+func (j *Joiner) joinPartitioned(corpus io.Reader, labels *csv.Reader, partitions int, tempDir string, c *TempCipher) error {
+	dir, err := os.MkdirTemp(tempDir, "addrmint-join-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	j.stats.partitions = partitions
+
+	// Partition the labels, keeping them as CSV so quoted values survive
+	labelFiles := make([]*os.File, partitions)
+	labelSealers := make([]io.WriteCloser, partitions)
+	labelWriters := make([]*csv.Writer, partitions)
+	for i := range labelFiles {
+		if labelFiles[i], err = os.Create(shardPath(filepath.Join(dir, "labels"), i)); err != nil {
+			return err
+		}
+		defer labelFiles[i].Close()
+		labelSealers[i] = c.Writer(labelFiles[i])
+		labelWriters[i] = csv.NewWriter(labelSealers[i])
+	}
+	for {
+		record, err := labels.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(record) <= j.keyColumn {
+			return errMissingLabelKey
+		}
+		shard := shardOf(strings.TrimSpace(record[j.keyColumn]), partitions)
+		if err := labelWriters[shard].Write(record); err != nil {
+			return err
+		}
+	}
+	for i, w := range labelWriters {
+		if w.Flush(); w.Error() != nil {
+			return w.Error()
+		}
+		if err := labelSealers[i].Close(); err != nil {
+			return err
+		}
+	}
+
+	// Partition the corpus with the same hash
+	corpusFiles := make([]*os.File, partitions)
+	corpusSealers := make([]io.WriteCloser, partitions)
+	corpusWriters := make([]io.Writer, partitions)
+	buffers := make([]*bufio.Writer, partitions)
+	for i := range corpusFiles {
+		if corpusFiles[i], err = os.Create(shardPath(filepath.Join(dir, "corpus"), i)); err != nil {
+			return err
+		}
+		defer corpusFiles[i].Close()
+		corpusSealers[i] = c.Writer(corpusFiles[i])
+		buffers[i] = bufio.NewWriterSize(corpusSealers[i], 64*1024)
+		corpusWriters[i] = buffers[i]
+	}
+	if _, err := splitCorpus(corpus, corpusWriters); err != nil {
+		return err
+	}
+	for i, b := range buffers {
+		if err := b.Flush(); err != nil {
+			return err
+		}
+		if err := corpusSealers[i].Close(); err != nil {
+			return err
+		}
+	}
+
+	// Join partition by partition
+	wroteHeader := false
+	for i := 0; i < partitions; i++ {
+		if _, err := labelFiles[i].Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		reader := csv.NewReader(bufio.NewReader(c.Reader(labelFiles[i])))
+		reader.FieldsPerRecord = -1
+		rows, err := j.loadLabels(reader)
+		if err != nil {
+			return err
+		}
+		if _, err := corpusFiles[i].Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := j.joinCorpus(c.Reader(corpusFiles[i]), rows, &wroteHeader); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runJoin implements the join subcommand and returns the exit code
+func runJoin(args []string) int {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	labelsFile := fs.String("labels", "", "CSV file of labels with a header row")
+	keyName := fs.String("key", "address", "Label column holding the address")
+	left := fs.Bool("left", false, "Keep corpus rows without labels, with empty label columns")
+	output := fs.String("output", "", "Output file path (default: stdout)")
+	memoryMB := fs.Int("memory-mb", 1024, "Memory in MiB for labels before both inputs are partitioned on disk")
+	tempDir := fs.String("temp-dir", os.TempDir(), "Directory for partitions spilled to disk")
+	encryptTemp := fs.Bool("encrypt-temp", false, "Encrypt the partitions spilled to disk with a key held only in memory")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint join --labels FILE [--key COLUMN] [--left] [--memory-mb N] [--temp-dir DIR] [--encrypt-temp] [--output FILE] CORPUS\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if *labelsFile == "" {
+		fatalf("--labels is required")
+	}
+	if *memoryMB < 1 {
+		fatalf("Memory limit must be at least 1 MiB")
+	}
+	if fs.NArg() != 1 {
+		fatalf("join takes exactly one corpus file")
+	}
+
+	corpus, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fatalf("Failed to open corpus: %v", err)
+	}
+	defer corpus.Close()
+
+	labelFile, err := os.Open(*labelsFile)
+	if err != nil {
+		fatalf("Failed to open label file: %v", err)
+	}
+	defer labelFile.Close()
+	info, err := labelFile.Stat()
+	if err != nil {
+		fatalf("Failed to read label file: %v", err)
+	}
+
+	labels := csv.NewReader(bufio.NewReaderSize(labelFile, 64*1024))
+	labels.FieldsPerRecord = -1
+	header, err := labels.Read()
+	if err != nil {
+		fatalf("Failed to read label header: %v", err)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	writer := bufio.NewWriterSize(out, 64*1024)
+	csvWriter := csv.NewWriter(writer)
+
+	joiner, err := newJoiner(header, *keyName, *left, csvWriter)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	// Join in memory when the labels fit, otherwise partition both sides
+	memoryLimit := int64(*memoryMB) << 20
+	needed := info.Size() * labelOverhead
+	if needed <= memoryLimit {
+		rows, err := joiner.loadLabels(labels)
+		if err == nil {
+			wroteHeader := false
+			err = joiner.joinCorpus(corpus, rows, &wroteHeader)
+		}
+		if err != nil {
+			fatalf("Failed to join: %v", err)
+		}
+	} else {
+		partitions := int(min((needed+memoryLimit-1)/memoryLimit, maxJoinPartitions))
+		var cipher *TempCipher
+		if *encryptTemp {
+			if cipher, err = NewTempCipher(); err != nil {
+				fatalf("Failed to create the temp file key: %v", err)
+			}
+		}
+		if err := joiner.joinPartitioned(corpus, labels, partitions, *tempDir, cipher); err != nil {
+			fatalf("Failed to join: %v", err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		fatalf("Failed to write output: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		fatalf("Failed to write output: %v", err)
+	}
+
+	stats := joiner.stats
+	fmt.Fprintf(os.Stderr, "Joined %s rows: %s matched, %s without labels\n",
+		formatCount(stats.rows), formatCount(stats.matched), formatCount(stats.unmatched))
+	if stats.duplicates > 0 {
+		printWarning("Ignored %s label rows with duplicate addresses", formatCount(stats.duplicates))
+	}
+	if stats.partitions > 0 {
+		fmt.Fprintf(os.Stderr, "Partitioned both inputs into %d spill files in %s\n", stats.partitions, *tempDir)
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// joinLabels is a label file with a quoted value and a duplicate key
+const joinLabels = `entity,address,risk
+"Exchange, Inc.",0xaaa,low
+Mixer,0xbbb,high
+Duplicate,0xaaa,none
+`
+
+func runTestJoin(t *testing.T, corpus string, left bool, partitions int, c *TempCipher) (string, JoinStats) {
+	t.Helper()
+	var out bytes.Buffer
+	csvWriter := csv.NewWriter(&out)
+
+	labels := csv.NewReader(strings.NewReader(joinLabels))
+	labels.FieldsPerRecord = -1
+	header, _ := labels.Read()
+	joiner, err := newJoiner(header, "address", left, csvWriter)
+	if err != nil {
+		t.Fatalf("newJoiner failed: %v", err)
+	}
+
+	if partitions == 0 {
+		rows, err := joiner.loadLabels(labels)
+		if err != nil {
+			t.Fatalf("loadLabels failed: %v", err)
+		}
+		wroteHeader := false
+		err = joiner.joinCorpus(strings.NewReader(corpus), rows, &wroteHeader)
+		if err != nil {
+			t.Fatalf("joinCorpus failed: %v", err)
+		}
+	} else if err := joiner.joinPartitioned(strings.NewReader(corpus), labels, partitions, t.TempDir(), c); err != nil {
+		t.Fatalf("joinPartitioned failed: %v", err)
+	}
+	csvWriter.Flush()
+	return out.String(), joiner.stats
+}
+
+func TestJoin(t *testing.T) {
+	corpus := "h1,0xaaa\nh2,0xccc\nh3,0xbbb\n"
+
+	out, stats := runTestJoin(t, corpus, false, 0, nil)
+	expected := "hash,address,entity,risk\nh1,0xaaa,\"Exchange, Inc.\",low\nh3,0xbbb,Mixer,high\n"
+	if out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+	if stats.matched != 2 || stats.unmatched != 1 || stats.duplicates != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	// A left join keeps rows without labels
+	out, _ = runTestJoin(t, corpus, true, 0, nil)
+	if !strings.Contains(out, "h2,0xccc,,\n") {
+		t.Errorf("Expected unlabeled row to be kept, got %q", out)
+	}
+}
+
+func TestJoinPartitioned(t *testing.T) {
+	var corpus strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&corpus, "0x%03x\n", i)
+	}
+	corpus.WriteString("0xaaa\n0xbbb\n")
+
+	// Partitioning changes the row order but not the result
+	inMemory, _ := runTestJoin(t, corpus.String(), true, 0, nil)
+	partitioned, stats := runTestJoin(t, corpus.String(), true, 7, nil)
+	if stats.partitions != 7 || stats.matched != 2 || stats.rows != 102 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	sorted := func(s string) string {
+		lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+		sort.Strings(lines[1:])
+		return strings.Join(lines, "\n")
+	}
+	if sorted(partitioned) != sorted(inMemory) {
+		t.Errorf("Partitioned join differs from in-memory join:\n%s\n---\n%s", partitioned, inMemory)
+	}
+
+	// Encrypted partitions join the same way
+	cipher, err := NewTempCipher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, _ := runTestJoin(t, corpus.String(), true, 7, cipher)
+	if encrypted != partitioned {
+		t.Errorf("Encrypted partitions changed the join:\n%s\n---\n%s", encrypted, partitioned)
+	}
+}
+
+func TestNewJoinerMissingKey(t *testing.T) {
+	if _, err := newJoiner([]string{"entity", "risk"}, "address", false, nil); err == nil {
+		t.Errorf("Expected an error for a label file without the key column")
+	}
+}
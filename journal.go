@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// journalVersion is the format version written to journal headers
+const journalVersion = 1
+
+// journalSettingFlags are the flags that shape the output rows. A journal
+// can only be resumed by a run that sets them the same way. The hash key
+// is fingerprinted instead of a flag, since it may come from --salt-file.
+var journalSettingFlags = []string{
+	"network", "chain", "derivation-scheme", "derivation-path", "generate-hash", "hash-only", "hash-iterations",
+	"contract", "deployer", "init-code-hash", "solana-account", "multisig", "token-program",
+	"btc-address-type", "btc-type-mix", "ens-names", "entity-labels", "include-keys", "key-format", "format",
+}
+
+// JournalHeader is the first line of a journal and describes the run
+type JournalHeader struct {
+	Journal int    `json:"journal"`
+	Output  string `json:"output"`
+	Network string `json:"network"`
+	Offset  int    `json:"offset"` // First index of the run
+	Count   int    `json:"count"`
+	Run     string `json:"run"` // Fingerprint of the base seed and the settings that shape rows
+}
+
+// JournalEntry is a checkpoint: the rows of the first Indices indices of
+// the run, which take the first Bytes bytes of the output, were synced to
+// disk. Indices skipped with --on-error skip have no row.
+type JournalEntry struct {
+	Seq     int    `json:"seq"`
+	Indices int    `json:"indices"`
+	Bytes   int64  `json:"bytes"`
+	SHA256  string `json:"sha256"` // Of the output bytes since the previous checkpoint
+}
+
+// journalLine is an entry read back with the journal offset just past it
+type journalLine struct {
+	JournalEntry
+	end int64
+}
+
+// Journal is a write-ahead journal of an output file. Rows written through
+// it are counted and hashed, and each checkpoint syncs the output before
+// the entry that vouches for it is appended and synced, so every entry
+// describes data that is already durable.
+type Journal struct {
+	file    *os.File
+	output  *os.File
+	segment hash.Hash
+	bytes   int64        // Output bytes written so far
+	base    int          // Indices of the run written before this process started
+	last    JournalEntry // Latest checkpoint
+	dropped error        // Why checkpoints past the resumed one were dropped
+}
+
+// journalRun fingerprints the base seed, the key of keyed hashing and the
+// flags in fs that shape the output rows
+func journalRun(fs *flag.FlagSet, baseSeed string, hashKey []byte) string {
+	var settings strings.Builder
+	settings.WriteString(baseSeed)
+	for _, name := range journalSettingFlags {
+		if f := fs.Lookup(name); f != nil {
+			fmt.Fprintf(&settings, "\n%s=%s", name, f.Value)
+		}
+	}
+	if hashKey != nil {
+		fmt.Fprintf(&settings, "\nhash-key=%s", keyFingerprint(hashKey))
+	}
+	return keyFingerprint([]byte(settings.String()))
+}
+
+// createJournal starts a new journal at path for a run writing to output
+func createJournal(path string, header JournalHeader, output *os.File) (*Journal, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	header.Journal = journalVersion
+	line, _ := json.Marshal(header)
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Journal{file: file, output: output, segment: sha256.New()}, nil
+}
+
+// resumeJournal reopens the journal at path for a rerun of the run in
+// header. The output is cut back to the last checkpoint that matches its
+// contents, and the journal to that checkpoint's entry, and writing
+// continues from there.
+func resumeJournal(path string, header JournalHeader, output *os.File) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*Journal, error) {
+		file.Close()
+		return nil, err
+	}
+
+	recorded, lines, headerEnd, err := readJournal(file)
+	if err != nil {
+		return fail(err)
+	}
+	header.Journal = journalVersion
+	if recorded != header {
+		return fail(fmt.Errorf("%s belongs to a different run (%s %s rows from index %s into %s)",
+			path, recorded.Network, formatCount(recorded.Count), formatCount(recorded.Offset), recorded.Output))
+	}
+	durable, problem := verifyJournal(lines, output)
+
+	// Drop what the checkpoint does not vouch for
+	end := headerEnd
+	if durable.Seq > 0 {
+		end = lines[durable.Seq-1].end
+	}
+	if err := file.Truncate(end); err != nil {
+		return fail(err)
+	}
+	if _, err := file.Seek(end, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	if err := output.Truncate(durable.Bytes); err != nil {
+		return fail(err)
+	}
+	if _, err := output.Seek(durable.Bytes, io.SeekStart); err != nil {
+		return fail(err)
+	}
+	return &Journal{file: file, output: output, segment: sha256.New(), bytes: durable.Bytes, base: durable.Indices, last: durable.JournalEntry, dropped: problem}, nil
+}
+
+// readJournal reads a journal's header and its consistent entries: those
+// numbered from 1 without gaps, each covering at least as much as the one
+// before. Reading stops at the first line that is torn or inconsistent,
+// such as a partial write cut short by a crash.
+func readJournal(r io.Reader) (JournalHeader, []journalLine, int64, error) {
+	var header JournalHeader
+	reader := bufio.NewReader(r)
+	line, err := reader.ReadBytes('\n')
+	if err != nil || json.Unmarshal(line, &header) != nil || header.Journal == 0 {
+		return header, nil, 0, errors.New("not a journal: missing header")
+	}
+	if header.Journal != journalVersion {
+		return header, nil, 0, fmt.Errorf("unsupported journal version %d", header.Journal)
+	}
+	offset := int64(len(line))
+	headerEnd := offset
+
+	var lines []journalLine
+	previous := JournalEntry{}
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		var entry JournalEntry
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if decoder.Decode(&entry) != nil || entry.Seq != previous.Seq+1 || entry.Indices < previous.Indices ||
+			entry.Indices > header.Count || entry.Bytes < previous.Bytes {
+			break
+		}
+		offset += int64(len(line))
+		lines = append(lines, journalLine{entry, offset})
+		previous = entry
+	}
+	return header, lines, headerEnd, nil
+}
+
+// verifyJournal hashes the output segment by segment and returns the last
+// checkpoint whose segments all match, along with the reason the next one
+// does not
+func verifyJournal(lines []journalLine, output io.Reader) (journalLine, error) {
+	var durable journalLine
+	for _, line := range lines {
+		segment := sha256.New()
+		n, err := io.CopyN(segment, output, line.Bytes-durable.Bytes)
+		if err != nil {
+			return durable, fmt.Errorf("checkpoint %d: the output ends after %s of its %s bytes",
+				line.Seq, formatCount(int(durable.Bytes+n)), formatCount(int(line.Bytes)))
+		}
+		if hex.EncodeToString(segment.Sum(nil)) != line.SHA256 {
+			return durable, fmt.Errorf("checkpoint %d: the output differs from what was written", line.Seq)
+		}
+		durable = line
+	}
+	return durable, nil
+}
+
+// Write writes p to the output, counting and hashing it
+func (j *Journal) Write(p []byte) (int, error) {
+	n, err := j.output.Write(p)
+	j.segment.Write(p[:n])
+	j.bytes += int64(n)
+	return n, err
+}
+
+// Indices returns the number of indices of the run covered by the latest
+// checkpoint
+func (j *Journal) Indices() int {
+	return j.last.Indices
+}
+
+// Checkpoint records that the rows of the first indices indices handled by
+// this process are in the output. Everything written so far must be whole
+// rows that have already been flushed through to the journal.
+func (j *Journal) Checkpoint(indices int) error {
+	indices += j.base
+	if indices == j.last.Indices && j.bytes == j.last.Bytes {
+		return nil
+	}
+	if err := j.output.Sync(); err != nil {
+		return err
+	}
+	entry := JournalEntry{Seq: j.last.Seq + 1, Indices: indices, Bytes: j.bytes, SHA256: hex.EncodeToString(j.segment.Sum(nil))}
+	line, _ := json.Marshal(entry)
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.segment.Reset()
+	j.last = entry
+	return nil
+}
+
+// Dropped returns why checkpoints after the one a resumed journal
+// continues from were dropped, or nil if there were none or all matched
+func (j *Journal) Dropped() error {
+	return j.dropped
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// runJournal implements the journal verify subcommand, which reports the
+// indices a journal shows to be durable in its output
+func runJournal(args []string) int {
+	if len(args) == 0 || args[0] != "verify" {
+		fatalf("Usage: addrmint journal verify FILE")
+	}
+	fs := flag.NewFlagSet("journal verify", flag.ExitOnError)
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint journal verify FILE\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	prepareConsole()
+	setupColor(*noColor)
+	if fs.NArg() != 1 {
+		fatalf("journal verify takes exactly one journal file")
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fatalf("Failed to open journal: %v", err)
+	}
+	defer file.Close()
+	header, lines, _, err := readJournal(file)
+	if err != nil {
+		fatalf("Failed to read journal: %v", err)
+	}
+	output, err := os.Open(header.Output)
+	if err != nil {
+		fatalf("Failed to open output: %v", err)
+	}
+	defer output.Close()
+
+	durable, problem := verifyJournal(lines, output)
+	fmt.Fprintf(os.Stderr, "%s of %s indices (%s bytes) are durable in %s after %d checkpoints\n",
+		formatCount(durable.Indices), formatCount(header.Count), formatCount(int(durable.Bytes)), header.Output, durable.Seq)
+	// The durable indices in --range form
+	fmt.Printf("%d-%d\n", header.Offset, header.Offset+durable.Indices)
+	if problem != nil {
+		fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, fmt.Sprintf("Journal does not match the output: %v", problem)))
+		return 1
+	}
+	return 0
+}
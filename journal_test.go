@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalResume(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "out.txt")
+	journalPath := filepath.Join(dir, "out.journal")
+	header := JournalHeader{Output: outputPath, Network: "ethereum", Offset: 100, Count: 6, Run: "abc"}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := createJournal(journalPath, header, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		journal.Write([]byte(row))
+		if i%2 == 1 {
+			if err := journal.Checkpoint(i + 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := journal.Checkpoint(4); err != nil {
+		t.Fatal(err)
+	}
+	// A row past the last checkpoint and a torn entry, as a crash leaves them
+	journal.Write([]byte("e\n"))
+	journal.file.WriteString(`{"seq":3,"ind`)
+	journal.Close()
+	output.Close()
+
+	// Another run cannot take the journal over
+	output, err = os.OpenFile(outputPath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	other := header
+	other.Run = "def"
+	if _, err := resumeJournal(journalPath, other, output); err == nil || !strings.Contains(err.Error(), "different run") {
+		t.Fatalf("Expected a different run to be refused, got %v", err)
+	}
+
+	journal, err = resumeJournal(journalPath, header, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if journal.Indices() != 4 || journal.Dropped() != nil {
+		t.Fatalf("Expected to resume after 4 indices, got %d (%v)", journal.Indices(), journal.Dropped())
+	}
+	// The rest of the run continues from index 4
+	journal.Write([]byte("e\nf\n"))
+	if err := journal.Checkpoint(2); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	data, _ := os.ReadFile(outputPath)
+	if string(data) != "a\nb\nc\nd\ne\nf\n" {
+		t.Errorf("Unexpected output %q", data)
+	}
+	file, err := os.Open(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	recorded, lines, _, err := readJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded.Offset != 100 || len(lines) != 3 || lines[2].Indices != 6 {
+		t.Fatalf("Unexpected journal %+v %+v", recorded, lines)
+	}
+	durable, problem := verifyJournal(lines, bytes.NewReader(data))
+	if problem != nil || durable.Seq != 3 {
+		t.Errorf("Expected all 3 checkpoints to verify, got %d (%v)", durable.Seq, problem)
+	}
+
+	// Damage in the second segment leaves only the first one durable
+	data[5] = 'x'
+	durable, problem = verifyJournal(lines, bytes.NewReader(data))
+	if problem == nil || durable.Seq != 1 || durable.Indices != 2 {
+		t.Errorf("Expected checkpoint 1 to be the last durable one, got %d (%v)", durable.Seq, problem)
+	}
+	durable, problem = verifyJournal(lines, bytes.NewReader(data[:3]))
+	if problem == nil || durable.Seq != 0 {
+		t.Errorf("Expected a short output to fail checkpoint 1, got %d (%v)", durable.Seq, problem)
+	}
+}
+
+func TestReadJournalRejectsNonJournals(t *testing.T) {
+	for _, content := range []string{"", "0x1234\n", `{"journal":99}` + "\n"} {
+		if _, _, _, err := readJournal(strings.NewReader(content)); err == nil {
+			t.Errorf("Expected %q to be rejected", content)
+		}
+	}
+}
+
+func TestJournalRun(t *testing.T) {
+	run := func(key []byte, args ...string) string {
+		fs := flag.NewFlagSet("addrmint", flag.ContinueOnError)
+		fs.String("network", "ethereum", "")
+		fs.Bool("include-keys", false, "")
+		fs.String("key-format", "hex", "")
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return journalRun(fs, "seed", key)
+	}
+
+	base := run(nil)
+	if run(nil) != base {
+		t.Error("Expected the same settings to give the same run")
+	}
+	for _, changed := range []string{
+		run(nil, "--include-keys"),
+		run(nil, "--key-format", "wif"),
+		run([]byte("key one")),
+	} {
+		if changed == base {
+			t.Error("Expected a setting that shapes rows to change the run")
+		}
+	}
+	if run([]byte("key one")) == run([]byte("key two")) {
+		t.Error("Expected different hash keys, such as from different salt files, to give different runs")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// addressOnlyEnv hard-disables private key output at runtime when set to
+// anything but an empty string, for binaries that cannot be rebuilt
+const addressOnlyEnv = "ADDRMINT_ADDRESS_ONLY"
+
+// checkPrivateKeyOutput fails when private keys must not be written,
+// either because the binary was built with the addressonly tag or because
+// ADDRMINT_ADDRESS_ONLY is set. Every path that writes private keys checks
+// it before generating anything.
+func checkPrivateKeyOutput() error {
+	if addressOnlyBuild {
+		return errors.New("private key output is disabled in this address-only build")
+	}
+	if os.Getenv(addressOnlyEnv) != "" {
+		return fmt.Errorf("private key output is disabled by %s", addressOnlyEnv)
+	}
+	return nil
+}
+
+// Private key encodings for --key-format
+const (
+	keyFormatHex = "hex" // 0x-prefixed hex, the Ed25519 seed for Solana and TON
+	keyFormatWIF = "wif" // Wallet Import Format, Bitcoin only
+)
+
+// keyFileMode is the permission of outputs holding private keys
+const keyFileMode = 0600
+
+// checkKeyFormat fails if format cannot encode the private keys of network
+func checkKeyFormat(network, format string) error {
+	switch format {
+	case keyFormatHex:
+	case keyFormatWIF:
+		if network != addrmint.Bitcoin {
+			return errors.New("wif keys are only defined for bitcoin")
+		}
+	default:
+		return fmt.Errorf("unknown key format %q (want hex or wif)", format)
+	}
+	return nil
+}
+
+// KeyDeriver derives the keys behind the address of seed. It runs on the
+// workers.
+type KeyDeriver func(seed *[32]byte) (*KeyMaterial, error)
+
+// newKeyDeriver returns the deriver of the keys that --include-keys writes
+// with each address. Keys are derived from the same seed as the address,
+// so they match it on every network, including networks defined by a
+// descriptor. The output layer encodes them as --key-format says.
+func newKeyDeriver(network string, descriptor *addrmint.ChainDescriptor) KeyDeriver {
+	return func(seed *[32]byte) (*KeyMaterial, error) {
+		var publicKey []byte
+		var err error
+		if descriptor != nil {
+			publicKey, err = descriptor.PublicKey(seed[:])
+		} else {
+			publicKey, err = addrmint.PublicKey(network, seed[:])
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &KeyMaterial{publicKey: publicKey, privateKey: slices.Clone(seed[:])}, nil
+	}
+}
+
+// printKeyWarning warns that the output about to be written holds private
+// keys, which is easy to miss when a run was copied from an address-only one
+func printKeyWarning(output string) {
+	if output == "" {
+		output = "stdout"
+	}
+	line := colorize(colorBold+colorRed, "!!! ============================================================ !!!")
+	fmt.Fprintln(os.Stderr, line)
+	fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, fmt.Sprintf("!!! WARNING: writing PRIVATE KEYS to %s", output)))
+	fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, "!!! Anyone who can read it controls every address in it."))
+	fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, "!!! Keep it out of shared storage and delete it when done."))
+	fmt.Fprintln(os.Stderr, line)
+}
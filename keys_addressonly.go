@@ -0,0 +1,8 @@
+//go:build addressonly
+
+package main
+
+// addressOnlyBuild is set in binaries built with the addressonly tag, which
+// only ever write addresses. The compiler drops the private key paths
+// guarded by it.
+const addressOnlyBuild = true
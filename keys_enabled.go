@@ -0,0 +1,6 @@
+//go:build !addressonly
+
+package main
+
+// addressOnlyBuild is set in binaries built with the addressonly tag
+const addressOnlyBuild = false
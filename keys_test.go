@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mr-tron/base58"
+)
+
+func TestCheckPrivateKeyOutput(t *testing.T) {
+	t.Setenv(addressOnlyEnv, "")
+	if err := checkPrivateKeyOutput(); (err == nil) == addressOnlyBuild {
+		t.Errorf("Expected private keys to be allowed only outside address-only builds, got %v", err)
+	}
+	t.Setenv(addressOnlyEnv, "1")
+	if err := checkPrivateKeyOutput(); err == nil {
+		t.Errorf("Expected %s to disable private key output", addressOnlyEnv)
+	}
+}
+
+func TestKeyDeriver(t *testing.T) {
+	deriver := v1Seeds(t, selftestSeed)
+	var job Job
+	var generators workerGenerators
+	deriver.Derive(7, &job.seed)
+
+	// Each network's public key must be the one its address commits to
+	tests := []struct {
+		network string
+		format  string
+		check   func(public []byte, addr string) bool
+	}{
+		{"ethereum", keyFormatHex, func(public []byte, addr string) bool {
+			return common.BytesToAddress(crypto.Keccak256(public[1:])[12:]).Hex() == addr
+		}},
+		{"bitcoin", keyFormatWIF, func(public []byte, addr string) bool {
+			legacy, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(public), addrmint.BitcoinParams(""))
+			return err == nil && legacy.EncodeAddress() == addr
+		}},
+		{"solana", keyFormatHex, func(public []byte, addr string) bool {
+			return base58.Encode(public) == addr
+		}},
+	}
+	for _, tt := range tests {
+		if err := checkKeyFormat(tt.network, tt.format); err != nil {
+			t.Fatal(err)
+		}
+		keys, err := newKeyDeriver(tt.network, nil)(&job.seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.network = tt.network
+		addr, err := generateAddress(&job, &generators)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The output layer encodes the keys as --key-format says
+		layout := &rowLayout{keys: true, keyFormat: tt.format, chain: addrmint.ChainMainnet}
+		row, _, err := layout.appendRow(nil, layout.outputColumns(false), &Record{address: addr, keys: keys})
+		if err != nil {
+			t.Fatal(err)
+		}
+		columns := strings.Split(string(row), ",")
+		private, public := columns[0], columns[1]
+		if columns[2] != addr {
+			t.Errorf("%s: expected the address after the keys, got %s", tt.network, row)
+		}
+		if tt.format == keyFormatWIF {
+			wif, err := btcutil.DecodeWIF(private)
+			if err != nil || !wif.CompressPubKey {
+				t.Fatalf("%s: invalid WIF %s: %v", tt.network, private, err)
+			}
+			private = "0x" + hex.EncodeToString(wif.PrivKey.Serialize())
+		}
+		publicKey, _ := hex.DecodeString(strings.TrimPrefix(public, "0x"))
+		if private != "0x"+hex.EncodeToString(job.seed[:]) || !tt.check(publicKey, addr) {
+			t.Errorf("%s: keys %s,%s do not belong to %s", tt.network, private, public, addr)
+		}
+	}
+
+	if err := checkKeyFormat("ethereum", keyFormatWIF); err == nil {
+		t.Error("Expected WIF keys to be rejected for Ethereum")
+	}
+	if err := checkKeyFormat("bitcoin", "pem"); err == nil {
+		t.Error("Expected an unknown key format to be rejected")
+	}
+}
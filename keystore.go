@@ -0,0 +1,188 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Scrypt parameters of keystore files, matching geth's standard and light
+// settings. Standard files take about a second and 256 MiB to write or
+// unlock; light ones are for throwaway test accounts.
+const (
+	keystoreStandardN = 1 << 18
+	keystoreStandardP = 1
+	keystoreLightN    = 1 << 12
+	keystoreLightP    = 6
+	keystoreScryptR   = 8
+)
+
+// keystoreScryptBudget bounds the memory of the scrypt derivations running
+// at once. Each takes 128*r*N bytes, 256 MiB with standard parameters, so
+// unbounded workers could exhaust memory on a many-core machine.
+const keystoreScryptBudget = 1 << 30
+
+// keystoreConcurrency returns how many scrypt derivations of cost n fit in
+// keystoreScryptBudget, and at least one
+func keystoreConcurrency(n int) int {
+	return max(keystoreScryptBudget/(128*keystoreScryptR*n), 1)
+}
+
+// KeystoreFile is a Web3 Secret Storage (V3) keystore file as geth writes it
+type KeystoreFile struct {
+	Address string         `json:"address"`
+	Crypto  KeystoreCrypto `json:"crypto"`
+	ID      string         `json:"id"`
+	Version int            `json:"version"`
+}
+
+// KeystoreCrypto holds the encrypted key and how to decrypt it
+type KeystoreCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	KDF       string         `json:"kdf"`
+	KDFParams KeystoreScrypt `json:"kdfparams"`
+	MAC       string         `json:"mac"`
+}
+
+// KeystoreScrypt holds the scrypt parameters of a keystore file
+type KeystoreScrypt struct {
+	DKLen int    `json:"dklen"`
+	N     int    `json:"n"`
+	P     int    `json:"p"`
+	R     int    `json:"r"`
+	Salt  string `json:"salt"`
+}
+
+// encryptKeystore encrypts key under password with the given salt, IV and
+// scrypt cost: AES-128-CTR under the first half of the scrypt key, with a
+// Keccak-256 MAC of the second half and the ciphertext
+func encryptKeystore(key *[32]byte, password string, salt, iv []byte, n, r, p int) (KeystoreCrypto, error) {
+	derived, err := scrypt.Key([]byte(password), salt, n, r, p, 32)
+	if err != nil {
+		return KeystoreCrypto{}, err
+	}
+	block, err := aes.NewCipher(derived[:16])
+	if err != nil {
+		return KeystoreCrypto{}, err
+	}
+	ciphertext := make([]byte, len(key))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, key[:])
+
+	c := KeystoreCrypto{
+		Cipher:     "aes-128-ctr",
+		CipherText: hex.EncodeToString(ciphertext),
+		KDF:        "scrypt",
+		KDFParams:  KeystoreScrypt{DKLen: 32, N: n, P: p, R: r, Salt: hex.EncodeToString(salt)},
+		MAC:        hex.EncodeToString(crypto.Keccak256(derived[16:32], ciphertext)),
+	}
+	c.CipherParams.IV = hex.EncodeToString(iv)
+	return c, nil
+}
+
+// KeystoreWriter writes each Ethereum key of a run as a V3 keystore file
+// in dir, named like geth names them. It runs on the workers, as scrypt
+// is far slower than deriving the address, and lets only as many of them
+// encrypt at once as keystoreScryptBudget allows.
+type KeystoreWriter struct {
+	dir      string
+	password string
+	n, p     int
+	created  time.Time     // Timestamp in every file name of the run
+	scrypt   chan struct{} // Semaphore of the derivations running at once
+}
+
+// NewKeystoreWriter creates dir if needed, readable by its owner only
+func NewKeystoreWriter(dir, password string, light bool) (*KeystoreWriter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	w := &KeystoreWriter{dir: dir, password: password, n: keystoreStandardN, p: keystoreStandardP, created: time.Now().UTC()}
+	if light {
+		w.n, w.p = keystoreLightN, keystoreLightP
+	}
+	w.scrypt = make(chan struct{}, keystoreConcurrency(w.n))
+	return w, nil
+}
+
+// Write encrypts the key in seed to a new keystore file
+func (w *KeystoreWriter) Write(seed *[32]byte) error {
+	privateKey, err := crypto.ToECDSA(seed[:])
+	if err != nil {
+		return fmt.Errorf("failed to create private key: %w", err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	var random [32 + 16 + 16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return err
+	}
+	salt, iv, id := random[:32], random[32:48], random[48:]
+	w.scrypt <- struct{}{}
+	c, err := encryptKeystore(seed, w.password, salt, iv, w.n, keystoreScryptR, w.p)
+	<-w.scrypt
+	if err != nil {
+		return err
+	}
+	// The ID is a random version 4 UUID
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	file := KeystoreFile{
+		Address: hex.EncodeToString(address[:]),
+		Crypto:  c,
+		ID:      fmt.Sprintf("%x-%x-%x-%x-%x", id[:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		Version: 3,
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	// UTC--2006-01-02T15-04-05.000000000Z--address, as geth names its files
+	name := fmt.Sprintf("UTC--%s--%s", w.created.Format("2006-01-02T15-04-05.000000000Z"), file.Address)
+	f, err := os.OpenFile(filepath.Join(w.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, keyFileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// keystoreLinker writes the keystore file of each address and adds no
+// columns, so keystores are written on the workers alongside the address
+func (w *KeystoreWriter) keystoreLinker(seed *[32]byte) (*LinkedColumns, error) {
+	if err := w.Write(seed); err != nil {
+		return nil, fmt.Errorf("failed to write keystore: %w", err)
+	}
+	return &LinkedColumns{}, nil
+}
+
+// readKeystorePassword reads --keystore-password, from a file for @FILE
+// without its trailing newline
+func readKeystorePassword(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
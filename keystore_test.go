@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestEncryptKeystore(t *testing.T) {
+	// Scrypt test vector of the Web3 Secret Storage definition
+	decode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	key := (*[32]byte)(decode("7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"))
+	salt := decode("ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19")
+	iv := decode("83dbcc02d8ccb40e466191a123791e0e")
+	c, err := encryptKeystore(key, "testpassword", salt, iv, 262144, 1, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CipherText != "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c" {
+		t.Errorf("Unexpected ciphertext %s", c.CipherText)
+	}
+	if c.MAC != "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097" {
+		t.Errorf("Unexpected MAC %s", c.MAC)
+	}
+}
+
+func TestKeystoreWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keystore")
+	w, err := NewKeystoreWriter(dir, "secret", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seed [32]byte
+	seed[31] = 1
+	if _, err := w.keystoreLinker(&seed); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one keystore file, got %v (%v)", entries, err)
+	}
+	address := common.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	name := entries[0].Name()
+	if !strings.HasPrefix(name, "UTC--") || !strings.HasSuffix(name, "--"+hex.EncodeToString(address[:])) {
+		t.Errorf("Unexpected keystore file name %s", name)
+	}
+	info, err := entries[0].Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != keyFileMode {
+		t.Errorf("Expected mode %o, got %o", keyFileMode, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file KeystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	params := file.Crypto.KDFParams
+	if file.Version != 3 || file.Address != hex.EncodeToString(address[:]) || params.N != keystoreLightN || params.P != keystoreLightP || params.R != keystoreScryptR {
+		t.Errorf("Unexpected keystore %s", data)
+	}
+
+	// The file must decrypt back to the key with the password
+	salt, _ := hex.DecodeString(params.Salt)
+	iv, _ := hex.DecodeString(file.Crypto.CipherParams.IV)
+	c, err := encryptKeystore(&seed, "secret", salt, iv, params.N, params.R, params.P)
+	if err != nil || c.CipherText != file.Crypto.CipherText || c.MAC != file.Crypto.MAC {
+		t.Errorf("Keystore does not match its key (%v)", err)
+	}
+}
+
+func TestKeystoreConcurrency(t *testing.T) {
+	// Standard derivations take 256 MiB each, light ones 4 MiB
+	if n := keystoreConcurrency(keystoreStandardN); n != 4 {
+		t.Errorf("Expected 4 standard derivations at once, got %d", n)
+	}
+	if n := keystoreConcurrency(keystoreLightN); n != 256 {
+		t.Errorf("Expected 256 light derivations at once, got %d", n)
+	}
+	if n := keystoreConcurrency(1 << 24); n != 1 {
+		t.Errorf("Expected one derivation over the budget, got %d", n)
+	}
+}
+
+func TestReadKeystorePassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if password, err := readKeystorePassword("@" + path); err != nil || password != "hunter2" {
+		t.Errorf("Expected hunter2, got %q (%v)", password, err)
+	}
+	if password, _ := readKeystorePassword("plain"); password != "plain" {
+		t.Errorf("Expected plain, got %q", password)
+	}
+}
@@ -1,51 +1,74 @@
 package main
 
 import (
-	"crypto/ed25519"
+	"bufio"
+	"context"
+	"crypto/fips140"
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/hex"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
-	"github.com/blocto/solana-go-sdk/types"
-	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/btcsuite/btcd/btcutil"
-	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
 )
 
 // Version information (can be overridden by build flags)
 var version = "dev"
 
+// Crypto and hash backends of the --crypto-backend and --hash-backend flags
+const (
+	backendSDK        = addrmint.BackendSDK
+	backendNative     = addrmint.BackendNative
+	hashBackendGeth   = addrmint.HashBackendGeth
+	hashBackendReused = addrmint.HashBackendReused
+)
+
 // Job represents a single address generation task
 type Job struct {
-	index   int
-	seed    string
-	network string
+	index       int
+	seed        [32]byte
+	network     string
+	backend     string
+	hashBackend string
+	btcTypes    *addrmint.BitcoinTypeMix    // Bitcoin address types to pick from, nil for legacy only
+	chain       string                      // Chain to encode addresses for, mainnet when ""
+	contract    *addrmint.ContractGenerator // Predicts contract addresses instead, with the seed as nonce or salt
+	plugin      string                      // Path of the plugin generating the addresses of a network that is not built in
+	descriptor  *addrmint.ChainDescriptor   // Definition of a network that is not built in, from --network-dir
 }
 
-// Result represents the result of a job
-type Result struct {
-	index   int
-	address string
-}
+// Supported progress bar styles
+const (
+	progressStyleAuto    = "auto"    // Unicode unless the console cannot render it
+	progressStyleUnicode = "unicode" // Block characters
+	progressStyleASCII   = "ascii"   // Plain ASCII for legacy consoles
+)
 
 // ProgressBar displays a visual progress bar
 type ProgressBar struct {
 	total     int
-	current   int
+	current   int // Rows generated and collected
+	written   int // Rows written to the output
 	width     int
 	lastPrint time.Time
 	mu        sync.Mutex
+	plain     bool          // Emit machine-readable progress lines for a parent process
+	rows      *atomic.Int64 // Source of the written counter, if tracked
+	tracked   bool          // Written count is tracked separately from the generated count
+	fill      string        // Character for the completed part of the bar
+	empty     string        // Character for the remaining part of the bar
+	lastWidth int           // Length of the previous line, for clearing leftovers
 }
 
 // NewProgressBar creates a new progress bar
@@ -54,95 +77,1115 @@ func NewProgressBar(total int, width int) *ProgressBar {
 		total:     total,
 		width:     width,
 		lastPrint: time.Now().Add(-1 * time.Second), // Start immediately
+		fill:      "█",
+		empty:     "░",
 	}
 }
 
-// Update updates the progress bar
+// SetStyle selects the characters used to draw the bar
+func (pb *ProgressBar) SetStyle(style string) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	if style == progressStyleASCII {
+		pb.fill, pb.empty = "#", "-"
+	} else {
+		pb.fill, pb.empty = "█", "░"
+	}
+}
+
+// TrackWritten makes the bar report rows written to the output, read from rows,
+// in addition to rows generated
+func (pb *ProgressBar) TrackWritten(rows *atomic.Int64) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.rows = rows
+	pb.tracked = true
+}
+
+// Update updates the progress bar with the number of rows generated
 func (pb *ProgressBar) Update(current int) {
 	pb.mu.Lock()
 	defer pb.mu.Unlock()
 
 	pb.current = current
+	pb.render(false)
+}
+
+// UpdateWritten updates the progress bar with explicit generated and written counts
+func (pb *ProgressBar) UpdateWritten(current, written int) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	pb.current = current
+	pb.written = written
+	pb.tracked = true
+	pb.render(false)
+}
+
+// Finish draws the final state of the bar once all output has been written
+func (pb *ProgressBar) Finish() {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	pb.render(true)
+}
+
+// render draws the bar, limiting the refresh rate unless forced
+func (pb *ProgressBar) render(force bool) {
+	if pb.rows != nil {
+		pb.written = int(pb.rows.Load())
+	} else if !pb.tracked {
+		pb.written = pb.current
+	}
 
 	// Only update the display if enough time has passed (limit refresh rate)
-	if time.Since(pb.lastPrint) < 100*time.Millisecond && current < pb.total {
+	done := pb.written >= pb.total
+	if !force && time.Since(pb.lastPrint) < 100*time.Millisecond && !done {
 		return
 	}
+	if done && pb.lastPrint.IsZero() {
+		return // Completion has already been drawn
+	}
 
 	pb.lastPrint = time.Now()
-	percent := float64(pb.current) / float64(pb.total)
+
+	// Report raw counts to the parent process when running as a shard
+	if pb.plain {
+		fmt.Fprintf(os.Stderr, "%s%d %d\n", shardProgressPrefix, pb.current, pb.written)
+		if done {
+			pb.lastPrint = time.Time{}
+		}
+		return
+	}
+
+	percent := float64(pb.written) / float64(pb.total)
 	filled := int(percent * float64(pb.width))
+	if filled > pb.width {
+		filled = pb.width
+	}
 
 	// Create the bar
-	bar := strings.Repeat("█", filled) + strings.Repeat("░", pb.width-filled)
+	bar := strings.Repeat(pb.fill, filled) + strings.Repeat(pb.empty, pb.width-filled)
 
-	// Show the progress bar
-	fmt.Fprintf(os.Stderr, "\r[%s] %d/%d (%.2f%%) ", bar, pb.current, pb.total, percent*100)
+	// Show the progress bar, with the generated count when it differs from the written one
+	var line string
+	if pb.tracked {
+		line = fmt.Sprintf("[%s] %s/%s written (%.2f%%), %s generated ", bar,
+			formatCount(pb.written), formatCount(pb.total), percent*100, formatCount(pb.current))
+	} else {
+		line = fmt.Sprintf("[%s] %s/%s (%.2f%%) ", bar, formatCount(pb.written), formatCount(pb.total), percent*100)
+	}
+
+	// Pad with spaces to clear what is left of a longer previous line; consoles
+	// without ANSI support don't understand erase sequences
+	width := utf8.RuneCountInString(line)
+	if width < pb.lastWidth {
+		line += strings.Repeat(" ", pb.lastWidth-width)
+	}
+	pb.lastWidth = width
+	fmt.Fprint(os.Stderr, "\r"+line)
 
 	// If we're done, print a newline
-	if pb.current >= pb.total {
+	if done {
 		fmt.Fprintln(os.Stderr)
+		pb.lastPrint = time.Time{}
 	}
 }
 
 func main() {
+	defer redactPanic()
+
+	// Subcommands are dispatched before the generator flags are parsed
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "selftest":
+			os.Exit(runSelftest(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "normalize":
+			os.Exit(runNormalize(os.Args[2:]))
+		case "sample":
+			os.Exit(runSample(os.Args[2:]))
+		case "stats":
+			os.Exit(runStats(os.Args[2:]))
+		case "split":
+			os.Exit(runSplit(os.Args[2:]))
+		case "merge":
+			os.Exit(runMerge(os.Args[2:]))
+		case "join":
+			os.Exit(runJoin(os.Args[2:]))
+		case "vanity":
+			os.Exit(runVanity(os.Args[2:]))
+		case "bench":
+			os.Exit(runBench(os.Args[2:]))
+		case "audit":
+			os.Exit(runAudit(os.Args[2:]))
+		case "journal":
+			os.Exit(runJournal(os.Args[2:]))
+		case "rows":
+			os.Exit(runRows(os.Args[2:]))
+		}
+	}
+
 	// Parse command line flags
 	showVersion := flag.Bool("version", false, "Show version information")
-	network := flag.String("network", "", "Blockchain network (ethereum, bitcoin, solana)")
+	network := flag.String("network", "", "Blockchain network (ethereum, bitcoin, solana, ton, or one defined in --network-dir or --plugin-dir)")
+	networkDir := flag.String("network-dir", "", "Directory of YAML descriptors (*.yaml) defining bech32 and Base58Check networks that are not built in")
+	pluginDir := flag.String("plugin-dir", "", "Directory of addrmint-NETWORK plugin executables generating networks that are not built in")
+	chain := flag.String("chain", addrmint.ChainMainnet, "Chain to encode addresses for (mainnet, testnet, signet, regtest); signet and regtest are Bitcoin only")
 	count := flag.Int("count", 1, "Number of addresses to generate")
 	seedInt := flag.Int64("seed", 0, "Random seed as integer (0 for random seed)")
+	seedShares := flag.String("seed-shares", "", "Comma-separated files of hex secrets, one per operator, combined into the seed so no single operator can reproduce the run")
+	mnemonicFlag := flag.String("mnemonic", "", "Start from this BIP-39 mnemonic instead of an integer seed (@FILE reads it from a file)")
+	mnemonicPassphrase := flag.String("mnemonic-passphrase", "", "Optional BIP-39 passphrase for --mnemonic or --generate-mnemonic")
+	generateMnemonic := flag.Int("generate-mnemonic", 0, "Start from a new random BIP-39 mnemonic of this many words (12 or 24), printed to stderr")
+	derivationScheme := flag.String("derivation-scheme", addrmint.DerivationV1, "How each address seed is derived from the base seed (v1, v2)")
+	derivationPath := flag.String("derivation-path", "", "Derive keys along this BIP-32/SLIP-10 path from the seed, with {i} for the address index, e.g. m/44'/60'/0'/0/{i}")
 	workers := flag.Int("workers", runtime.NumCPU(), "Number of worker goroutines")
 	batchSize := flag.Int("batch-size", 1000, "Number of addresses to batch before reporting progress")
 	outputBufferSize := flag.Int("output-buffer", 10000, "Size of the output buffer for results")
+	resultBatchSize := flag.Int("result-batch", 64, "Number of consecutive indices each worker takes at once and sends to the collector together")
 	outputFile := flag.String("output", "", "Output file path (default: stdout)")
+	writeBuffer := flag.Int("write-buffer", 64*1024, "Size in bytes of the output write buffer")
+	minFreeMB := flag.Int("min-free-mb", 1024, "Free space in MiB to keep on the output's filesystem")
+	lowSpace := flag.String("low-space", onErrorAbort, "What to do when free space drops below --min-free-mb during the run (abort, pause)")
+	auditLog := flag.String("audit-log", "", "Append a hash-chained record of written keys to this file")
+	journalFile := flag.String("journal", "", "Keep a write-ahead journal of synced checkpoints of --output in this file")
+	journalInterval := flag.Int("journal-interval", 100000, "Indices between --journal checkpoints")
+	offsetIndexFile := flag.String("offset-index", "", "Write the byte offset of every --offset-index-interval-th output row to this binary file, for seeking to rows")
+	offsetIndexInterval := flag.Int("offset-index-interval", defaultOffsetIndexRows, "Rows between --offset-index entries")
+	resume := flag.Bool("resume", false, "Continue the run recorded in --journal from its last checkpoint that matches the output")
+	dryRun := flag.Bool("dry-run", false, "Check the flags, output paths and sinks, then exit without generating")
+	format := flag.String("format", outputFormatPlain, "Output format (plain, csv, ndjson, json, avro, parquet)")
+	avroCodec := flag.String("avro-codec", avroCodecDeflate, "Block codec of --format avro output (null, deflate, snappy)")
+	parquetCodec := flag.String("parquet-codec", parquetCodecSnappy, "Page codec of --format parquet output (none, snappy, gzip)")
+	parquetRowGroup := flag.Int("parquet-row-group", defaultParquetRowGroup, "Rows per row group of --format parquet output, each buffered in memory until written")
+	redisURL := flag.String("redis-url", "", "Load the output rows into Redis at this redis://[user:password@]host[:port][/db] URL instead of writing them out")
+	redisKey := flag.String("redis-key", "addresses", "Key of the Redis set or Bloom filter that --redis-url loads")
+	redisType := flag.String("redis-type", redisTypeSet, "Redis structure that --redis-url loads (set, bloom)")
+	redisOnError := flag.String("redis-on-error", onErrorAbort, "What to do when Redis fails (abort, skip to drop Redis and keep the other sinks)")
+	redisDeadLetter := flag.String("redis-dead-letter", "", "Write rows that Redis still rejects after the retries to this file")
+	natsURL := flag.String("nats-url", "", "Publish the output rows to NATS JetStream at this nats://[user:password@|token@]host[:port] URL instead of writing them out")
+	natsSubject := flag.String("nats-subject", "", "Subject that --nats-url publishes to; a JetStream stream must capture it")
+	natsBatch := flag.Int("nats-batch", 1000, "Rows per message published with --nats-url")
+	natsOnError := flag.String("nats-on-error", onErrorAbort, "What to do when NATS fails (abort, skip to drop NATS and keep the other sinks)")
+	natsDeadLetter := flag.String("nats-dead-letter", "", "Write rows that NATS still rejects after the retries to this file")
+	sinkRetries := flag.Int("sink-retries", 3, "Times Redis and NATS are reconnected after a failure before it counts")
+	sinkRetryBackoff := flag.Duration("sink-retry-backoff", time.Second, "Delay before the first reconnection, doubled for every further attempt")
+	sinkRetryJitter := flag.Float64("sink-retry-jitter", 0.5, "Fraction of each reconnection delay that is randomized (0 to 1)")
+	directIO := flag.Bool("direct-io", false, "Write the output file with O_DIRECT using aligned writes (Linux only)")
+	writeQueue := flag.Int("write-queue", 64, "Number of 64 KiB output chunks queued for the writer goroutine (0 writes on the collector)")
 	generateHash := flag.Bool("generate-hash", false, "Prefix each address with a SHA-256 hash (first 6 characters) and comma")
+	hashMapFile := flag.String("hash-map", "", "With --generate-hash, write hash,address rows to this file and only hashes to the output")
+	hashOnly := flag.Bool("hash-only", false, "Write only the full HMAC-SHA256 hash of each address, never the address")
+	hashKeyHex := flag.String("hash-key", "", "Hex encoded key for --hash-only (default: random key printed on stderr)")
+	hashIterations := flag.Int("hash-iterations", 1, "Number of HMAC rounds per address for --hash-only")
+	saltFile := flag.String("salt-file", "", "Read the --hash-only key from this file, or save a new random key there if it does not exist")
+	manifestFile := flag.String("manifest", "", "Write a JSON manifest describing the run to this file")
+	onError := flag.String("on-error", onErrorAbort, "What to do when an address fails to generate (abort, skip)")
+	errorFile := flag.String("error-file", "", "With --on-error skip, write index,error rows for failed addresses to this file")
+	indicesFile := flag.String("indices-file", "", "Generate only the indices listed in this file, one index or START-END range per line")
+	indexRange := flag.String("range", "", "Generate indices START up to but not including END (START-END), instead of --count from 0")
+	solanaAccount := flag.String("solana-account", solanaAccountWallet, "Solana account type to generate (wallet, nonce, multisig, mint)")
+	multisig := flag.String("multisig", "2-of-3", "Signer threshold of --solana-account multisig accounts, as M-of-N")
+	tokenProgram := flag.String("token-program", "spl", "Token program of --solana-account mint accounts (spl, token-2022)")
+	ensNames := flag.Bool("ens-names", false, "Write a deterministic ENS-style name before each Ethereum address")
+	btcAddressType := flag.String("btc-address-type", "", "Bitcoin address type of every address (legacy, p2sh-segwit, segwit, taproot)")
+	btcTypeMix := flag.String("btc-type-mix", "", "Mix of Bitcoin address types as type=weight pairs, e.g. legacy=0.2,segwit=0.6,taproot=0.2 (legacy, p2sh-segwit, segwit, taproot)")
+	entityLabels := flag.Bool("entity-labels", false, "Write a deterministic entity name, country and KYC tier before each address")
+	includeKeys := flag.Bool("include-keys", false, "Write the private and public key before each address, in a file only its owner can read")
+	keyFormat := flag.String("key-format", keyFormatHex, "Private key encoding of --include-keys (hex, wif for Bitcoin)")
+	keystoreDir := flag.String("keystore-dir", "", "Also write each Ethereum key as a geth V3 keystore file in this directory")
+	keystorePassword := flag.String("keystore-password", "", "Password encrypting the --keystore-dir files (@FILE reads it from a file)")
+	keystoreLightKDF := flag.Bool("keystore-light-kdf", false, "Encrypt --keystore-dir files with geth's fast, weak light scrypt parameters, for test accounts")
+	contractOpcode := flag.String("contract", "", "Predict the Ethereum contract addresses a deployer creates with this opcode (create, create2), using the indices as nonces or salts")
+	deployer := flag.String("deployer", "", "Address of the deployer of --contract, 0x-prefixed")
+	initCodeHash := flag.String("init-code-hash", "", "Keccak-256 of the init code of --contract create2, 0x-prefixed")
+	cryptoBackend := flag.String("crypto-backend", backendSDK, "Crypto backend for key derivation (sdk, native)")
+	hashBackend := flag.String("hash-backend", hashBackendGeth, "Hash backend for Ethereum address hashing (geth, reused)")
+	fipsMode := flag.Bool("fips", false, "Restrict hashing and encryption to FIPS 140-3 approved algorithms and record compliance in the manifest")
+	pinWorkers := flag.Bool("pin-workers", false, "Pin each worker to a CPU and report per-worker throughput (Linux only)")
+	workerStats := flag.Bool("worker-stats", false, "Report each worker's throughput at the end of the run and flag stragglers")
+	hungWorkerTimeout := flag.Duration("hung-worker-timeout", 0, "Warn when a worker spends longer than this on one address, e.g. 30s (0 disables)")
+	stallTimeout := flag.Duration("stall-timeout", 0, "React when no rows are collected or written for this long, e.g. 5m (0 disables)")
+	onStall := flag.String("on-stall", onErrorAbort, "What to do when --stall-timeout expires (abort, dump)")
+	checkInvariants := flag.Bool("check-invariants", false, "Assert that every index is emitted exactly once and in order, aborting on the first violation")
+	restartHungWorkers := flag.Bool("restart-hung-workers", false, "Replace workers that exceed --hung-worker-timeout; the replacement generates the stuck address again")
+	progressStyle := flag.String("progress-style", progressStyleAuto, "Progress bar style (auto, unicode, ascii)")
+	processes := flag.Int("processes", 1, "Number of child processes to split the work across")
+	encryptTemp := flag.Bool("encrypt-temp", false, "Encrypt the shard files of --processes with a key held only in memory")
+	shardIndex := flag.Int("shard-index", -1, "Internal: shard handled by this child process")
+	shardOffset := flag.Int("shard-offset", 0, "Internal: index of the first address in this shard")
+	noColor := flag.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	numberFormatFlag := flag.String("number-format", numberFormatGrouped, "Number format for progress and summaries (grouped, si, raw)")
 	flag.Parse()
 
+	// Prepare the console before anything is printed
+	unicodeConsole := prepareConsole()
+	setupColor(*noColor)
+	if err := setupNumberFormat(*numberFormatFlag); err != nil {
+		fatalf("Invalid --number-format: %v", err)
+	}
+
 	// Show version if requested
 	if *showVersion {
 		fmt.Fprintf(os.Stderr, "AddrMint v%s - High-performance blockchain address generator\n", version)
+		if addressOnlyBuild {
+			fmt.Fprintf(os.Stderr, "Address-only build: private key output is disabled\n")
+		}
 		os.Exit(0)
 	}
 
 	startTime := time.Now()
 
 	// Print banner
-	fmt.Fprintf(os.Stderr, "AddrMint v%s - Blockchain Address Generator\n", version)
-	fmt.Fprintf(os.Stderr, "==========================================\n")
+	printBanner("AddrMint v%s - Blockchain Address Generator", version)
+	printBanner("==========================================")
 
 	// Validate network
 	if *network == "" {
-		log.Fatal("Network is required. Use --network ethereum|bitcoin|solana|ton")
+		fatalf("Network is required. Use --network ethereum|bitcoin|solana|ton")
+	}
+
+	// Networks that are not built in are defined by a descriptor, or else
+	// generated by a plugin
+	var plugin string
+	var descriptor *addrmint.ChainDescriptor
+	if !slices.Contains(addrmint.Networks, *network) {
+		if *networkDir != "" {
+			descriptors, err := addrmint.LoadChainDescriptors(*networkDir)
+			if err != nil {
+				fatalf("Failed to load network descriptors: %v", err)
+			}
+			descriptor = descriptors[*network]
+		}
+		switch {
+		case descriptor != nil:
+			fmt.Fprintf(os.Stderr, "Generating %s addresses from the descriptor in %s\n", *network, *networkDir)
+		case *pluginDir != "":
+			var err error
+			if plugin, err = addrmint.FindPlugin(*pluginDir, *network); err != nil {
+				fatalf("Unknown network: %v", err)
+			}
+			// Start the plugin once so a broken one fails before anything is written
+			generator, err := addrmint.StartPlugin(plugin)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			generator.Close()
+			fmt.Fprintf(os.Stderr, "Generating %s addresses with plugin %s\n", *network, plugin)
+		case *networkDir != "":
+			fatalf("Unknown network: no descriptor in %s defines %s", *networkDir, *network)
+		default:
+			fatalf("Network must be ethereum, bitcoin, solana, or ton, or be defined in --network-dir or --plugin-dir")
+		}
+	}
+
+	if !slices.Contains(addrmint.DerivationSchemes, *derivationScheme) {
+		fatalf("Derivation scheme must be %s", strings.Join(addrmint.DerivationSchemes, " or "))
+	}
+
+	if *writeBuffer < 1 {
+		fatalf("Write buffer size must be at least 1")
+	}
+
+	if *hungWorkerTimeout < 0 {
+		fatalf("--hung-worker-timeout cannot be negative")
+	}
+	if *restartHungWorkers && *hungWorkerTimeout == 0 {
+		fatalf("--restart-hung-workers requires --hung-worker-timeout")
+	}
+	if *resume && *journalFile == "" {
+		fatalf("--resume requires --journal")
+	}
+	if *journalFile != "" {
+		switch {
+		case *outputFile == "":
+			fatalf("--journal requires --output")
+		case *journalInterval < 1:
+			fatalf("--journal-interval must be at least 1")
+		case *format != outputFormatPlain || *directIO || *hashMapFile != "":
+			fatalf("--journal requires --format plain and cannot be combined with --direct-io or --hash-map")
+		case *processes > 1 || *indicesFile != "":
+			fatalf("--journal cannot be combined with --processes or --indices-file")
+		case *redisURL != "" || *natsURL != "":
+			fatalf("--journal cannot be combined with Redis or NATS sinks, which would receive resumed rows twice")
+		}
+	}
+	if *offsetIndexFile != "" {
+		switch {
+		case *outputFile == "":
+			fatalf("--offset-index requires --output")
+		case *offsetIndexInterval < 1:
+			fatalf("--offset-index-interval must be at least 1")
+		case *format != outputFormatPlain && *format != outputFormatCSV && *format != outputFormatNDJSON:
+			fatalf("--offset-index requires --format plain, csv or ndjson, which write a row per line")
+		case *resume:
+			fatalf("--offset-index cannot be combined with --resume")
+		}
+	}
+	if *stallTimeout < 0 {
+		fatalf("--stall-timeout cannot be negative")
+	}
+	if *onStall != onErrorAbort && *onStall != onStallDump {
+		fatalf("--on-stall must be abort or dump")
+	}
+
+	// Resolve the progress bar style, falling back to ASCII on consoles that can't render Unicode
+	switch *progressStyle {
+	case progressStyleAuto:
+		if unicodeConsole {
+			*progressStyle = progressStyleUnicode
+		} else {
+			*progressStyle = progressStyleASCII
+		}
+	case progressStyleUnicode, progressStyleASCII:
+	default:
+		fatalf("Progress style must be auto, unicode, or ascii")
+	}
+
+	if *writeQueue < 0 {
+		fatalf("Write queue size cannot be negative")
+	}
+
+	if *directIO && *outputFile == "" {
+		fatalf("Direct I/O requires --output")
+	}
+	if *encryptTemp {
+		if *processes <= 1 && *shardIndex < 0 {
+			fatalf("--encrypt-temp requires --processes")
+		}
+		if *directIO {
+			fatalf("--encrypt-temp cannot be combined with --direct-io")
+		}
+	}
+
+	switch *format {
+	case outputFormatPlain:
+	case outputFormatAvro:
+		if *directIO || *processes > 1 {
+			fatalf("--format avro cannot be combined with --direct-io or --processes")
+		}
+		if *outputFile == "" && (*redisURL != "" || *natsURL != "") {
+			fatalf("--format avro with --redis-url or --nats-url requires --output")
+		}
+		if *avroCodec != avroCodecNull && *avroCodec != avroCodecDeflate && *avroCodec != avroCodecSnappy {
+			fatalf("Avro codec must be null, deflate or snappy")
+		}
+	case outputFormatCSV, outputFormatNDJSON, outputFormatJSON, outputFormatParquet:
+		if *directIO || *hashMapFile != "" {
+			fatalf("--format %s cannot be combined with --direct-io or --hash-map", *format)
+		}
+		if *processes > 1 && *format != outputFormatNDJSON {
+			fatalf("--format %s cannot be combined with --processes; use ndjson, whose shards concatenate", *format)
+		}
+		if *redisURL != "" || *natsURL != "" {
+			fatalf("--format %s cannot be combined with Redis or NATS sinks, which take plain rows", *format)
+		}
+		if *format == outputFormatParquet {
+			if *parquetCodec != parquetCodecNone && *parquetCodec != parquetCodecSnappy && *parquetCodec != parquetCodecGzip {
+				fatalf("Parquet codec must be none, snappy or gzip")
+			}
+			if *parquetRowGroup < 1 {
+				fatalf("--parquet-row-group must be at least 1")
+			}
+		}
+	default:
+		fatalf("Format must be plain, csv, ndjson, json, avro or parquet")
+	}
+
+	if *redisURL != "" {
+		if *processes > 1 {
+			fatalf("--redis-url cannot be combined with --processes")
+		}
+		if *redisType != redisTypeSet && *redisType != redisTypeBloom {
+			fatalf("Redis type must be set or bloom")
+		}
+		if *redisOnError != onErrorAbort && *redisOnError != onErrorSkip {
+			fatalf("--redis-on-error must be abort or skip")
+		}
+	}
+	if *natsURL != "" {
+		if *processes > 1 {
+			fatalf("--nats-url cannot be combined with --processes")
+		}
+		if *natsSubject == "" {
+			fatalf("--nats-url requires --nats-subject")
+		}
+		if *natsBatch < 1 {
+			fatalf("NATS batch size must be at least 1")
+		}
+		if *natsOnError != onErrorAbort && *natsOnError != onErrorSkip {
+			fatalf("--nats-on-error must be abort or skip")
+		}
+	}
+	if *redisDeadLetter != "" && *redisURL == "" {
+		fatalf("--redis-dead-letter requires --redis-url")
+	}
+	if *natsDeadLetter != "" && *natsURL == "" {
+		fatalf("--nats-dead-letter requires --nats-url")
+	}
+	if *sinkRetries < 0 {
+		fatalf("--sink-retries cannot be negative")
+	}
+	if *sinkRetryBackoff <= 0 {
+		fatalf("--sink-retry-backoff must be positive")
+	}
+	if *sinkRetryJitter < 0 || *sinkRetryJitter > 1 {
+		fatalf("--sink-retry-jitter must be between 0 and 1")
+	}
+
+	if *resultBatchSize < 1 {
+		fatalf("Result batch size must be at least 1")
+	}
+
+	if *hashMapFile != "" {
+		if !*generateHash {
+			fatalf("--hash-map requires --generate-hash")
+		}
+		if *processes > 1 {
+			fatalf("--hash-map cannot be combined with --processes")
+		}
+	}
+
+	if *minFreeMB < 0 {
+		fatalf("--min-free-mb cannot be negative")
+	}
+	if *lowSpace != onErrorAbort && *lowSpace != lowSpacePause {
+		fatalf("--low-space must be abort or pause")
+	}
+
+	if *onError != onErrorAbort && *onError != onErrorSkip {
+		fatalf("--on-error must be abort or skip")
+	}
+	if *errorFile != "" {
+		if *onError != onErrorSkip {
+			fatalf("--error-file requires --on-error skip")
+		}
+		if *processes > 1 {
+			fatalf("--error-file cannot be combined with --processes")
+		}
+	}
+
+	// Nonce, multisig and mint accounts are written with the accounts that belong with them
+	link, err := newSolanaLinker(*solanaAccount, *multisig, *tokenProgram)
+	if err != nil {
+		fatalf("Invalid --solana-account: %v", err)
+	}
+	linkedNames := solanaColumnNames(*solanaAccount, *multisig)
+	if link != nil {
+		if *network != "solana" {
+			fatalf("--solana-account requires --network solana")
+		}
+		if *hashOnly || *hashMapFile != "" {
+			fatalf("--solana-account %s cannot be combined with --hash-only or --hash-map", *solanaAccount)
+		}
+	}
+	if *ensNames {
+		if *network != "ethereum" {
+			fatalf("--ens-names requires --network ethereum")
+		}
+		if *hashOnly || *hashMapFile != "" {
+			fatalf("--ens-names cannot be combined with --hash-only or --hash-map")
+		}
+		link = ensNameLinker
+		linkedNames = append(linkedNames, ensNameColumn)
+	}
+	if *entityLabels {
+		if *hashOnly || *hashMapFile != "" {
+			fatalf("--entity-labels cannot be combined with --hash-only or --hash-map")
+		}
+		link = chainLinkers(link, entityLabelLinker)
+		linkedNames = append(linkedNames, entityLabelNames...)
+	}
+	// Keys are derived on the workers and written next to the address
+	var keys KeyDeriver
+	if *includeKeys {
+		if err := checkPrivateKeyOutput(); err != nil {
+			fatalf("--include-keys writes private keys: %v", err)
+		}
+		if *hashOnly || *hashMapFile != "" {
+			fatalf("--include-keys cannot be combined with --hash-only or --hash-map")
+		}
+		if *redisURL != "" || *natsURL != "" {
+			fatalf("--include-keys cannot be combined with Redis or NATS sinks")
+		}
+		if err := checkKeyFormat(*network, *keyFormat); err != nil {
+			fatalf("Invalid --key-format: %v", err)
+		}
+		keys = newKeyDeriver(*network, descriptor)
+	} else if *keyFormat != keyFormatHex {
+		fatalf("--key-format requires --include-keys")
+	}
+	// Keystore files are written once the run starts
+	var password string
+	if *keystoreDir != "" {
+		if *network != "ethereum" {
+			fatalf("--keystore-dir requires --network ethereum")
+		}
+		// A replacement would write the hung worker's keystore file again
+		if *restartHungWorkers {
+			fatalf("--keystore-dir cannot be combined with --restart-hung-workers")
+		}
+		if err := checkPrivateKeyOutput(); err != nil {
+			fatalf("--keystore-dir writes private keys: %v", err)
+		}
+		if password, err = readKeystorePassword(*keystorePassword); err != nil {
+			fatalf("Failed to read keystore password: %v", err)
+		}
+		if password == "" {
+			fatalf("--keystore-dir requires a --keystore-password")
+		}
+		registerSecret(password)
+	} else if *keystorePassword != "" || *keystoreLightKDF {
+		fatalf("--keystore-password and --keystore-light-kdf require --keystore-dir")
+	}
+
+	// Plugins only derive addresses, from outside the FIPS module. A
+	// descriptor defines the prefixes of a single chain.
+	if plugin != "" {
+		switch {
+		case *includeKeys:
+			fatalf("--include-keys is not supported for plugin networks")
+		case *chain != addrmint.ChainMainnet:
+			fatalf("--chain is not supported for plugin networks")
+		case *fipsMode:
+			fatalf("--fips cannot be combined with plugin networks, which run outside the FIPS module")
+		}
+	}
+	if descriptor != nil {
+		switch {
+		case *chain != addrmint.ChainMainnet:
+			fatalf("--chain is not supported for descriptor networks; define the other chain in its own descriptor")
+		case *fipsMode:
+			fatalf("--fips is not supported for descriptor networks")
+		}
+	}
+	if err := addrmint.CheckChain(*network, *chain); err != nil {
+		fatalf("Invalid --chain: %v", err)
 	}
 
-	if *network != "ethereum" && *network != "bitcoin" && *network != "solana" && *network != "ton" {
-		log.Fatal("Network must be ethereum, bitcoin, solana, or ton")
+	var btcTypes *addrmint.BitcoinTypeMix
+	if *btcTypeMix != "" {
+		if *network != "bitcoin" {
+			fatalf("--btc-type-mix requires --network bitcoin")
+		}
+		if btcTypes, err = addrmint.ParseBitcoinTypeMix(*btcTypeMix); err != nil {
+			fatalf("Invalid --btc-type-mix: %v", err)
+		}
+	}
+	if *btcAddressType != "" {
+		if *network != "bitcoin" {
+			fatalf("--btc-address-type requires --network bitcoin")
+		}
+		if *btcTypeMix != "" {
+			fatalf("--btc-address-type cannot be combined with --btc-type-mix")
+		}
+		if btcTypes, err = addrmint.SingleBitcoinType(*btcAddressType); err != nil {
+			fatalf("Invalid --btc-address-type: %v", err)
+		}
+	}
+
+	// A range replaces the count and starts at its first index
+	if *indexRange != "" {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "count" || f.Name == "indices-file" {
+				fatalf("--range cannot be combined with --count or --indices-file")
+			}
+		})
+		start, end, err := parseIndexRange(*indexRange)
+		if err != nil {
+			fatalf("Invalid --range: %v", err)
+		}
+		*shardOffset = start
+		*count = end - start
+	}
+
+	// Generate only the listed indices when patching holes in a corpus
+	var indices []int
+	if *indicesFile != "" {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "count" {
+				fatalf("--indices-file cannot be combined with --count")
+			}
+		})
+		if *processes > 1 || *hashMapFile != "" {
+			fatalf("--indices-file cannot be combined with --processes or --hash-map")
+		}
+		var err error
+		if indices, err = loadIndices(*indicesFile); err != nil {
+			fatalf("Failed to read indices file: %v", err)
+		}
+		if len(indices) == 0 {
+			fatalf("Indices file %s lists no indices", *indicesFile)
+		}
+		*count = len(indices)
+		fmt.Fprintf(os.Stderr, "Generating %s indices listed in %s\n", formatCount(len(indices)), *indicesFile)
+	}
+
+	// HD paths derive each key from a master seed like a wallet does, in
+	// place of --derivation-scheme
+	var hdPath *addrmint.HDPath
+	if *derivationPath != "" {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "derivation-scheme" {
+				fatalf("--derivation-path cannot be combined with --derivation-scheme")
+			}
+		})
+		if hdPath, err = addrmint.ParseHDPath(*derivationPath); err != nil {
+			fatalf("Invalid --derivation-path: %v", err)
+		}
+		last := *shardOffset + *count - 1
+		if len(indices) > 0 {
+			last = slices.Max(indices)
+		}
+		if last > addrmint.MaxHDIndex {
+			fatalf("--derivation-path indices end at %d", addrmint.MaxHDIndex)
+		}
+		// The path's purpose picks the Bitcoin address type unless one is given
+		if t := hdPath.BitcoinType(); *network == "bitcoin" && btcTypes == nil && t != "" && t != addrmint.BitcoinLegacy {
+			btcTypes, _ = addrmint.SingleBitcoinType(t)
+		}
+	}
+
+	// Contract addresses follow from the deployer and the index, which is
+	// the nonce or the salt, so no seed is involved
+	var contract *addrmint.ContractGenerator
+	if *contractOpcode != "" {
+		if *network != "ethereum" {
+			fatalf("--contract requires --network ethereum")
+		}
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "seed", "seed-shares", "mnemonic", "mnemonic-passphrase", "generate-mnemonic", "derivation-scheme", "derivation-path", "crypto-backend", "hash-backend", "include-keys", "keystore-dir":
+				fatalf("--contract needs no keys and cannot be combined with --%s", f.Name)
+			}
+		})
+		if contract, err = addrmint.NewContractGenerator(*contractOpcode, *deployer, *initCodeHash); err != nil {
+			fatalf("Invalid --contract: %v", err)
+		}
+	} else if *deployer != "" || *initCodeHash != "" {
+		fatalf("--deployer and --init-code-hash require --contract")
+	}
+
+	// Resolve the key for keyed hashing
+	var hashKey []byte
+	var hashing *HashingManifest
+	if *hashOnly {
+		if *generateHash {
+			fatalf("--hash-only cannot be combined with --generate-hash")
+		}
+		hashing = &HashingManifest{Algorithm: "HMAC-SHA256", Iterations: *hashIterations}
+		switch {
+		case *shardIndex >= 0:
+			// Shards hash with the parent's key
+			key, err := hex.DecodeString(os.Getenv(shardHashKeyEnv))
+			if err != nil || len(key) == 0 {
+				fatalf("%s must hold the parent's hex hash key", shardHashKeyEnv)
+			}
+			os.Unsetenv(shardHashKeyEnv)
+			hashKey = key
+			hashing.KeySource = keySourceFlag
+		case *hashKeyHex != "" && *saltFile != "":
+			fatalf("--hash-key cannot be combined with --salt-file")
+		case *hashKeyHex != "":
+			key, err := hex.DecodeString(*hashKeyHex)
+			if err != nil || len(key) == 0 {
+				fatalf("--hash-key must be a non-empty hex string")
+			}
+			hashKey = key
+			hashing.KeySource = keySourceFlag
+		case *saltFile != "" && *dryRun && !fileExists(*saltFile):
+			// A dry run leaves a missing salt file uncreated
+			if err := checkWritable(*saltFile); err != nil {
+				fatalf("Cannot write %s: %v", *saltFile, err)
+			}
+			hashKey = make([]byte, hashKeySize)
+			hashing.KeySource = keySourceSaltFile
+		case *saltFile != "":
+			key, created, err := loadOrCreateSaltFile(*saltFile)
+			if err != nil {
+				fatalf("Failed to load salt file: %v", err)
+			}
+			hashKey = key
+			hashing.KeySource = keySourceSaltFile
+			hashing.SaltFile = *saltFile
+			if created {
+				if *auditLog != "" {
+					destination, err := fileDestination(*saltFile)
+					if err != nil {
+						fatalf("Failed to read salt file: %v", err)
+					}
+					destination.Fingerprint = keyFingerprint(key)
+					recordAudit(*auditLog, newAuditEntry("generate", "hash key", destination))
+				}
+				fmt.Fprintf(os.Stderr, "Saved new random hash key to %s\n", *saltFile)
+			} else {
+				fmt.Fprintf(os.Stderr, "Using hash key from %s\n", *saltFile)
+			}
+		default:
+			hashKey = make([]byte, hashKeySize)
+			if _, err := rand.Read(hashKey); err != nil {
+				fatalf("Failed to generate hash key: %v", err)
+			}
+			hashing.KeySource = keySourceGenerated
+			if *auditLog != "" {
+				destination := AuditDestination{Path: "stderr", Fingerprint: keyFingerprint(hashKey)}
+				recordAudit(*auditLog, newAuditEntry("generate", "hash key", destination))
+			}
+			fmt.Fprintf(os.Stderr, "Generated random hash key %s (needed to hash addresses for lookups)\n", hex.EncodeToString(hashKey))
+		}
+		registerSecret(hex.EncodeToString(hashKey))
+		hashing.KeyFingerprint = keyFingerprint(hashKey)
+		fmt.Fprintf(os.Stderr, "Hash key fingerprint: %s\n", hashing.KeyFingerprint)
+		if *hashIterations < 1 {
+			fatalf("Hash iterations must be at least 1")
+		}
+	} else if *hashKeyHex != "" || *hashIterations != 1 || *saltFile != "" {
+		fatalf("--hash-key, --hash-iterations and --salt-file require --hash-only")
+	}
+
+	// The manifest is written once the output is complete
+	manifest := &Manifest{
+		Version:       version,
+		CreatedAt:     time.Now().UTC(),
+		Network:       *network,
+		Count:         *count,
+		Offset:        *shardOffset,
+		CryptoBackend: *cryptoBackend,
+		Derivation:    *derivationScheme,
+		HashBackend:   *hashBackend,
+		Output:        *outputFile,
+		Hashing:       hashing,
+	}
+	if *chain != addrmint.ChainMainnet {
+		manifest.Chain = *chain
+	}
+	if *format != outputFormatPlain {
+		manifest.Format = *format
+	}
+	if *includeKeys {
+		manifest.Keys = *keyFormat
+	}
+	manifest.Keystore = *keystoreDir
+	if plugin != "" {
+		manifest.Plugin = filepath.Base(plugin)
+	}
+	if descriptor != nil {
+		manifest.Descriptor = descriptorManifest(descriptor)
+	}
+	// Ed25519 networks derive along SLIP-10 paths
+	hdScheme := addrmint.HDScheme(*network)
+	if descriptor != nil && descriptor.Curve == addrmint.CurveEd25519 {
+		hdScheme = addrmint.DerivationSLIP10
+	}
+	if hdPath != nil {
+		manifest.Derivation = hdScheme
+		manifest.DerivationPath = hdPath.String()
+	}
+	if contract != nil {
+		manifest.Derivation = addrmint.DerivationIndex
+		manifest.Contract = &ContractManifest{Opcode: contract.Opcode, Deployer: contract.Deployer.Hex()}
+		if contract.Opcode == addrmint.ContractCreate2 {
+			manifest.Contract.InitCodeHash = contract.InitCodeHash.Hex()
+		}
+	}
+
+	source, err := seedSource(*seedInt, *seedShares, *mnemonicFlag, *generateMnemonic, *mnemonicPassphrase)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	// FIPS mode needs Go's FIPS 140-3 module and keeps to its algorithms
+	if *fipsMode {
+		if !fips140.Enabled() {
+			fatalf("--fips requires the Go FIPS 140-3 module: run with GODEBUG=fips140=on or build with make build-fips")
+		}
+		if *cryptoBackend == backendNative {
+			fatalf("--fips cannot be combined with --crypto-backend native, which bypasses the FIPS module")
+		}
+		if *hashOnly && len(hashKey) < minFIPSHMACKeyBytes {
+			fatalf("--fips requires a --hash-key of at least %d bytes", minFIPSHMACKeyBytes)
+		}
+		algorithms := runAlgorithms(*network, manifest.Derivation, source, btcTypes, *generateHash, *hashOnly, *encryptTemp)
+		manifest.Compliance = fipsCompliance(algorithms)
+		if !manifest.Compliance.Compliant {
+			printWarning("%s addresses need algorithms FIPS 140-3 does not approve: %s", *network, unapprovedAlgorithms(algorithms))
+		}
+	}
+	saveManifest := func() {
+		if *manifestFile == "" {
+			return
+		}
+		if err := writeManifest(*manifestFile, manifest); err != nil {
+			fatalf("Failed to write manifest: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote manifest to %s\n", *manifestFile)
+	}
+
+	// Validate crypto backend
+	if *cryptoBackend != backendSDK && *cryptoBackend != backendNative {
+		fatalf("Crypto backend must be sdk or native")
+	}
+
+	// Validate hash backend
+	if *hashBackend != hashBackendGeth && *hashBackend != hashBackendReused {
+		fatalf("Hash backend must be geth or reused")
 	}
 
 	// Prepare the initial seed
 	var baseSeed string
-	if *seedInt == 0 {
+	if seed := os.Getenv(shardSeedEnv); *shardIndex >= 0 && seed != "" {
+		// Child processes reuse the parent's base seed, which plugins they
+		// start must not inherit
+		baseSeed = seed
+		os.Unsetenv(shardSeedEnv)
+	} else if contract != nil {
+		// Contract addresses need no seed
+	} else if *seedShares != "" {
+		// Split knowledge: every operator's share is needed to rebuild the seed
+		shares, err := loadSeedShares(strings.Split(*seedShares, ","))
+		if err != nil {
+			fatalf("Failed to load seed shares: %v", err)
+		}
+		for _, share := range shares {
+			registerSecret(hex.EncodeToString(share))
+			manifest.SeedShares = append(manifest.SeedShares, keyFingerprint(share))
+		}
+		baseSeed = combineSeedShares(shares)
+		fmt.Fprintf(os.Stderr, "Combined %d seed shares (fingerprints %s)\n", len(shares), strings.Join(manifest.SeedShares, ", "))
+	} else if *mnemonicFlag != "" || *generateMnemonic != 0 {
+		// A BIP-39 phrase the operator can write down and restore from
+		mnemonic, err := readMnemonic(*mnemonicFlag)
+		if err != nil {
+			fatalf("Failed to read mnemonic: %v", err)
+		}
+		if *generateMnemonic != 0 {
+			if *generateMnemonic != 12 && *generateMnemonic != 24 {
+				fatalf("--generate-mnemonic must be 12 or 24")
+			}
+			if err := checkPrivateKeyOutput(); err != nil {
+				fatalf("Cannot print a mnemonic: %v", err)
+			}
+			mnemonic, err = addrmint.NewMnemonic(*generateMnemonic)
+			if err != nil {
+				fatalf("Failed to generate mnemonic: %v", err)
+			}
+		}
+		registerSecret(strings.Join(strings.Fields(mnemonic), " "))
+		if *mnemonicPassphrase != "" {
+			registerSecret(*mnemonicPassphrase)
+		}
+		baseSeed, err = mnemonicBaseSeed(mnemonic, *mnemonicPassphrase)
+		if err != nil {
+			fatalf("Invalid mnemonic: %v", err)
+		}
+		manifest.Mnemonic = keyFingerprint([]byte(baseSeed))
+		if *generateMnemonic != 0 {
+			if *auditLog != "" {
+				destination := AuditDestination{Path: "stderr", Fingerprint: manifest.Mnemonic}
+				recordAudit(*auditLog, newAuditEntry("generate", "mnemonic", destination))
+			}
+			fmt.Fprintf(os.Stderr, "Generated mnemonic (write it down to restore these addresses):\n%s\n", mnemonic)
+		}
+		fmt.Fprintf(os.Stderr, "Using BIP-39 mnemonic (fingerprint %s)\n", manifest.Mnemonic)
+	} else if *seedInt == 0 {
 		// Generate random seed if not provided
 		randBytes := make([]byte, 32)
 		_, err := rand.Read(randBytes)
 		if err != nil {
-			log.Fatal("Failed to generate random seed:", err)
+			fatalf("Failed to generate random seed: %v", err)
 		}
 		baseSeed = hex.EncodeToString(randBytes)
 		fmt.Fprintf(os.Stderr, "Generated random seed\n")
+		if *journalFile != "" {
+			fatalf("--journal needs a seed that can be given again on resume: --seed, --seed-shares or --mnemonic")
+		}
 	} else {
 		// Use the provided integer seed
 		baseSeed = strconv.FormatInt(*seedInt, 16)
-		fmt.Fprintf(os.Stderr, "Using seed value: %d\n", *seedInt)
+		registerSecret(strconv.FormatInt(*seedInt, 10))
+		fmt.Fprintf(os.Stderr, "Using the seed given with --seed\n")
+		if *seedInt > -1<<32 && *seedInt < 1<<32 {
+			printWarning("The seed is small and easy to guess; anyone who guesses it can reproduce these addresses")
+		}
+	}
+	registerSecret(baseSeed)
+
+	// HD paths start from the binary seed, such as the 64 bytes of a mnemonic
+	newSeedDeriver := func() *addrmint.SeedDeriver {
+		d, err := addrmint.NewSeedDeriver(*derivationScheme, baseSeed)
+		if err != nil {
+			fatalf("Invalid --derivation-scheme: %v", err)
+		}
+		return d
+	}
+	if hdPath != nil {
+		masterSeed, err := hex.DecodeString(baseSeed)
+		if err != nil || len(masterSeed) < 16 {
+			fatalf("--derivation-path needs a --mnemonic, --seed-shares or random seed; --seed is too short for an HD master seed")
+		}
+		hd, err := addrmint.NewHDDeriver(hdScheme, hdPath, masterSeed)
+		if err != nil {
+			fatalf("Invalid --derivation-path: %v", err)
+		}
+		newSeedDeriver = func() *addrmint.SeedDeriver {
+			return addrmint.NewHDSeedDeriver(hd)
+		}
+		fmt.Fprintf(os.Stderr, "Deriving keys along %s (%s)\n", hdPath, manifest.Derivation)
+	}
+	if contract != nil {
+		newSeedDeriver = addrmint.NewIndexSeedDeriver
+		fmt.Fprintf(os.Stderr, "Predicting %s addresses of contracts deployed by %s\n", contract.Opcode, contract.Deployer.Hex())
+	}
+
+	// Every job names the same network and backends
+	template := Job{network: *network, backend: *cryptoBackend, hashBackend: *hashBackend, btcTypes: btcTypes, chain: *chain, contract: contract, plugin: plugin, descriptor: descriptor}
+
+	// Connect to Redis and NATS before the output is created, so a bad URL
+	// or a missing stream fails the run before anything is truncated
+	var redis *RedisWriter
+	if *redisURL != "" {
+		redis, err = DialRedis(*redisURL, *redisKey, *redisType)
+		if err != nil {
+			fatalf("Failed to connect to Redis: %v", err)
+		}
+		defer redis.Close()
+		if err := redis.Check(); err != nil {
+			fatalf("Redis preflight failed: %v", err)
+		}
+	}
+	var nats *NATSWriter
+	var natsMsgID string
+	if *natsURL != "" {
+		// Message IDs are unique to the run, so only replays are dropped
+		var id [8]byte
+		rand.Read(id[:])
+		natsMsgID = hex.EncodeToString(id[:])
+		nats, err = DialNATS(*natsURL, *natsSubject, *natsBatch, natsMsgID)
+		if err != nil {
+			fatalf("Failed to connect to NATS: %v", err)
+		}
+		defer nats.Close()
+		if err := nats.Check(); err != nil {
+			fatalf("NATS preflight failed: %v", err)
+		}
+	}
+
+	// The layout decides the columns of the rows in every output format.
+	// Formats that name their fields also give each row its index and
+	// network, and its path in HD runs.
+	layout := &rowLayout{
+		linked:       linkedNames,
+		keys:         keys != nil,
+		keyFormat:    *keyFormat,
+		chain:        *chain,
+		path:         hdPath != nil && structuredFormat(*format),
+		hashOnly:     *hashOnly,
+		generateHash: *generateHash,
+		indices:      indices,
+		offset:       *shardOffset,
+	}
+	columns := layout.outputColumns(*hashMapFile != "")
+	if structuredFormat(*format) {
+		columns = layout.structuredColumns()
+	}
+	var recordPath *addrmint.HDPath
+	if layout.path {
+		recordPath = hdPath
+	}
+
+	// Estimate the output from a sample of rows and check that it fits. A
+	// pausing run starts anyway, expecting space to be freed as it goes.
+	reserve := int64(*minFreeMB) << 20
+	if *outputFile != "" && *shardIndex < 0 {
+		// Rows are sampled whole; --hash-map cuts them after the hash
+		sampled := layout.outputColumns(false)
+		if structuredFormat(*format) {
+			sampled = columns
+		}
+		process := newRecordProcessor(*network, nil, link, keys, recordPath)
+		outputBytes, mappingBytes := estimateOutputBytes(template, newSeedDeriver(), *count, process, layout, sampled, *hashMapFile != "")
+		if structuredFormat(*format) {
+			// JSON adds field names, and the sampled indices are narrower
+			// than the last ones
+			row := len(strconv.Itoa(*shardOffset+*count)) + structuredRowOverhead(*format, columns)
+			outputBytes += int64(*count) * int64(row)
+		}
+		if *processes > 1 {
+			// Shard files sit next to the output until they are merged into it
+			outputBytes *= 2
+		}
+		files := []struct {
+			path string
+			size int64
+		}{{*outputFile, outputBytes}, {*hashMapFile, mappingBytes}}
+		for _, file := range files {
+			if file.path == "" {
+				continue
+			}
+			if err := checkFreeSpace(file.path, file.size, reserve); err != nil {
+				if *lowSpace != lowSpacePause {
+					fatalf("Not enough disk space: %v", err)
+				}
+				printWarning("Not enough disk space: %v", err)
+			}
+		}
+		if *dryRun {
+			fmt.Fprintf(os.Stderr, "Estimated output size: %s MiB\n", formatCount(int(outputBytes>>20)))
+		}
+	}
+
+	if *dryRun {
+		for _, path := range []string{*outputFile, *hashMapFile, *errorFile, *manifestFile, *redisDeadLetter, *natsDeadLetter, *auditLog, *offsetIndexFile} {
+			if path == "" {
+				continue
+			}
+			if err := checkWritable(path); err != nil {
+				fatalf("Cannot write %s: %v", path, err)
+			}
+		}
+		if *keystoreDir != "" {
+			// The directory is created by the run if it does not exist
+			path := *keystoreDir
+			if fileExists(path) {
+				path = filepath.Join(path, "UTC--preflight")
+			}
+			if err := checkWritable(path); err != nil {
+				fatalf("Cannot write keystores to %s: %v", *keystoreDir, err)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Preflight passed; nothing was generated\n")
+		return
 	}
 
-	// Setup output file if specified
+	// Setup output file if specified. Outputs holding private keys are only
+	// readable by their owner, including files that already existed.
+	if *includeKeys && *shardIndex < 0 {
+		printKeyWarning(*outputFile)
+	}
+	perm := os.FileMode(0644)
+	if *includeKeys {
+		perm = keyFileMode
+	}
 	var output *os.File
-	var err error
 	if *outputFile != "" {
-		output, err = os.Create(*outputFile)
+		// The parent of sharded runs only merges the shard files, so only the
+		// processes that generate addresses open their output for direct I/O
+		if *directIO && *processes <= 1 {
+			output, err = openDirect(*outputFile, perm)
+		} else if *resume {
+			// A resumed run keeps the rows its journal vouches for
+			output, err = os.OpenFile(*outputFile, os.O_RDWR|os.O_CREATE, perm)
+		} else {
+			output, err = os.OpenFile(*outputFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+		}
+		if err == nil && *includeKeys {
+			err = output.Chmod(keyFileMode)
+		}
 		if err != nil {
-			log.Fatalf("Failed to create output file: %v", err)
+			fatalf("Failed to create output file: %v", err)
 		}
 		defer output.Close()
 		fmt.Fprintf(os.Stderr, "Writing results to %s\n", *outputFile)
@@ -150,7 +1193,97 @@ func main() {
 		output = os.Stdout
 	}
 
-	fmt.Fprintf(os.Stderr, "Generating %d %s addresses using %d workers\n", *count, *network, *workers)
+	// Record where the keys went once the output is complete
+	auditKeys := func(written int64) {
+		if *auditLog == "" || *shardIndex >= 0 {
+			return
+		}
+		if *includeKeys {
+			destination := AuditDestination{Path: "stdout", Bytes: written}
+			if *outputFile != "" {
+				var err error
+				if destination, err = fileDestination(*outputFile); err != nil {
+					fatalf("Failed to read output file: %v", err)
+				}
+			}
+			recordAudit(*auditLog, newAuditEntry("generate", "private keys", destination))
+		}
+		if *keystoreDir != "" {
+			recordAudit(*auditLog, newAuditEntry("generate", "keystore files", AuditDestination{Path: *keystoreDir}))
+		}
+	}
+
+	// Journal the output so a crashed run can resume from its last checkpoint
+	var journal *Journal
+	if *journalFile != "" {
+		header := JournalHeader{Output: *outputFile, Network: *network, Offset: *shardOffset, Count: *count, Run: journalRun(flag.CommandLine, baseSeed, hashKey)}
+		if *resume {
+			journal, err = resumeJournal(*journalFile, header, output)
+		} else {
+			journal, err = createJournal(*journalFile, header, output)
+		}
+		if err != nil {
+			fatalf("Failed to open journal: %v", err)
+		}
+		defer journal.Close()
+		if err := journal.Dropped(); err != nil {
+			printWarning("Dropped the checkpoints the output does not match: %v", err)
+		}
+		if done := journal.Indices(); *resume {
+			if done == *count {
+				fmt.Fprintf(os.Stderr, "All %s indices are already in %s\n", formatCount(done), *outputFile)
+				return
+			}
+			*shardOffset += done
+			*count -= done
+			fmt.Fprintf(os.Stderr, "Resuming at index %s with %s indices already on disk\n", formatCount(*shardOffset), formatCount(done))
+		}
+	}
+
+	// Fan the work out to child processes if requested
+	if *processes > 1 {
+		if *count < *processes {
+			*processes = *count
+		}
+		fmt.Fprintf(os.Stderr, "Generating %s %s addresses using %d processes\n", formatCount(*count), *network, *processes)
+		progressBar := NewProgressBar(*count, 50)
+		progressBar.SetStyle(*progressStyle)
+		var cipher *TempCipher
+		if *encryptTemp {
+			if cipher, err = NewTempCipher(); err != nil {
+				fatalf("Failed to create the temp file key: %v", err)
+			}
+		}
+		// The merged output is indexed as the shards are concatenated
+		var merged io.Writer = output
+		var offsetIndex *OffsetIndexWriter
+		if *offsetIndexFile != "" {
+			if offsetIndex, err = NewOffsetIndexWriter(*offsetIndexFile, output, *offsetIndexInterval); err != nil {
+				fatalf("Failed to create offset index: %v", err)
+			}
+			merged = offsetIndex
+		}
+		written, err := runShardedProcesses(*processes, *count, *shardOffset, *workers, baseSeed, hashKey, output, merged, cipher, progressBar)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if offsetIndex != nil {
+			if err := offsetIndex.Close(); err != nil {
+				fatalf("Failed to write offset index: %v", err)
+			}
+		}
+		elapsedTime := time.Since(startTime)
+		fmt.Fprintf(os.Stderr, "Generated %s addresses in %s (%s addresses/sec)\n",
+			formatCount(*count), elapsedTime, formatRate(float64(*count)/elapsedTime.Seconds()))
+		manifest.Resources = measureResources(startTime, written)
+		auditKeys(written)
+		saveManifest()
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Generating %s %s addresses using %d workers\n", formatCount(*count), *network, *workers)
+	fmt.Fprintf(os.Stderr, "Using %s crypto backend\n", *cryptoBackend)
+	fmt.Fprintf(os.Stderr, "Hash backend: %s\n", hashBackendInfo(*hashBackend))
 
 	// Optimize number of workers based on count
 	if *count < *workers {
@@ -158,120 +1291,472 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Adjusted number of workers to %d based on address count\n", *workers)
 	}
 
-	// Create a worker pool with optimized channel sizes for better throughput
-	jobs := make(chan Job, *workers*2)
-	results := make(chan Result, *outputBufferSize)
+	// Resolve the CPUs workers are pinned to, round-robin over the allowed set.
+	// Shards start further along the set so sibling processes don't share CPUs.
+	var pinCPUs []int
+	if *pinWorkers {
+		pinCPUs, err = availableCPUs()
+		if err != nil {
+			fatalf("Failed to pin workers: %v", err)
+		}
+		if *shardIndex > 0 {
+			shift := (*shardIndex * *workers) % len(pinCPUs)
+			pinCPUs = append(pinCPUs[shift:], pinCPUs[:shift]...)
+		}
+		fmt.Fprintf(os.Stderr, "Pinning workers across %d CPUs\n", len(pinCPUs))
+		if *workers > len(pinCPUs) {
+			printWarning("%d workers share %d CPUs; pinned workers will compete for the same cores", *workers, len(pinCPUs))
+		}
+	}
 
-	// Start workers
-	var wg sync.WaitGroup
-	for w := 1; w <= *workers; w++ {
-		wg.Add(1)
-		go worker(w, jobs, results, &wg)
+	// Each worker hashes with its own HMAC state
+	var newHasher func() *KeyedHasher
+	if hashKey != nil {
+		newHasher = func() *KeyedHasher {
+			hasher := NewKeyedHasher(hashKey)
+			hasher.SetIterations(*hashIterations)
+			return hasher
+		}
 	}
 
-	// Start a goroutine to close the results channel when all jobs are done
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	// Workers write the keystore files, which take far longer than the
+	// addresses. The linker is added only now so that the size estimate
+	// wrote none.
+	if *keystoreDir != "" {
+		keystore, err := NewKeystoreWriter(*keystoreDir, password, *keystoreLightKDF)
+		if err != nil {
+			fatalf("Failed to create keystore directory: %v", err)
+		}
+		link = chainLinkers(link, keystore.keystoreLinker)
+		fmt.Fprintf(os.Stderr, "Writing keystore files to %s, encrypting at most %d at a time\n", *keystoreDir, cap(keystore.scrypt))
+	}
 
-	// Create a job submission pool for better memory efficiency
-	jobPool := &sync.Pool{
-		New: func() interface{} {
-			return &Job{}
+	// Workers take --result-batch indices at a time, and --output-buffer
+	// bounds the results waiting for the collector. A replacement for a
+	// hung worker starts the same way, on the same CPU.
+	restarts := make([]int, *workers+1)
+	pipeline := &addrmint.Pipeline{
+		Workers:   *workers,
+		BatchSize: *resultBatchSize,
+		Backlog:   (*outputBufferSize + *resultBatchSize - 1) / *resultBatchSize,
+		Indices:   indices,
+		NewGenerator: func() (addrmint.Generator, error) {
+			return newGenerator(&template)
+		},
+		NewSeeds: newSeedDeriver,
+		RunWorker: func(w int, work func()) {
+			defer redactPanic()
+			if pinCPUs != nil {
+				cpu := pinCPUs[(w-1)%len(pinCPUs)]
+				if err := pinToCPU(cpu); err != nil {
+					fatalf("Failed to pin worker %d to CPU %d: %v", w, cpu, err)
+				}
+			}
+			work()
 		},
 	}
+	if newHasher != nil || link != nil || keys != nil || recordPath != nil {
+		pipeline.NewProcessor = func(int) func(*addrmint.Result) {
+			var keyed *KeyedHasher
+			if newHasher != nil {
+				keyed = newHasher()
+			}
+			return newRecordProcessor(*network, keyed, link, keys, recordPath)
+		}
+	}
 
-	// Submit jobs in batches for better memory efficiency
-	go func() {
-		batchSubmitJobs(jobs, *count, baseSeed, *network, *batchSize, jobPool)
-		close(jobs)
-	}()
+	// Watch for workers stuck on one address, such as in a cgo call
+	if *hungWorkerTimeout > 0 {
+		pipeline.HungTimeout = *hungWorkerTimeout
+		pipeline.OnHung = func(w, index int, busy time.Duration) bool {
+			if !*restartHungWorkers {
+				printWarning("Worker %d has spent %s on index %d", w, busy.Round(time.Millisecond), index)
+				return false
+			}
+			printWarning("Worker %d spent %s on index %d; starting a replacement", w, busy.Round(time.Millisecond), index)
+			restarts[w]++
+			return true
+		}
+	}
 
 	// Create an efficient result collector with progress bar
 	resultCollector := NewResultCollector(*count, *batchSize, output, *generateHash)
+	resultCollector.SetLayout(layout)
+	var invariants *InvariantChecker
+	if *checkInvariants {
+		invariants = NewInvariantChecker(*count)
+		resultCollector.SetInvariants(invariants)
+	}
+	// Rows fan out to the output, unless Redis or NATS replace stdout, and
+	// to Redis and NATS. Formats other than plain rows encode the records
+	// straight to the output instead.
+	fanout := &FanoutWriter{}
+	var sealedShard io.WriteCloser
+	var spaceGuard *SpaceGuard
+	var offsetIndex *OffsetIndexWriter
+	var encoder RecordEncoder
+	if *outputFile != "" || (*redisURL == "" && *natsURL == "") {
+		var primary io.Writer = output
+		if journal != nil {
+			primary = journal
+		}
+		if *outputFile != "" {
+			spaceGuard = NewSpaceGuard(primary, *outputFile, reserve, *lowSpace == lowSpacePause)
+			primary = spaceGuard
+		}
+		if *offsetIndexFile != "" {
+			offsetIndex, err = NewOffsetIndexWriter(*offsetIndexFile, primary, *offsetIndexInterval)
+			if err != nil {
+				fatalf("Failed to create offset index: %v", err)
+			}
+			primary = offsetIndex
+			fmt.Fprintf(os.Stderr, "Indexing every %s rows in %s\n", formatCount(*offsetIndexInterval), *offsetIndexFile)
+		}
+		if *encryptTemp && *shardIndex >= 0 {
+			// The shard file is encrypted for the parent to merge
+			cipher, err := tempCipherFromEnv()
+			if err != nil {
+				fatalf("Failed to read the temp file key: %v", err)
+			}
+			sealedShard = cipher.Writer(primary)
+			primary = sealedShard
+		}
+		switch {
+		case *format == outputFormatAvro:
+			encoder, err = NewAvroWriter(primary, layout, columns, *avroCodec)
+		case *format == outputFormatParquet:
+			encoder, err = NewParquetWriter(primary, layout, columns, *parquetCodec, *parquetRowGroup)
+		case structuredFormat(*format):
+			encoder, err = NewStructuredWriter(primary, *format, layout, columns)
+		default:
+			fanout.Add(fanoutOutput, primary, onErrorAbort)
+		}
+		if err != nil {
+			fatalf("Failed to write output: %v", err)
+		}
+		if encoder != nil {
+			resultCollector.SetEncoder(encoder)
+		}
+	}
+	// Network sinks reconnect and replay their rows after failures
+	var retried []*RetryWriter
+	addNetworkSink := func(name string, sink networkSink, dial func() (networkSink, error), deadLetter, policy string) {
+		if *sinkRetries > 0 || deadLetter != "" {
+			rw := NewRetryWriter(name, sink, dial, *sinkRetries, *sinkRetryBackoff, *sinkRetryJitter)
+			if deadLetter != "" {
+				if err := rw.SetDeadLetter(deadLetter); err != nil {
+					fatalf("Failed to create dead-letter file: %v", err)
+				}
+			}
+			retried = append(retried, rw)
+			sink = rw
+		}
+		fanout.Add(name, sink, policy)
+	}
+	if redis != nil {
+		addNetworkSink("redis", redis, func() (networkSink, error) {
+			return DialRedis(*redisURL, *redisKey, *redisType)
+		}, *redisDeadLetter, *redisOnError)
+		fmt.Fprintf(os.Stderr, "Loading results into Redis %s %s\n", *redisType, *redisKey)
+	}
+	if nats != nil {
+		addNetworkSink("nats", nats, func() (networkSink, error) {
+			return DialNATS(*natsURL, *natsSubject, *natsBatch, natsMsgID)
+		}, *natsDeadLetter, *natsOnError)
+		fmt.Fprintf(os.Stderr, "Publishing results to NATS subject %s\n", *natsSubject)
+	}
+	sink := fanout.Writer()
+
+	// Rows are counted as they reach the output file
+	writtenRows := NewCountingWriter(sink)
+	var destination FlushWriter
+	if *directIO {
+		alignedWriter := NewAlignedWriter(output, *writeBuffer, true)
+		alignedWriter.out = writtenRows
+		destination = alignedWriter
+		fmt.Fprintf(os.Stderr, "Using direct I/O with %d byte aligned writes\n", len(alignedWriter.buf))
+	} else {
+		destination = bufio.NewWriterSize(writtenRows, *writeBuffer)
+	}
+
+	// Keep the hash to address mapping out of the main output
+	if *hashMapFile != "" {
+		hashMap, err := os.Create(*hashMapFile)
+		if err != nil {
+			fatalf("Failed to create hash map file: %v", err)
+		}
+		defer hashMap.Close()
+		resultCollector.SetHashMap(bufio.NewWriterSize(hashMap, *writeBuffer))
+		fmt.Fprintf(os.Stderr, "Writing hash to address mapping to %s\n", *hashMapFile)
+	}
+
+	// Failed addresses are skipped instead of aborting the run
+	if *onError == onErrorSkip {
+		var errorWriter FlushWriter
+		if *errorFile != "" {
+			file, err := os.Create(*errorFile)
+			if err != nil {
+				fatalf("Failed to create error file: %v", err)
+			}
+			defer file.Close()
+			errorWriter = bufio.NewWriter(file)
+		}
+		resultCollector.SetSkipErrors(errorWriter)
+	}
+
+	// Hand file writes to a dedicated goroutine so slow disks don't hold the collector's lock
+	var asyncWriter *AsyncWriter
+	if *writeQueue > 0 {
+		asyncWriter = NewAsyncWriter(destination, *writeQueue)
+		resultCollector.SetWriter(asyncWriter)
+	} else {
+		resultCollector.SetWriter(destination)
+	}
 
 	// Create progress bar
 	progressBar := NewProgressBar(*count, 50) // 50 characters wide
+	progressBar.plain = *shardIndex >= 0
+	progressBar.SetStyle(*progressStyle)
+	progressBar.TrackWritten(&writtenRows.rows)
 
-	// Process results
-	for result := range results {
-		resultCollector.AddResult(result, progressBar)
+	// Diagnose or fail a run whose output stops moving instead of hanging
+	var collected atomic.Int64
+	stopStallWatchdog := make(chan struct{})
+	if *stallTimeout > 0 {
+		watchdog := NewStallWatchdog(*stallTimeout, *onStall == onErrorAbort, func() int64 {
+			return collected.Load() + writtenRows.Rows()
+		}, os.Stderr, func(elapsed time.Duration) {
+			fatalf("No rows collected or written for %s; stopping the stalled run", elapsed.Round(time.Second))
+		})
+		if spaceGuard != nil {
+			watchdog.idle = spaceGuard.Paused
+		}
+		go watchdog.Run(stopStallWatchdog)
 	}
 
-	elapsedTime := time.Since(startTime)
-	fmt.Fprintf(os.Stderr, "Generated %d addresses in %s (%.2f addresses/sec)\n",
-		*count, elapsedTime, float64(*count)/elapsedTime.Seconds())
-}
+	// Process results, counting how many each worker produced. Records
+	// reach the collector in batches to take its lock less often.
+	workerCounts := make([]int, *workers+1)
+	checkpointed := 0
+	records := make([]Record, 0, *resultBatchSize)
+	collect := func() {
+		collected.Add(int64(len(records)))
+		if err := resultCollector.AddResults(records, progressBar); err != nil {
+			fatalf("Failed to write output: %v", err)
+		}
+		records = records[:0]
 
-// batchSubmitJobs submits jobs in batches for better memory efficiency
-func batchSubmitJobs(jobs chan<- Job, count int, baseSeed, network string, batchSize int, pool *sync.Pool) {
-	for i := 0; i < count; i++ {
-		// Modify seed for each iteration to get different addresses
-		h := sha256.New()
-		h.Write([]byte(baseSeed + fmt.Sprintf("%d", i)))
-		seedValue := hex.EncodeToString(h.Sum(nil))
+		// Checkpoints need every row up to them flushed through to the file
+		if journal != nil {
+			if printed := resultCollector.Printed(); printed-checkpointed >= *journalInterval {
+				if err := resultCollector.Flush(); err != nil {
+					fatalf("Failed to write output: %v", err)
+				}
+				if err := journal.Checkpoint(printed); err != nil {
+					fatalf("Failed to write journal: %v", err)
+				}
+				checkpointed = printed
+			}
+		}
+	}
+	// Records are numbered by their position in the run, as the collector
+	// expects; targeted runs select positions of the indices list
+	first := *shardOffset
+	if indices != nil {
+		first = 0
+	}
+	position := 0
+	err = pipeline.Run(context.Background(), first, *count, func(r addrmint.Result) error {
+		record := resultRecord(&r, *network)
+		record.index = position
+		position++
+		workerCounts[r.Worker]++
+		records = append(records, record)
+		if len(records) == cap(records) {
+			collect()
+		}
+		return nil
+	})
+	if err != nil {
+		fatalf("Failed to generate addresses: %v", err)
+	}
+	if len(records) > 0 {
+		collect()
+	}
+	if err := resultCollector.Flush(); err != nil {
+		fatalf("Failed to write output: %v", err)
+	}
+	if asyncWriter != nil {
+		asyncWriter.Close()
+	}
+	// Redis and NATS sinks hold rows back until they are flushed, and the
+	// fan-out flushes each of them
+	if buffered, ok := sink.(FlushWriter); ok {
+		if err := buffered.Flush(); err != nil {
+			fatalf("Failed to write output: %v", err)
+		}
+	}
+	// The encoder ends a JSON array or writes the Parquet footer
+	if encoder != nil {
+		if err := encoder.Close(); err != nil {
+			fatalf("Failed to write output: %v", err)
+		}
+	}
+	if sealedShard != nil {
+		if err := sealedShard.Close(); err != nil {
+			fatalf("Failed to write output: %v", err)
+		}
+	}
+	if offsetIndex != nil {
+		if err := offsetIndex.Close(); err != nil {
+			fatalf("Failed to write offset index: %v", err)
+		}
+	}
+	if invariants != nil {
+		if err := invariants.Finish(writtenRows.Rows(), resultCollector.failed); err != nil {
+			fatalf("Invariant violated: %v", err)
+		}
+	}
+	if journal != nil {
+		if err := journal.Checkpoint(*count); err != nil {
+			fatalf("Failed to write journal: %v", err)
+		}
+	}
+	close(stopStallWatchdog)
+	progressBar.Finish()
 
-		// Get a job from the pool
-		job := pool.Get().(*Job)
-		job.index = i
-		job.seed = seedValue
-		job.network = network
+	elapsedTime := time.Since(startTime)
+	fmt.Fprintf(os.Stderr, "Generated %s addresses (%s rows written) in %s (%s addresses/sec)\n",
+		formatCount(*count), formatCount(int(writtenRows.Rows())), elapsedTime, formatRate(float64(*count)/elapsedTime.Seconds()))
 
-		// Submit the job
-		jobs <- *job
+	if asyncWriter != nil {
+		fmt.Fprintf(os.Stderr, "Writer: %s\n", asyncWriter.Stats())
+	}
+	if resultCollector.failed > 0 {
+		manifest.Errors = resultCollector.failed
+		printWarning("Skipped %s addresses that failed to generate", formatCount(resultCollector.failed))
+		if *errorFile != "" {
+			fmt.Fprintf(os.Stderr, "Wrote failed indices to %s\n", *errorFile)
+		}
+	}
+	if dropped := fanout.Dropped(); dropped != nil {
+		manifest.DroppedSinks = dropped
+		printWarning("Sinks %s did not receive all rows", strings.Join(dropped, ", "))
+	}
+	for _, rw := range retried {
+		if rows := rw.DeadLetterRows(); rows > 0 {
+			if manifest.DeadLetterRows == nil {
+				manifest.DeadLetterRows = map[string]int64{}
+			}
+			manifest.DeadLetterRows[rw.name] = rows
+			printWarning("%s rows meant for the %s sink are in %s", formatCount(int(rows)), rw.name, rw.deadLetterPath)
+		}
+		rw.Close()
+	}
 
-		// Put the job back in the pool
-		pool.Put(job)
+	// Report per-worker throughput when workers are pinned to CPUs or
+	// --worker-stats asks for it
+	if pinCPUs != nil || *workerStats {
+		slow := stragglers(workerCounts)
+		for w := 1; w <= *workers; w++ {
+			var notes []string
+			if pinCPUs != nil {
+				notes = append(notes, fmt.Sprintf("CPU %d", pinCPUs[(w-1)%len(pinCPUs)]))
+			}
+			if restarts[w] > 0 {
+				notes = append(notes, fmt.Sprintf("restarted %d times", restarts[w]))
+			}
+			if slices.Contains(slow, w) {
+				notes = append(notes, "straggler")
+			}
+			label := fmt.Sprintf("Worker %d", w)
+			if len(notes) > 0 {
+				label += " (" + strings.Join(notes, ", ") + ")"
+			}
+			fmt.Fprintf(os.Stderr, "  %s: %s addresses (%s addresses/sec)\n",
+				label, formatCount(workerCounts[w]), formatRate(float64(workerCounts[w])/elapsedTime.Seconds()))
+		}
+		if len(slow) > 0 {
+			printWarning("%d of %d workers generated less than half as many addresses as the median worker", len(slow), *workers)
+		}
 	}
+
+	manifest.Resources = measureResources(startTime, writtenRows.Bytes())
+	auditKeys(writtenRows.Bytes())
+	saveManifest()
 }
 
 // ResultCollector efficiently collects and prints results
 type ResultCollector struct {
-	resultMap    map[int]string
-	resultCount  int
-	nextToPrint  int
-	totalCount   int
-	batchSize    int
-	mu           sync.Mutex
-	outputFile   *os.File
-	generateHash bool
+	resultMap   map[int]Record
+	resultCount int
+	nextToPrint int
+	totalCount  int
+	batchSize   int
+	mu          sync.Mutex
+	outputFile  *os.File
+	writer      FlushWriter
+	line        []byte         // Reused line buffer for formatting output rows
+	layout      *rowLayout     // Formats the columns of each row
+	columns     []outputColumn // Columns of the plain rows, the hash and address with --hash-map
+	encoder     RecordEncoder  // Encodes the output in a format other than plain rows, if set
+	hashMap     FlushWriter    // Receives hash,address rows when the output holds only hashes
+	skipErrors  bool           // Skip failed records instead of aborting
+	errors      FlushWriter    // Receives index,error rows for skipped records, if set
+	failed      int            // Number of skipped records
+	invariants  *InvariantChecker
 }
 
 // NewResultCollector creates a new result collector
 func NewResultCollector(totalCount, batchSize int, outputFile *os.File, generateHash bool) *ResultCollector {
+	layout := &rowLayout{generateHash: generateHash}
 	return &ResultCollector{
-		resultMap:    make(map[int]string),
-		totalCount:   totalCount,
-		batchSize:    batchSize,
-		outputFile:   outputFile,
-		generateHash: generateHash,
+		resultMap:  make(map[int]Record),
+		totalCount: totalCount,
+		batchSize:  batchSize,
+		outputFile: outputFile,
+		writer:     bufio.NewWriterSize(outputFile, 64*1024),
+		line:       make([]byte, 0, 128),
+		layout:     layout,
+		columns:    layout.outputColumns(false),
 	}
 }
 
-// AddResult adds a result to the collector and prints results in order
-func (rc *ResultCollector) AddResult(result Result, progressBar *ProgressBar) {
+// AddResult adds a record to the collector and prints results in order
+func (rc *ResultCollector) AddResult(record Record, progressBar *ProgressBar) error {
+	return rc.AddResults([]Record{record}, progressBar)
+}
+
+// AddResults adds a batch of records to the collector under a single lock
+// and prints results in order. It stops at the first write error and
+// returns it.
+func (rc *ResultCollector) AddResults(records []Record, progressBar *ProgressBar) error {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
-	rc.resultMap[result.index] = result.address
-	rc.resultCount++
+	for _, record := range records {
+		if rc.invariants != nil {
+			if err := rc.invariants.Received(record.index); err != nil {
+				fatalf("Invariant violated: %v", err)
+			}
+		}
+		rc.resultMap[record.index] = record
+	}
+	rc.resultCount += len(records)
 
 	// Update progress bar
 	progressBar.Update(rc.resultCount)
 
 	// Print results in order
 	for {
-		if address, exists := rc.resultMap[rc.nextToPrint]; exists {
-			if rc.generateHash {
-				// Generate a hash from the address
-				h := sha256.New()
-				h.Write([]byte(address))
-				hash := hex.EncodeToString(h.Sum(nil))
-				// Use first 6 characters of hash for shorter representation
-				fmt.Fprintf(rc.outputFile, "%s,%s\n", hash[:6], address)
-			} else {
-				fmt.Fprintln(rc.outputFile, address)
+		if record, exists := rc.resultMap[rc.nextToPrint]; exists {
+			if rc.invariants != nil {
+				if err := rc.invariants.Emitted(record.index); err != nil {
+					fatalf("Invariant violated: %v", err)
+				}
+			}
+			if err := rc.writeRecord(&record); err != nil {
+				return err
 			}
 			delete(rc.resultMap, rc.nextToPrint)
 			rc.nextToPrint++
@@ -279,107 +1764,185 @@ func (rc *ResultCollector) AddResult(result Result, progressBar *ProgressBar) {
 			break
 		}
 	}
+
+	// Flush once everything has been written so the output is complete
+	if rc.nextToPrint >= rc.totalCount {
+		return rc.flush()
+	}
+	return nil
 }
 
-func worker(id int, jobs <-chan Job, results chan<- Result, wg *sync.WaitGroup) {
-	defer wg.Done()
+// Printed returns the number of records written out in order so far
+func (rc *ResultCollector) Printed() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.nextToPrint
+}
 
-	for job := range jobs {
-		var addr string
+// SetWriter replaces the default buffered writer, e.g. with an AlignedWriter
+func (rc *ResultCollector) SetWriter(writer FlushWriter) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.writer = writer
+}
 
-		switch job.network {
-		case "ethereum":
-			addr = generateEthereumAddress(job.seed)
-		case "bitcoin":
-			addr = generateBitcoinAddress(job.seed)
-		case "solana":
-			addr = generateSolanaAddress(job.seed)
-		case "ton":
-			addr = generateTonAddress(job.seed)
-		}
+// SetInvariants makes the collector assert its ordering guarantees with
+// checker, aborting the run on the first violation
+func (rc *ResultCollector) SetInvariants(checker *InvariantChecker) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.invariants = checker
+}
 
-		results <- Result{index: job.index, address: addr}
-	}
+// SetHashMap writes hash,address rows to writer and only the hash of each
+// address to the output, so the output can be shared without the addresses
+func (rc *ResultCollector) SetHashMap(writer FlushWriter) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.hashMap = writer
 }
 
-func generateEthereumAddress(seed string) string {
-	// Convert seed to private key
-	seedBytes, err := hex.DecodeString(seed)
-	if err != nil {
-		log.Fatal("Invalid seed:", err)
-	}
+// SetSkipErrors skips records that failed to generate instead of aborting,
+// writing their index and error to errors if it is not nil
+func (rc *ResultCollector) SetSkipErrors(errors FlushWriter) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.skipErrors = true
+	rc.errors = errors
+}
 
-	// Create private key from seed
-	privateKey, err := crypto.ToECDSA(seedBytes)
-	if err != nil {
-		log.Fatal("Failed to create private key:", err)
-	}
+// SetLayout replaces the layout of the rows, which decides their columns
+func (rc *ResultCollector) SetLayout(layout *rowLayout) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.layout = layout
+	rc.columns = layout.outputColumns(false)
+}
 
-	// Get Ethereum address
-	address := crypto.PubkeyToAddress(privateKey.PublicKey)
-	return address.Hex()
+// SetEncoder writes the output through encoder instead of as plain rows.
+// Plain rows still go to the writer, which counts them and feeds the
+// network sinks.
+func (rc *ResultCollector) SetEncoder(encoder RecordEncoder) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.encoder = encoder
 }
 
-func generateBitcoinAddress(seed string) string {
-	// Convert seed to private key
-	seedBytes, err := hex.DecodeString(seed)
-	if err != nil {
-		log.Fatal("Invalid seed:", err)
-	}
+// Flush writes any buffered output to the underlying file
+func (rc *ResultCollector) Flush() error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.flush()
+}
 
-	// Create private key from seed
-	privKey, _ := btcec.PrivKeyFromBytes(seedBytes)
+// flush flushes every writer that buffers output. The caller holds mu.
+func (rc *ResultCollector) flush() error {
+	if rc.encoder != nil {
+		if err := rc.encoder.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, w := range []FlushWriter{rc.hashMap, rc.errors} {
+		if w != nil {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return rc.writer.Flush()
+}
 
-	// Get Bitcoin address
-	wif, err := btcutil.NewWIF(privKey, &chaincfg.MainNetParams, true)
-	if err != nil {
-		log.Fatal("Failed to create WIF:", err)
+// newRecordProcessor returns what a worker runs on each of its results.
+// Addresses are linked, given their keys and path, and hashed before they
+// leave the worker, so expensive derivations and iterated hashing run in
+// parallel. The record goes along as the result's Value.
+func newRecordProcessor(network string, keyed *KeyedHasher, link ColumnLinker, keys KeyDeriver, path *addrmint.HDPath) func(*addrmint.Result) {
+	return func(r *addrmint.Result) {
+		record := resultRecord(r, network)
+		if r.Err != nil {
+			r.Value = &record
+			return
+		}
+		if link != nil {
+			record.linked, record.err = link(&r.Seed)
+		}
+		if keys != nil && record.err == nil {
+			record.keys, record.err = keys(&r.Seed)
+		}
+		if path != nil {
+			record.path = path.Index(r.Index)
+		}
+		if keyed != nil {
+			keyed.Sum(record.address, &record.keyedHash)
+			record.hashed = true
+		}
+		r.Value = &record
 	}
+}
 
-	addressPubKey, err := btcutil.NewAddressPubKey(wif.SerializePubKey(), &chaincfg.MainNetParams)
-	if err != nil {
-		log.Fatal("Failed to create address:", err)
+// resultRecord returns the record of a result, the one its worker's
+// processor built if there is one. Failures are passed on so the collector
+// can apply --on-error.
+func resultRecord(r *addrmint.Result, network string) Record {
+	if record, ok := r.Value.(*Record); ok {
+		return *record
 	}
+	return Record{index: r.Index, worker: int32(r.Worker), network: network, address: string(r.Address), err: r.Err}
+}
 
-	return addressPubKey.EncodeAddress()
+// workerGenerators holds the generator of one worker, created on first use
+// and kept while the jobs name the same network and backends, since
+// generators may keep reusable state or run a plugin process
+type workerGenerators struct {
+	config    Job // Job the generator was created for, without index and seed
+	generator addrmint.Generator
 }
 
-func generateSolanaAddress(seed string) string {
-	// Convert seed to private key
-	seedBytes, err := hex.DecodeString(seed)
-	if err != nil {
-		log.Fatal("Invalid seed:", err)
+// Close stops the worker's plugin process, if it started one
+func (g *workerGenerators) Close() {
+	if closer, ok := g.generator.(io.Closer); ok {
+		closer.Close()
 	}
+	g.generator = nil
+}
 
-	// Use seed bytes as private key
-	account, err := types.AccountFromSeed(seedBytes)
-	if err != nil {
-		log.Fatal("Failed to create Solana account:", err)
+// newGenerator creates the generator of the network and backends job names
+func newGenerator(job *Job) (addrmint.Generator, error) {
+	switch {
+	case job.contract != nil:
+		return job.contract, nil
+	case job.descriptor != nil:
+		return job.descriptor, nil
+	case job.plugin != "":
+		return addrmint.StartPlugin(job.plugin)
 	}
-	return account.PublicKey.ToBase58()
+	return addrmint.New(job.network, addrmint.Options{Backend: job.backend, HashBackend: job.hashBackend, BitcoinTypes: job.btcTypes, Chain: job.chain})
 }
 
-func generateTonAddress(seed string) string {
-	// Convert seed to private key bytes
-	seedBytes, err := hex.DecodeString(seed)
-	if err != nil {
-		log.Fatal("Invalid seed:", err)
+// generateAddress derives the address for a job using the network and
+// backends it names, with g holding the calling worker's generator
+func generateAddress(job *Job, g *workerGenerators) (string, error) {
+	config := *job
+	config.index, config.seed = 0, [32]byte{}
+	if g.generator == nil || config != g.config {
+		g.Close()
+		generator, err := newGenerator(job)
+		if err != nil {
+			return "", err
+		}
+		g.generator, g.config = generator, config
 	}
+	address, err := g.generator.Generate(job.seed[:])
+	return string(address), err
+}
 
-	// Create ed25519 private key from seed (first 32 bytes)
-	privKey := ed25519.NewKeyFromSeed(seedBytes[:32])
-	pubKey := privKey.Public().(ed25519.PublicKey)
-
-	// Generate TON V5R1 address (most common modern wallet)
-	// Using mainnet global ID (-239) and workchain 0
-	addr, err := wallet.AddressFromPubKey(pubKey, wallet.ConfigV5R1Final{
-		NetworkGlobalID: -239, // Mainnet
-		Workchain:       0,
-	}, 0, 0)
-	if err != nil {
-		log.Fatal("Failed to create TON address:", err)
+// hashBackendInfo describes the hash implementations behind backend. Both
+// backends run x/crypto/sha3; they differ only in how hash states are kept.
+// SHA-256 always comes from crypto/sha256, which picks its own assembly.
+func hashBackendInfo(backend string) string {
+	state := "states from go-ethereum's pool"
+	if backend == hashBackendReused {
+		state = "one state per worker"
 	}
-
-	// Return non-bounceable user-friendly address (UQ... format)
-	return addr.Bounce(false).String()
+	return fmt.Sprintf("%s (Keccak-256: x/crypto/sha3, %s; SHA-256: crypto/sha256)", backend, state)
 }
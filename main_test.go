@@ -1,91 +1,42 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
-)
-
-// TestGenerateEthereumAddress tests the Ethereum address generation
-func TestGenerateEthereumAddress(t *testing.T) {
-	// Use a fixed seed for reproducible testing
-	seed := "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3"
-
-	address := generateEthereumAddress(seed)
-
-	// Get the actual address from the current implementation
-	expected := "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"
-
-	if address != expected {
-		t.Errorf("Expected address %s, got %s", expected, address)
-	}
-}
-
-// TestGenerateBitcoinAddress tests the Bitcoin address generation
-func TestGenerateBitcoinAddress(t *testing.T) {
-	// Use a fixed seed for reproducible testing
-	seed := "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3"
-
-	address := generateBitcoinAddress(seed)
-
-	// Since Bitcoin address generation is more complex, we'll just check the format
-	if !strings.HasPrefix(address, "1") && !strings.HasPrefix(address, "3") {
-		t.Errorf("Expected Bitcoin address to start with 1 or 3, got %s", address)
-	}
-
-	// Check length is reasonable
-	if len(address) < 25 || len(address) > 35 {
-		t.Errorf("Bitcoin address length unusual: %d", len(address))
-	}
-}
-
-// TestGenerateSolanaAddress tests the Solana address generation
-func TestGenerateSolanaAddress(t *testing.T) {
-	// Use a fixed seed for reproducible testing
-	seed := "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3"
-
-	address := generateSolanaAddress(seed)
+	"time"
+	"unsafe"
 
-	// Check that the address is in base58 format (typically starts with specific characters)
-	if len(address) != 44 {
-		t.Errorf("Expected Solana address length to be 44, got %d", len(address))
-	}
-}
-
-// TestGenerateTonAddress tests the TON address generation
-func TestGenerateTonAddress(t *testing.T) {
-	// Use a fixed seed for reproducible testing
-	seed := "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3"
-
-	address := generateTonAddress(seed)
-
-	// TON user-friendly addresses are 48 characters (base64 encoded)
-	if len(address) != 48 {
-		t.Errorf("Expected TON address length to be 48, got %d (address: %s)", len(address), address)
-	}
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
 
-	// Non-bounceable mainnet addresses start with "UQ"
-	if !strings.HasPrefix(address, "UQ") {
-		t.Errorf("Expected TON address to start with 'UQ', got %s", address)
+// decodeSeed decodes a hex encoded test seed
+func decodeSeed(t *testing.T, seed string) []byte {
+	t.Helper()
+	seedBytes, err := hex.DecodeString(seed)
+	if err != nil {
+		t.Fatalf("Invalid test seed %s: %v", seed, err)
 	}
+	return seedBytes
 }
 
-// TestGenerateTonAddressDeterministic tests that TON address generation is deterministic
-func TestGenerateTonAddressDeterministic(t *testing.T) {
-	seed := "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3"
-
-	addr1 := generateTonAddress(seed)
-	addr2 := generateTonAddress(seed)
-
-	if addr1 != addr2 {
-		t.Errorf("TON address generation not deterministic: %s != %s", addr1, addr2)
+// v1Seeds creates a v1 seed deriver for a test seed
+func v1Seeds(t *testing.T, baseSeed string) *addrmint.SeedDeriver {
+	t.Helper()
+	d, err := addrmint.NewSeedDeriver(addrmint.DerivationV1, baseSeed)
+	if err != nil {
+		t.Fatal(err)
 	}
+	return d
 }
 
 // TestProgressBar tests the progress bar functionality
@@ -121,6 +72,78 @@ func TestProgressBar(t *testing.T) {
 	}
 }
 
+// TestProgressBarASCII tests the ASCII fallback style used on legacy consoles
+func TestProgressBarASCII(t *testing.T) {
+	// Redirect stderr to capture output
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	pb := NewProgressBar(4, 4)
+	pb.SetStyle(progressStyleASCII)
+	pb.Update(2)
+	pb.lastPrint = time.Time{}.Add(time.Second) // Allow an immediate redraw
+	pb.Update(4)
+
+	w.Close()
+	output, _ := io.ReadAll(r)
+	os.Stderr = oldStderr
+
+	outputStr := string(output)
+	if !strings.Contains(outputStr, "[##--]") || !strings.Contains(outputStr, "[####]") {
+		t.Errorf("Expected ASCII progress bar, got %q", outputStr)
+	}
+	for _, r := range outputStr {
+		if r > 127 {
+			t.Errorf("Unexpected non-ASCII character %q in progress output", r)
+			break
+		}
+	}
+}
+
+// TestProgressBarWritten tests that progress reflects written rows rather than collected ones
+func TestProgressBarWritten(t *testing.T) {
+	// Redirect stderr to capture output
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	var output bytes.Buffer
+	counter := NewCountingWriter(&output)
+	pb := NewProgressBar(4, 10)
+	pb.TrackWritten(&counter.rows)
+
+	// Everything is generated but only half of it has been written
+	counter.Write([]byte("a\nb\n"))
+	pb.Update(4)
+	halfway := pb.written
+
+	// Write the rest and draw the final state
+	counter.Write([]byte("c\nd\n"))
+	pb.Finish()
+
+	w.Close()
+	captured, _ := io.ReadAll(r)
+	os.Stderr = oldStderr
+
+	if halfway != 2 {
+		t.Errorf("Expected 2 written rows before the final write, got %d", halfway)
+	}
+	if counter.Rows() != 4 {
+		t.Errorf("Expected counting writer to count 4 rows, got %d", counter.Rows())
+	}
+	if counter.Bytes() != 8 {
+		t.Errorf("Expected counting writer to count 8 bytes, got %d", counter.Bytes())
+	}
+	outputStr := string(captured)
+	if !strings.Contains(outputStr, "2/4 written") || !strings.Contains(outputStr, "4/4 written") {
+		t.Errorf("Progress bar output missing written counts: %s", outputStr)
+	}
+	if strings.Count(outputStr, "\n") != 1 {
+		t.Errorf("Expected progress bar to finish exactly once: %q", outputStr)
+	}
+}
+
 // TestResultCollector tests the result collector functionality separately from the actual ResultCollector type
 func TestResultCollector(t *testing.T) {
 	// Create our own test implementation to avoid the os.File requirement
@@ -134,7 +157,7 @@ func TestResultCollector(t *testing.T) {
 	pb := NewProgressBar(5, 10)
 
 	// Add results out of order
-	results := []Result{
+	results := []Record{
 		{index: 2, address: "address2"},
 		{index: 0, address: "address0"},
 		{index: 1, address: "address1"},
@@ -215,7 +238,7 @@ func TestGenerateHashForAddress(t *testing.T) {
 	// Test with the actual ResultCollector
 	rc := NewResultCollector(1, 1, tempFile, true)
 	pb := NewProgressBar(1, 10)
-	rc.AddResult(Result{index: 0, address: address}, pb)
+	rc.AddResult(Record{index: 0, address: address}, pb)
 
 	// Flush and rewind the file
 	tempFile.Sync()
@@ -234,80 +257,280 @@ func TestGenerateHashForAddress(t *testing.T) {
 	}
 }
 
-// TestBatchSubmitJobs tests the batch job submission
-func TestBatchSubmitJobs(t *testing.T) {
-	// Create channels and a pool
-	jobs := make(chan Job, 10)
-	pool := &sync.Pool{
-		New: func() interface{} {
-			return &Job{}
-		},
+// TestResultCollectorHashMap tests that --hash-map keeps addresses out of the output
+func TestResultCollectorHashMap(t *testing.T) {
+	address := "0x122b84B924B5f9bE23b7A8961685B3AB8224ebCa"
+	sum := sha256.Sum256([]byte(address))
+	hash := hex.EncodeToString(sum[:3])
+
+	var output, hashMap bytes.Buffer
+	rc := NewResultCollector(2, 1, nil, true)
+	rc.SetWriter(bufio.NewWriter(&output))
+	rc.SetHashMap(bufio.NewWriter(&hashMap))
+
+	pb := NewProgressBar(2, 10)
+	rc.AddResult(Record{index: 1, address: address}, pb)
+	rc.AddResult(Record{index: 0, address: address}, pb)
+
+	if expected := hash + "\n" + hash + "\n"; output.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, output.String())
+	}
+	if expected := hash + "," + address + "\n"; hashMap.String() != expected+expected {
+		t.Errorf("Expected hash map %q, got %q", expected+expected, hashMap.String())
+	}
+
+	// Columns before the hash stay in the output
+	output.Reset()
+	hashMap.Reset()
+	rc = NewResultCollector(1, 1, nil, true)
+	rc.SetWriter(bufio.NewWriter(&output))
+	rc.SetHashMap(bufio.NewWriter(&hashMap))
+	rc.SetLayout(&rowLayout{linked: []string{"label"}, generateHash: true})
+	rc.AddResult(Record{index: 0, address: address, linked: &LinkedColumns{columns: []string{"label"}}}, pb)
+	if expected := "label," + hash + "\n"; output.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, output.String())
+	}
+	if expected := "label," + hash + "," + address + "\n"; hashMap.String() != expected {
+		t.Errorf("Expected hash map %q, got %q", expected, hashMap.String())
 	}
+}
 
-	// Submit jobs
-	go batchSubmitJobs(jobs, 5, "testseed", "ethereum", 2, pool)
+// TestResultCollectorWriteError tests that the collector stops at the first
+// failed write instead of generating the rest of the run
+func TestResultCollectorWriteError(t *testing.T) {
+	rc := NewResultCollector(3, 1, nil, false)
+	rc.SetWriter(bufio.NewWriterSize(failingWriter{}, 16))
 
-	// Read and validate jobs
-	count := 0
-	for job := range jobs {
-		if job.network != "ethereum" {
-			t.Errorf("Expected network ethereum, got %s", job.network)
-		}
-		count++
-		if count == 5 {
-			// All jobs received, we're done
-			break
-		}
+	pb := NewProgressBar(3, 10)
+	if err := rc.AddResult(Record{index: 0, address: "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"}, pb); err == nil {
+		t.Error("Expected the write error to be returned")
 	}
+	if rc.Printed() != 0 {
+		t.Errorf("Expected the failed record to stay unprinted, got %d printed", rc.Printed())
+	}
+}
 
-	if count != 5 {
-		t.Errorf("Expected 5 jobs, got %d", count)
+// TestResultCollectorSkipErrors tests that --on-error skip leaves failed
+// addresses out of the output and records them in the error file
+func TestResultCollectorSkipErrors(t *testing.T) {
+	var output, errorFile bytes.Buffer
+	rc := NewResultCollector(3, 1, nil, false)
+	rc.SetWriter(bufio.NewWriter(&output))
+	rc.SetSkipErrors(bufio.NewWriter(&errorFile))
+
+	pb := NewProgressBar(3, 10)
+	rc.AddResult(Record{index: 2, address: "address2"}, pb)
+	rc.AddResult(Record{index: 1, err: fmt.Errorf(`unsupported network: "x"`)}, pb)
+	rc.AddResult(Record{index: 0, address: "address0"}, pb)
+
+	if expected := "address0\naddress2\n"; output.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, output.String())
+	}
+	if expected := "1,\"unsupported network: \"\"x\"\"\"\n"; errorFile.String() != expected {
+		t.Errorf("Expected error file %q, got %q", expected, errorFile.String())
+	}
+	if rc.failed != 1 {
+		t.Errorf("Expected 1 failed record, got %d", rc.failed)
 	}
 }
 
-// TestWorker tests the worker function
-func TestWorker(t *testing.T) {
-	// Create channels
-	jobs := make(chan Job, 4)
-	results := make(chan Result, 4)
-	var wg sync.WaitGroup
-
-	// Start worker
-	wg.Add(1)
-	go worker(1, jobs, results, &wg)
-
-	// Send jobs for different networks
-	jobs <- Job{index: 0, seed: "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3", network: "ethereum"}
-	jobs <- Job{index: 1, seed: "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3", network: "bitcoin"}
-	jobs <- Job{index: 2, seed: "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3", network: "solana"}
-	jobs <- Job{index: 3, seed: "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3", network: "ton"}
-	close(jobs)
-
-	// Wait for worker to finish
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(results)
-		close(done)
-	}()
-
-	// Verify results
-	resultCount := 0
-	for result := range results {
-		if result.index < 0 || result.index > 3 {
-			t.Errorf("Unexpected result index: %d", result.index)
+// TestNewGenerator tests that jobs of every network get their generator
+func TestNewGenerator(t *testing.T) {
+	var seed [32]byte
+	copy(seed[:], decodeSeed(t, "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3"))
+
+	var generators workerGenerators
+	defer generators.Close()
+	for _, network := range []string{"ethereum", "bitcoin", "solana", "ton"} {
+		job := Job{seed: seed, network: network}
+		addr, err := generateAddress(&job, &generators)
+		if err != nil || addr == "" {
+			t.Errorf("%s: unexpected address %q (%v)", network, addr, err)
 		}
-		if result.address == "" {
-			t.Errorf("Empty address for result %d", result.index)
+	}
+	if _, err := generateAddress(&Job{network: "dogecoin"}, &generators); err == nil {
+		t.Error("Expected an error for an unknown network")
+	}
+}
+
+func TestRecordProcessor(t *testing.T) {
+	var seed [32]byte
+	copy(seed[:], decodeSeed(t, "c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3"))
+	key := []byte("test key")
+	process := newRecordProcessor("ethereum", NewKeyedHasher(key), nil, nil, nil)
+	result := addrmint.Result{Index: 5, Seed: seed, Address: "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f", Worker: 2}
+	process(&result)
+
+	// Records keep the address; only the output layer decides to write the hash
+	record := resultRecord(&result, "bitcoin")
+	if record.network != "ethereum" || record.address != "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f" || !record.hashed || record.worker != 2 {
+		t.Fatalf("Unexpected record: %+v", record)
+	}
+
+	var output bytes.Buffer
+	rc := NewResultCollector(1, 1, nil, false)
+	rc.SetWriter(bufio.NewWriter(&output))
+	rc.SetLayout(&rowLayout{hashOnly: true})
+	record.index = 0
+	rc.AddResult(record, NewProgressBar(1, 10))
+	rc.Flush()
+
+	expected := string(NewKeyedHasher(key).AppendHex(nil, record.address)) + "\n"
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+
+	// Keys and the path go along with the record
+	path, _ := addrmint.ParseHDPath("m/44'/60'/0'/0/{i}")
+	process = newRecordProcessor("ethereum", nil, nil, newKeyDeriver("ethereum", nil), path)
+	result = addrmint.Result{Index: 5, Seed: seed, Address: "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"}
+	process(&result)
+	if record := resultRecord(&result, "ethereum"); record.keys == nil || !bytes.Equal(record.keys.privateKey, seed[:]) || record.path != "m/44'/60'/0'/0/5" {
+		t.Errorf("Expected keys and path in %+v", record)
+	}
+
+	// Failed addresses are neither linked nor hashed
+	linked := false
+	process = newRecordProcessor("ethereum", NewKeyedHasher(key), func(*[32]byte) (*LinkedColumns, error) {
+		linked = true
+		return nil, nil
+	}, newKeyDeriver("ethereum", nil), nil)
+	result = addrmint.Result{Err: addrmint.ErrZeroPrivateKey}
+	process(&result)
+	if record := resultRecord(&result, "ethereum"); record.err != addrmint.ErrZeroPrivateKey || record.hashed || linked || record.keys != nil {
+		t.Errorf("Unexpected record for a failed address: %+v", record)
+	}
+}
+
+func TestGeneratePlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The test plugin is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'addrmint-plugin 1'\nwhile read -r seed; do echo \"ok plug$(echo $seed | cut -c1-4)\"; done\n"
+	if err := os.WriteFile(filepath.Join(dir, "addrmint-plugcoin"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plugin, err := addrmint.FindPlugin(dir, "plugcoin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each worker starts its own plugin process and stops it when done
+	var generators workerGenerators
+	first, err := generateAddress(&Job{seed: [32]byte{0xab, 0xcd}, network: "plugcoin", plugin: plugin}, &generators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := generators.generator
+	second, err := generateAddress(&Job{seed: [32]byte{0x12, 0x34}, network: "plugcoin", plugin: plugin}, &generators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != "plugabcd" || second != "plug1234" || generators.generator != started {
+		t.Errorf("Expected both addresses from one plugin process, got %q and %q", first, second)
+	}
+	generators.Close()
+	if _, err := started.Generate(make([]byte, 32)); err == nil {
+		t.Error("Expected the plugin to be stopped")
+	}
+}
+
+// TestRecordSize keeps records small enough for the collector's map to store
+// them inline, which is what keeps result collection allocation free
+func TestRecordSize(t *testing.T) {
+	if size := unsafe.Sizeof(Record{}); size > 128 {
+		t.Errorf("Record is %d bytes; maps allocate values larger than 128 bytes", size)
+	}
+}
+
+// TestHotPathAllocations guards the zero-allocation steady state of the pipeline
+// stages owned by AddrMint (seed derivation and result collection)
+func TestHotPathAllocations(t *testing.T) {
+	// Seed derivation must reuse the scratch buffer
+	var seed [32]byte
+	buf := make([]byte, 0, 64)
+	index := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		buf = addrmint.DeriveSeed(buf, "c8c5e5a7f326a2b5", index, &seed)
+		index++
+	})
+	if allocs != 0 {
+		t.Errorf("Expected seed derivation to perform 0 allocations, got %.1f", allocs)
+	}
+
+	// Result collection must reuse the line buffer and the output buffer
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	for _, generateHash := range []bool{false, true} {
+		rc := NewResultCollector(1<<30, 1000, devNull, generateHash)
+		pb := NewProgressBar(1<<30, 10)
+		pb.lastPrint = time.Now().Add(time.Hour) // Keep the progress bar quiet
+		result := Record{address: "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"}
+		allocs := testing.AllocsPerRun(1000, func() {
+			rc.AddResult(result, pb)
+			result.index++
+		})
+		if allocs != 0 {
+			t.Errorf("Expected result collection (generateHash=%t) to perform 0 allocations, got %.1f", generateHash, allocs)
 		}
-		resultCount++
 	}
+}
 
-	// Wait for done signal
-	<-done
+// BenchmarkDeriveSeed measures per-index seed derivation
+func BenchmarkDeriveSeed(b *testing.B) {
+	b.ReportAllocs()
+	var seed [32]byte
+	buf := make([]byte, 0, 64)
+	for i := 0; i < b.N; i++ {
+		buf = addrmint.DeriveSeed(buf, "c8c5e5a7f326a2b5", i, &seed)
+	}
+}
 
-	// Check that we got all results
-	if resultCount != 4 {
-		t.Errorf("Expected 4 results, got %d", resultCount)
+// BenchmarkResultCollector measures in-order result collection and output formatting
+func BenchmarkResultCollector(b *testing.B) {
+	b.ReportAllocs()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	rc := NewResultCollector(b.N, 1000, devNull, true)
+	pb := NewProgressBar(1<<30, 10)
+	pb.lastPrint = time.Now().Add(time.Hour) // Keep the progress bar quiet
+	for i := 0; i < b.N; i++ {
+		rc.AddResult(Record{index: i, address: "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"}, pb)
+	}
+}
+
+// BenchmarkResultCollectorOutOfOrder measures collection when batches from
+// several workers arrive interleaved, so most records wait in the map
+func BenchmarkResultCollectorOutOfOrder(b *testing.B) {
+	b.ReportAllocs()
+	const workers, batchSize = 8, 64
+	rc := NewResultCollector(b.N, 1000, nil, false)
+	rc.SetWriter(bufio.NewWriter(io.Discard))
+	pb := NewProgressBar(1<<30, 10)
+	pb.lastPrint = time.Now().Add(time.Hour) // Keep the progress bar quiet
+
+	// Each round deals consecutive batches to the workers, which deliver
+	// them last to first
+	batch := make([]Record, 0, batchSize)
+	for round := 0; round < b.N; round += workers * batchSize {
+		for w := workers - 1; w >= 0; w-- {
+			batch = batch[:0]
+			for i := round + w*batchSize; i < min(round+(w+1)*batchSize, b.N); i++ {
+				batch = append(batch, Record{index: i, address: "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"})
+			}
+			rc.AddResults(batch, pb)
+		}
+	}
+	if rc.Printed() != b.N {
+		b.Fatalf("Expected %d records written, got %d", b.N, rc.Printed())
 	}
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// Manifest describes a generation run so that its output can be traced and
+// reproduced later. It never contains the seed or the hash key itself.
+type Manifest struct {
+	Version        string              `json:"version"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Network        string              `json:"network"`
+	Plugin         string              `json:"plugin,omitempty"`     // Plugin executable that generated a network that is not built in
+	Descriptor     *DescriptorManifest `json:"descriptor,omitempty"` // Definition of a network from --network-dir
+	Chain          string              `json:"chain,omitempty"`      // Chain other than mainnet, set by --chain
+	Count          int                 `json:"count"`
+	Offset         int                 `json:"offset,omitempty"`           // Index of the first address, set by --range
+	Errors         int                 `json:"errors,omitempty"`           // Addresses skipped with --on-error skip
+	DroppedSinks   []string            `json:"dropped_sinks,omitempty"`    // Sinks dropped after failing with a skip policy
+	DeadLetterRows map[string]int64    `json:"dead_letter_rows,omitempty"` // Rows each sink's dead-letter file received
+	CryptoBackend  string              `json:"crypto_backend"`
+	Derivation     string              `json:"derivation_scheme"`              // Seed derivation scheme, see SeedDeriver
+	DerivationPath string              `json:"derivation_path,omitempty"`      // HD path given by --derivation-path
+	SeedShares     []string            `json:"seed_shares,omitempty"`          // Fingerprints of the shares combined by --seed-shares
+	Mnemonic       string              `json:"mnemonic_fingerprint,omitempty"` // Fingerprint of the BIP-39 seed given by --mnemonic or --generate-mnemonic
+	Contract       *ContractManifest   `json:"contract,omitempty"`             // Deployment whose addresses --contract predicted
+	HashBackend    string              `json:"hash_backend"`
+	Output         string              `json:"output,omitempty"`
+	Format         string              `json:"format,omitempty"`   // Output format, omitted for plain rows
+	Keys           string              `json:"keys,omitempty"`     // Private key format of --include-keys, omitted when no keys were written
+	Keystore       string              `json:"keystore,omitempty"` // Directory of the keystore files written by --keystore-dir
+	Hashing        *HashingManifest    `json:"hashing,omitempty"`
+	Shard          *ShardManifest      `json:"shard,omitempty"`
+	Resources      *ResourceUsage      `json:"resources,omitempty"`
+	Compliance     *ComplianceManifest `json:"compliance,omitempty"` // FIPS status, set by --fips
+}
+
+// ContractManifest records the deployment of a --contract run. The
+// indices of the run are the deployer's nonces or the salts.
+type ContractManifest struct {
+	Opcode       string `json:"opcode"`
+	Deployer     string `json:"deployer"`
+	InitCodeHash string `json:"init_code_hash,omitempty"`
+}
+
+// DescriptorManifest records the definition of a --network-dir network,
+// so the corpus can be regenerated even if the descriptor file changes
+type DescriptorManifest struct {
+	Encoding  string `json:"encoding"`
+	Curve     string `json:"curve"`
+	PublicKey string `json:"public_key,omitempty"`
+	Hash      string `json:"hash"`
+	HRP       string `json:"hrp,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// descriptorManifest describes d in the terms of its descriptor file
+func descriptorManifest(d *addrmint.ChainDescriptor) *DescriptorManifest {
+	m := &DescriptorManifest{Encoding: d.Encoding, Curve: d.Curve, Hash: d.Hash, HRP: d.HRP}
+	if d.Curve == addrmint.CurveSecp256k1 {
+		m.PublicKey = "compressed"
+		if d.Uncompressed {
+			m.PublicKey = "uncompressed"
+		}
+	}
+	if d.Version != nil {
+		m.Version = "0x" + hex.EncodeToString(d.Version)
+	}
+	return m
+}
+
+// HashingManifest records how addresses were hashed. Two corpora share a
+// hash space exactly when their key fingerprints and iterations match.
+type HashingManifest struct {
+	Algorithm      string `json:"algorithm"`
+	Iterations     int    `json:"iterations"`
+	KeyFingerprint string `json:"key_fingerprint"`
+	KeySource      string `json:"key_source"`
+	SaltFile       string `json:"salt_file,omitempty"`
+}
+
+// ShardManifest identifies one shard of a corpus produced by split
+type ShardManifest struct {
+	Index  int    `json:"index"`
+	Shards int    `json:"shards"`
+	Source string `json:"source,omitempty"`
+}
+
+// ResourceUsage records what a run cost, for charging back generation jobs.
+// CPU time and peak RSS include child processes and are omitted on platforms
+// that cannot report them.
+type ResourceUsage struct {
+	WallSeconds  float64 `json:"wall_seconds"`
+	CPUSeconds   float64 `json:"cpu_seconds,omitempty"`
+	PeakRSSBytes int64   `json:"peak_rss_bytes,omitempty"`
+	BytesWritten int64   `json:"bytes_written"`
+}
+
+// measureResources returns the usage of the run so far
+func measureResources(start time.Time, bytesWritten int64) *ResourceUsage {
+	usage := &ResourceUsage{
+		WallSeconds:  time.Since(start).Seconds(),
+		BytesWritten: bytesWritten,
+	}
+	if cpu, peak, ok := runUsage(); ok {
+		usage.CPUSeconds = cpu.Seconds()
+		usage.PeakRSSBytes = peak
+	}
+	return usage
+}
+
+// Sources of the hash key recorded in the manifest
+const (
+	keySourceFlag      = "flag"
+	keySourceGenerated = "generated"
+	keySourceSaltFile  = "salt-file"
+)
+
+// writeManifest writes the manifest as indented JSON
+func writeManifest(path string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readManifest reads a manifest written by writeManifest
+func readManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// manifestPath returns where the manifest of a corpus file is kept
+func manifestPath(corpus string) string {
+	return corpus + ".manifest.json"
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteManifest(t *testing.T) {
+	key := []byte("secret hash key")
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	manifest := &Manifest{
+		Version: "1.2.3",
+		Network: "ethereum",
+		Count:   10,
+		Hashing: &HashingManifest{
+			Algorithm:      "HMAC-SHA256",
+			Iterations:     3,
+			KeyFingerprint: keyFingerprint(key),
+			KeySource:      keySourceGenerated,
+		},
+	}
+	if err := writeManifest(path, manifest); err != nil {
+		t.Fatalf("writeManifest failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var decoded Manifest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Manifest is not valid JSON: %v", err)
+	}
+	if decoded.Network != "ethereum" || decoded.Count != 10 || decoded.Hashing == nil || decoded.Hashing.Iterations != 3 {
+		t.Errorf("Manifest did not round-trip: %+v", decoded)
+	}
+
+	// Only the fingerprint of the key is recorded
+	if strings.Contains(string(data), hex.EncodeToString(key)) {
+		t.Errorf("Manifest must not contain the hash key")
+	}
+	if decoded.Hashing.KeyFingerprint != keyFingerprint(key) {
+		t.Errorf("Expected key fingerprint %s, got %s", keyFingerprint(key), decoded.Hashing.KeyFingerprint)
+	}
+}
+
+func TestMeasureResources(t *testing.T) {
+	start := time.Now().Add(-time.Second)
+	usage := measureResources(start, 1234)
+	if usage.WallSeconds < 1 || usage.BytesWritten != 1234 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+	if _, _, ok := runUsage(); ok && (usage.CPUSeconds <= 0 || usage.PeakRSSBytes < 1<<20) {
+		t.Errorf("Expected CPU time and a peak RSS of at least 1 MiB, got %+v", usage)
+	}
+}
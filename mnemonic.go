@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// readMnemonic returns the phrase given to --mnemonic, reading it from a
+// file when the value starts with @ so the words stay out of the shell
+// history and the process list
+func readMnemonic(value string) (string, error) {
+	path, ok := strings.CutPrefix(value, "@")
+	if !ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// mnemonicBaseSeed derives the base seed of a run from a BIP-39 mnemonic:
+// the hex of its 64-byte binary seed, which each index then derives from
+// like any other base seed
+func mnemonicBaseSeed(mnemonic, passphrase string) (string, error) {
+	seed, err := addrmint.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(seed), nil
+}
+
+// Where the base seed of a run comes from
+const (
+	seedSourceRandom   = "random"
+	seedSourceInteger  = "seed"
+	seedSourceShares   = "seed-shares"
+	seedSourceMnemonic = "mnemonic"
+)
+
+// seedSource returns the single seed source the flags select. --seed,
+// --seed-shares and a mnemonic each give the whole seed, so combining them
+// would silently ignore all but one.
+func seedSource(seed int64, shares, mnemonic string, generate int, passphrase string) (string, error) {
+	var flags []string
+	source := seedSourceRandom
+	if seed != 0 {
+		flags, source = append(flags, "--seed"), seedSourceInteger
+	}
+	if shares != "" {
+		flags, source = append(flags, "--seed-shares"), seedSourceShares
+	}
+	if mnemonic != "" {
+		flags, source = append(flags, "--mnemonic"), seedSourceMnemonic
+	}
+	if generate != 0 {
+		flags, source = append(flags, "--generate-mnemonic"), seedSourceMnemonic
+	}
+	if len(flags) > 1 {
+		return "", fmt.Errorf("%s cannot be combined", strings.Join(flags, " and "))
+	}
+	if passphrase != "" && source != seedSourceMnemonic {
+		return "", errors.New("--mnemonic-passphrase needs --mnemonic or --generate-mnemonic")
+	}
+	return source, nil
+}
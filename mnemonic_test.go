@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMnemonicBaseSeed(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	path := filepath.Join(t.TempDir(), "phrase.txt")
+	if err := os.WriteFile(path, []byte(mnemonic+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fromFile, err := readMnemonic("@" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, phrase := range []string{mnemonic, fromFile} {
+		seed, err := mnemonicBaseSeed(phrase, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seed != "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04" {
+			t.Errorf("Unexpected base seed %s", seed)
+		}
+	}
+
+	if _, err := readMnemonic("@" + filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing mnemonic file")
+	}
+	if _, err := mnemonicBaseSeed("abandon abandon abandon", ""); err == nil {
+		t.Error("Expected an error for a short mnemonic")
+	}
+}
+
+func TestSeedSource(t *testing.T) {
+	const phrase = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	tests := []struct {
+		seed       int64
+		shares     string
+		mnemonic   string
+		generate   int
+		passphrase string
+		want       string // Empty for an error
+	}{
+		{0, "", "", 0, "", seedSourceRandom},
+		{42, "", "", 0, "", seedSourceInteger},
+		{0, "a.hex,b.hex", "", 0, "", seedSourceShares},
+		{0, "", phrase, 0, "TREZOR", seedSourceMnemonic},
+		{0, "", "", 12, "TREZOR", seedSourceMnemonic},
+		{42, "a.hex,b.hex", "", 0, "", ""},
+		{0, "a.hex,b.hex", phrase, 0, "", ""},
+		{0, "a.hex,b.hex", "", 24, "", ""},
+		{42, "", phrase, 0, "", ""},
+		{0, "", phrase, 12, "", ""},
+		{0, "a.hex,b.hex", "", 0, "TREZOR", ""},
+		{0, "", "", 0, "TREZOR", ""},
+	}
+	for i, tt := range tests {
+		got, err := seedSource(tt.seed, tt.shares, tt.mnemonic, tt.generate, tt.passphrase)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%d: expected an error, got source %q", i, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%d: expected source %q, got %q (%v)", i, tt.want, got, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"encoding/hex"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// ensNameHashBytes is the number of hash bytes in a generated name, enough
+// to keep names of corpora with millions of addresses unique in practice
+const ensNameHashBytes = 6
+
+// ensName returns the deterministic ENS-style name of the address derived
+// from seed, such as wallet-3f9a0c1b2d4e.eth
+func ensName(seed *[32]byte) string {
+	sum := addrmint.LinkedSeed(seed, "ens-name", 0)
+	return "wallet-" + hex.EncodeToString(sum[:ensNameHashBytes]) + ".eth"
+}
+
+// ensNameColumn names the column ensNameLinker writes
+const ensNameColumn = "ens_name"
+
+// ensNameLinker writes the ENS-style name of each address before it
+func ensNameLinker(seed *[32]byte) (*LinkedColumns, error) {
+	return &LinkedColumns{columns: []string{ensName(seed)}}, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+func TestENSName(t *testing.T) {
+	var seed [32]byte
+	addrmint.DeriveSeed(nil, selftestSeed, 0, &seed)
+
+	// Names are stable across regenerations with the same seed
+	if name := ensName(&seed); name != "wallet-607f8a72565c.eth" {
+		t.Errorf("Expected wallet-607f8a72565c.eth, got %s", name)
+	}
+
+	pattern := regexp.MustCompile(`^wallet-[0-9a-f]{12}\.eth$`)
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		addrmint.DeriveSeed(nil, selftestSeed, i, &seed)
+		name := ensName(&seed)
+		if !pattern.MatchString(name) || seen[name] {
+			t.Errorf("Index %d: name %s is malformed or repeated", i, name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestEntityLabels(t *testing.T) {
+	var seed [32]byte
+	addrmint.DeriveSeed(nil, selftestSeed, 0, &seed)
+	labels := entityLabelColumns(&seed)
+	if got := strings.Join(labels, ","); got != "Mateo Yilmaz,KR,standard" {
+		t.Errorf("Expected Mateo Yilmaz,KR,standard, got %s", got)
+	}
+
+	// Tiers follow their weights
+	tiers := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		addrmint.DeriveSeed(nil, selftestSeed, i, &seed)
+		tiers[entityLabelColumns(&seed)[2]]++
+	}
+	for _, tier := range labelKYCTiers {
+		if got := tiers[tier.tier]; got < int(tier.weight)*80 || got > int(tier.weight)*120 {
+			t.Errorf("Tier %s: %d of 10000, expected about %d", tier.tier, got, tier.weight*100)
+		}
+	}
+}
+
+func TestChainLinkers(t *testing.T) {
+	var seed [32]byte
+	link := chainLinkers(ensNameLinker, entityLabelLinker)
+	linked, err := link(&seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]string{ensName(&seed)}, entityLabelColumns(&seed)...)
+	if !slices.Equal(linked.columns, want) {
+		t.Errorf("Expected %v, got %v", want, linked.columns)
+	}
+	if chainLinkers(nil, ensNameLinker) == nil {
+		t.Error("Expected the second linker when the first is nil")
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	natsPipeline   = 64               // Messages in flight before their acks are awaited
+	natsAckTimeout = 30 * time.Second // How long to wait for JetStream to acknowledge a message
+)
+
+// NATSWriter publishes the rows written to it to a JetStream subject,
+// several rows per message. Every message is published with a reply inbox
+// and counts as delivered only once the stream acknowledges it, so a
+// missing stream or a full one fails the run instead of dropping rows. It
+// implements FlushWriter; Flush publishes the pending rows and waits for
+// their acks.
+//
+// With a message ID prefix, each message carries a Nats-Msg-Id made of the
+// prefix and the number of rows before it. Messages are cut at the same rows
+// when RetryWriter replays them after Resume, so JetStream drops the copies
+// of messages it already stored, as long as they arrive within the stream's
+// duplicate window.
+type NATSWriter struct {
+	conn    net.Conn
+	out     *bufio.Writer
+	in      *bufio.Reader
+	subject string
+	inbox   string // Prefix of the reply subjects acks arrive on
+	rows    int    // Rows per message
+	msgID   string // Prefix of the Nats-Msg-Id headers, empty for none
+	headers bool   // The server accepts messages with headers
+
+	message  []byte // Rows of the message being built
+	pending  int    // Rows in message
+	row      int64  // Rows published before message, numbering its ID
+	sent     int    // Messages published so far, used to number reply subjects
+	inFlight int    // Messages published whose acks have not arrived
+}
+
+// natsAPIError is the error JetStream replies with when it rejects a
+// publish or an API request
+type natsAPIError struct {
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+}
+
+func (e *natsAPIError) Error() string {
+	return fmt.Sprintf("jetstream: %s (%d)", e.Description, e.Code)
+}
+
+// natsAck is the reply JetStream sends to a publish
+type natsAck struct {
+	Stream string        `json:"stream"`
+	Seq    uint64        `json:"seq"`
+	Error  *natsAPIError `json:"error"`
+}
+
+// errNoResponders is returned for requests that nothing subscribes to
+var errNoResponders = errors.New("no responders")
+
+// DialNATS connects to a nats://[user:password@|token@]host[:port] URL.
+// Rows are published to subject, rows at a time, with message IDs starting
+// with msgID unless it is empty.
+func DialNATS(rawURL, subject string, rows int, msgID string) (*NATSWriter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("unsupported scheme %q, expected nats://", u.Scheme)
+	}
+	if password, ok := u.User.Password(); ok {
+		registerSecret(password)
+	} else if u.User != nil {
+		registerSecret(u.User.Username())
+	}
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n*>") {
+		return nil, fmt.Errorf("invalid subject %q", subject)
+	}
+	if rows < 1 {
+		return nil, fmt.Errorf("rows per message must be at least 1")
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	nw := &NATSWriter{
+		conn:    conn,
+		out:     bufio.NewWriterSize(conn, 64*1024),
+		in:      bufio.NewReader(conn),
+		subject: subject,
+		inbox:   "_INBOX." + hex.EncodeToString(id[:]),
+		rows:    rows,
+		msgID:   msgID,
+	}
+	if err := nw.handshake(u.User); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return nw, nil
+}
+
+// handshake reads the server's INFO, sends CONNECT with any credentials
+// and subscribes to the ack inbox. A PING round trip confirms the server
+// accepted all of it.
+func (nw *NATSWriter) handshake(user *url.Userinfo) error {
+	nw.conn.SetReadDeadline(time.Now().Add(natsAckTimeout))
+	line, err := nw.in.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+		Headers     bool `json:"headers"`
+	}
+	infoJSON, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok || json.Unmarshal([]byte(infoJSON), &info) != nil {
+		return fmt.Errorf("unexpected greeting %q", strings.TrimSpace(line))
+	}
+	if info.TLSRequired {
+		return errors.New("the server requires TLS, which is not supported")
+	}
+	nw.headers = info.Headers
+
+	// Headers let the server report a subject no stream listens on
+	// instead of leaving the publish unanswered
+	connect := map[string]any{"verbose": false, "pedantic": false, "headers": info.Headers, "no_responders": info.Headers}
+	if user != nil {
+		if password, ok := user.Password(); ok {
+			connect["user"] = user.Username()
+			connect["pass"] = password
+		} else {
+			connect["auth_token"] = user.Username()
+		}
+	}
+	connectJSON, _ := json.Marshal(connect)
+	fmt.Fprintf(nw.out, "CONNECT %s\r\nSUB %s.* 1\r\nPING\r\n", connectJSON, nw.inbox)
+	if err := nw.out.Flush(); err != nil {
+		return err
+	}
+	for {
+		line, err := nw.in.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.TrimSpace(line[4:]))
+		}
+	}
+}
+
+// Write queues every complete row in p, publishing a message whenever one
+// holds enough rows
+func (nw *NATSWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			nw.message = append(nw.message, p...)
+			break
+		}
+		nw.message = append(nw.message, p[:i+1]...)
+		p = p[i+1:]
+		nw.pending++
+		if nw.pending == nw.rows {
+			if err := nw.publish(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush publishes the queued rows and waits until JetStream has
+// acknowledged every message
+func (nw *NATSWriter) Flush() error {
+	if nw.pending > 0 {
+		if err := nw.publish(); err != nil {
+			return err
+		}
+	}
+	if err := nw.out.Flush(); err != nil {
+		return err
+	}
+	for nw.inFlight > 0 {
+		if err := nw.readAck(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection without flushing
+func (nw *NATSWriter) Close() error {
+	return nw.conn.Close()
+}
+
+// Resume numbers the next message's ID after rows already published, for
+// RetryWriter replays over a new connection
+func (nw *NATSWriter) Resume(rows int64) {
+	nw.row = rows
+}
+
+// publish sends the complete rows of the message being built, waiting for
+// acks once the pipeline is full
+func (nw *NATSWriter) publish() error {
+	// A row without its newline yet stays for the next message
+	end := bytes.LastIndexByte(nw.message, '\n') + 1
+	if nw.msgID != "" && nw.headers {
+		header := fmt.Sprintf("NATS/1.0\r\nNats-Msg-Id: %s-%d\r\n\r\n", nw.msgID, nw.row)
+		fmt.Fprintf(nw.out, "HPUB %s %s.%d %d %d\r\n%s", nw.subject, nw.inbox, nw.sent, len(header), len(header)+end, header)
+	} else {
+		fmt.Fprintf(nw.out, "PUB %s %s.%d %d\r\n", nw.subject, nw.inbox, nw.sent, end)
+	}
+	nw.out.Write(nw.message[:end])
+	nw.out.WriteString("\r\n")
+	nw.message = append(nw.message[:0], nw.message[end:]...)
+	nw.row += int64(nw.pending)
+	nw.pending = 0
+	nw.sent++
+	nw.inFlight++
+	if nw.inFlight < natsPipeline {
+		return nil
+	}
+	return nw.Flush()
+}
+
+// Check verifies that a JetStream stream captures the subject, so a run
+// fails before generating anything rather than at its first publish
+func (nw *NATSWriter) Check() error {
+	request, _ := json.Marshal(map[string]string{"subject": nw.subject})
+	fmt.Fprintf(nw.out, "PUB $JS.API.STREAM.NAMES %s.check %d\r\n%s\r\n", nw.inbox, len(request), request)
+	if err := nw.out.Flush(); err != nil {
+		return err
+	}
+	var reply struct {
+		Streams []string      `json:"streams"`
+		Error   *natsAPIError `json:"error"`
+	}
+	if err := nw.readReply(&reply); err != nil {
+		if errors.Is(err, errNoResponders) {
+			return errors.New("JetStream is not enabled on the server")
+		}
+		return err
+	}
+	if reply.Error != nil {
+		return reply.Error
+	}
+	if len(reply.Streams) == 0 {
+		return fmt.Errorf("no JetStream stream captures subject %s", nw.subject)
+	}
+	return nil
+}
+
+// readAck reads the ack of the oldest message in flight
+func (nw *NATSWriter) readAck() error {
+	var ack natsAck
+	err := nw.readReply(&ack)
+	nw.inFlight--
+	if errors.Is(err, errNoResponders) {
+		return fmt.Errorf("publish to %s got no ack; is there a JetStream stream for the subject?", nw.subject)
+	}
+	if err != nil {
+		return err
+	}
+	if ack.Error != nil {
+		return ack.Error
+	}
+	return nil
+}
+
+// readReply reads protocol messages until a reply arrives on the inbox,
+// answering the server's keepalive pings on the way, and decodes its JSON
+// payload into v
+func (nw *NATSWriter) readReply(v any) error {
+	nw.conn.SetReadDeadline(time.Now().Add(natsAckTimeout))
+	for {
+		line, err := nw.in.ReadString('\n')
+		if err != nil {
+			var timeout net.Error
+			if errors.As(err, &timeout) && timeout.Timeout() {
+				return fmt.Errorf("no reply from JetStream within %s", natsAckTimeout)
+			}
+			return err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			nw.out.WriteString("PONG\r\n")
+			if err := nw.out.Flush(); err != nil {
+				return err
+			}
+		case "-ERR":
+			return fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-ERR")))
+		case "MSG", "HMSG":
+			// MSG <subject> <sid> <size>, HMSG <subject> <sid> <header size> <total size>
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			if err != nil {
+				return fmt.Errorf("malformed message %q", strings.TrimSpace(line))
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(nw.in, payload); err != nil {
+				return err
+			}
+			if fields[0] == "HMSG" {
+				headerSize, _ := strconv.Atoi(fields[len(fields)-2])
+				switch status := natsStatus(payload[:headerSize]); status {
+				case "":
+				case "503":
+					return errNoResponders
+				default:
+					return fmt.Errorf("request failed with status %s", status)
+				}
+				payload = payload[headerSize:]
+			}
+			if err := json.Unmarshal(payload[:len(payload)-2], v); err != nil {
+				return fmt.Errorf("malformed reply %q", payload[:len(payload)-2])
+			}
+			return nil
+		}
+	}
+}
+
+// natsStatus returns the status code of a message's headers, such as 503
+// for a subject nothing listens on, or "" when there is none
+func natsStatus(header []byte) string {
+	firstLine, _, _ := bytes.Cut(header, []byte("\r\n"))
+	fields := strings.Fields(string(firstLine))
+	if len(fields) >= 2 {
+		return fields[1]
+	}
+	return ""
+}
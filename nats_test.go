@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeNATS accepts one connection and acknowledges publishes the way
+// JetStream would, collecting the published payloads. reply picks the ack
+// for each message.
+func fakeNATS(t *testing.T, reply func(seq int) string) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		var payloads []string
+		defer func() { received <- payloads }()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"headers\":true}\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch fields[0] {
+			case "PING":
+				fmt.Fprintf(conn, "PONG\r\n")
+			case "PUB", "HPUB":
+				// Headers are kept in front of the payload
+				size, _ := strconv.Atoi(fields[len(fields)-1])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				payloads = append(payloads, string(payload[:size]))
+				ack := reply(len(payloads))
+				if strings.HasPrefix(ack, "NATS/1.0") {
+					fmt.Fprintf(conn, "HMSG %s 1 %d %d\r\n%s\r\n", fields[2], len(ack), len(ack), ack)
+				} else {
+					fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[2], len(ack), ack)
+				}
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestNATSWriter(t *testing.T) {
+	addr, received := fakeNATS(t, func(seq int) string {
+		return fmt.Sprintf(`{"stream":"CORPUS","seq":%d}`, seq)
+	})
+	nw, err := DialNATS("nats://"+addr, "corpus.ethereum", 100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all strings.Builder
+	for i := 0; i < 10000; i++ {
+		all.WriteString("row" + strconv.Itoa(i) + "\n")
+	}
+	data := all.String()
+	for len(data) > 0 {
+		n := min(len(data), 333)
+		if _, err := nw.Write([]byte(data[:n])); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := nw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	nw.Close()
+
+	payloads := <-received
+	if len(payloads) != 100 {
+		t.Fatalf("Expected 100 messages of 100 rows, got %d", len(payloads))
+	}
+	if got := strings.Join(payloads, ""); got != all.String() {
+		t.Errorf("Published rows differ from the written ones")
+	}
+
+	// Message IDs count the rows before each message, from where a replay resumes
+	addr, received = fakeNATS(t, func(seq int) string {
+		return fmt.Sprintf(`{"stream":"CORPUS","seq":%d}`, seq)
+	})
+	nw, err = DialNATS("nats://"+addr, "corpus.ethereum", 10, "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nw.Resume(20)
+	nw.Write([]byte(strings.Repeat("row\n", 25)))
+	if err := nw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	nw.Close()
+	payloads = <-received
+	for i, row := range []int{20, 30, 40} {
+		header := fmt.Sprintf("NATS/1.0\r\nNats-Msg-Id: run-%d\r\n\r\n", row)
+		if i >= len(payloads) || !strings.HasPrefix(payloads[i], header) {
+			t.Errorf("Expected message %d to start with %q, got %q", i, header, payloads)
+		}
+	}
+}
+
+func TestNATSWriterErrors(t *testing.T) {
+	acks := map[string]string{
+		"no stream":   "NATS/1.0 503\r\n\r\n",
+		"stream full": `{"error":{"code":503,"err_code":10077,"description":"maximum messages exceeded"}}`,
+	}
+	for name, ack := range acks {
+		addr, received := fakeNATS(t, func(int) string { return ack })
+		nw, err := DialNATS("nats://"+addr, "corpus", 10, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		nw.Write([]byte("a\nb\n"))
+		if err := nw.Flush(); err == nil {
+			t.Errorf("%s: expected the flush to fail", name)
+		}
+		nw.Close()
+		<-received
+	}
+
+	if _, err := DialNATS("redis://localhost", "corpus", 10, ""); err == nil {
+		t.Error("Expected an error for a non-NATS URL")
+	}
+	if _, err := DialNATS("nats://localhost", "corpus.>", 10, ""); err == nil {
+		t.Error("Expected an error for a wildcard subject")
+	}
+}
+
+func TestNATSWriterCheck(t *testing.T) {
+	replies := map[string]bool{
+		`{"total":1,"streams":["CORPUS"]}`:                         true,
+		`{"total":0,"streams":null}`:                               false,
+		"NATS/1.0 503\r\n\r\n":                                     false, // JetStream is disabled
+		`{"error":{"code":403,"description":"permission denied"}}`: false,
+	}
+	for reply, ok := range replies {
+		addr, received := fakeNATS(t, func(int) string { return reply })
+		nw, err := DialNATS("nats://"+addr, "corpus", 10, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := nw.Check(); (err == nil) != ok {
+			t.Errorf("%q: expected ok %v, got %v", reply, ok, err)
+		}
+		nw.Close()
+		if payloads := <-received; len(payloads) != 1 || payloads[0] != `{"subject":"corpus"}` {
+			t.Errorf("Unexpected stream lookup %q", payloads)
+		}
+	}
+}
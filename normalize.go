@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Case modes for the normalize subcommand
+const (
+	caseCanonical = "canonical"
+	caseLower     = "lower"
+)
+
+// uriSchemes are the payment URI prefixes stripped from addresses
+var uriSchemes = []string{"ethereum:", "bitcoin:", "solana:", "ton://transfer/"}
+
+// normalizeAddress cleans up one raw input line: it trims whitespace and
+// byte order marks, strips payment URI prefixes and parameters, and applies
+// the case mode. It returns an empty string for lines without an address.
+func normalizeAddress(network, line, caseMode string) string {
+	addr := strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+
+	// ethereum:0xabc@1/transfer?value=1, bitcoin:1abc?amount=1, ton://transfer/UQ...?amount=1
+	for _, scheme := range uriSchemes {
+		if len(addr) >= len(scheme) && strings.EqualFold(addr[:len(scheme)], scheme) {
+			addr = addr[len(scheme):]
+			if i := strings.IndexAny(addr, "?@/"); i >= 0 {
+				addr = addr[:i]
+			}
+			break
+		}
+	}
+
+	if caseMode == caseLower && network == "ethereum" {
+		return strings.ToLower(addr)
+	}
+	return fixAddress(network, addr)
+}
+
+// NormalizeStats counts the outcome of normalizing an address list
+type NormalizeStats struct {
+	read       int
+	written    int
+	duplicates int
+	runs       int // Sorted runs spilled to disk
+}
+
+// normalizeList normalizes every line of in and writes the distinct
+// addresses to out in sorted order, sorting through sorter so that lists
+// larger than memory spill to disk
+func normalizeList(network string, in io.Reader, out io.Writer, caseMode string, sorter *ExternalSorter) (NormalizeStats, error) {
+	var stats NormalizeStats
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		addr := normalizeAddress(network, scanner.Text(), caseMode)
+		if addr == "" {
+			continue
+		}
+		stats.read++
+		if err := sorter.Add(addr); err != nil {
+			return stats, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+
+	// Sorting brings duplicates next to each other
+	previous := ""
+	err := sorter.Merge(func(addr string) error {
+		if stats.written > 0 && addr == previous {
+			stats.duplicates++
+			return nil
+		}
+		previous = addr
+		stats.written++
+		_, err := fmt.Fprintln(out, addr)
+		return err
+	})
+	stats.runs = sorter.Runs()
+	return stats, err
+}
+
+// runNormalize implements the normalize subcommand and returns the exit code
+func runNormalize(args []string) int {
+	fs := flag.NewFlagSet("normalize", flag.ExitOnError)
+	network := fs.String("network", "", "Blockchain network of the addresses (ethereum, bitcoin, solana, ton)")
+	output := fs.String("output", "", "Output file path (default: stdout)")
+	caseMode := fs.String("case", caseCanonical, "Case normalization: canonical (EIP-55, lowercase bech32) or lower (Ethereum only)")
+	memoryMB := fs.Int("memory-mb", 1024, "Memory in MiB for sorting before spilling sorted runs to disk")
+	tempDir := fs.String("temp-dir", os.TempDir(), "Directory for sorted runs spilled to disk")
+	encryptTemp := fs.Bool("encrypt-temp", false, "Encrypt the sorted runs spilled to disk with a key held only in memory")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint normalize --network NETWORK [--case canonical|lower] [--memory-mb N] [--temp-dir DIR] [--encrypt-temp] [--output FILE] [FILE]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if *network != "ethereum" && *network != "bitcoin" && *network != "solana" && *network != "ton" {
+		fatalf("Network must be ethereum, bitcoin, solana, or ton")
+	}
+	if *caseMode != caseCanonical && *caseMode != caseLower {
+		fatalf("Case must be canonical or lower")
+	}
+	if *memoryMB < 1 {
+		fatalf("Memory limit must be at least 1 MiB")
+	}
+	if fs.NArg() > 1 {
+		fatalf("normalize takes at most one input file")
+	}
+
+	// Read from the named file, or stdin when none is given
+	in := os.Stdin
+	if fs.NArg() == 1 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fatalf("Failed to open input file: %v", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	sorter := NewExternalSorter(*tempDir, int64(*memoryMB)<<20)
+	if *encryptTemp {
+		cipher, err := NewTempCipher()
+		if err != nil {
+			fatalf("Failed to create the temp file key: %v", err)
+		}
+		sorter.SetCipher(cipher)
+	}
+	writer := bufio.NewWriterSize(out, 64*1024)
+	stats, err := normalizeList(*network, in, writer, *caseMode, sorter)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := sorter.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fatalf("Failed to normalize addresses: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Normalized %s addresses: %s written, %s duplicates removed\n",
+		formatCount(stats.read), formatCount(stats.written), formatCount(stats.duplicates))
+	if stats.runs > 0 {
+		fmt.Fprintf(os.Stderr, "Merged %d sorted runs spilled to %s\n", stats.runs, *tempDir)
+	}
+	return 0
+}
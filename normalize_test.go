@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	const eth = "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"
+	tests := []struct {
+		network  string
+		line     string
+		caseMode string
+		expected string
+	}{
+		{"ethereum", "  0x0d747f8adfde4bef87cf21fea682083c7149268f\t", caseCanonical, eth},
+		{"ethereum", "\ufeff" + eth, caseCanonical, eth},
+		{"ethereum", "ethereum:" + eth + "@1/transfer?value=1", caseCanonical, eth},
+		{"ethereum", "ETHEREUM:" + eth, caseLower, strings.ToLower(eth)},
+		{"bitcoin", "bitcoin:BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4?amount=0.1", caseCanonical, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{"bitcoin", "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT", caseLower, "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT"},
+		{"solana", "solana:BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj?amount=1", caseCanonical, "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj"},
+		{"ton", "ton://transfer/UQCuIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8d?amount=1", caseCanonical, "UQCuIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8d"},
+		{"ethereum", "   ", caseCanonical, ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeAddress(tt.network, tt.line, tt.caseMode); got != tt.expected {
+			t.Errorf("normalizeAddress(%s, %q, %s) = %q, expected %q", tt.network, tt.line, tt.caseMode, got, tt.expected)
+		}
+	}
+}
+
+func TestNormalizeList(t *testing.T) {
+	input := strings.Join([]string{
+		"0xB53fCB3aeAe3851799b4eC244D6C1E9d80dca902",
+		"ethereum:0x0d747f8adfde4bef87cf21fea682083c7149268f",
+		"",
+		"0x0d747F8AdFdE4beF87CF21FEa682083C7149268f",
+		"0xb53fcb3aeae3851799b4ec244d6c1e9d80dca902",
+	}, "\n")
+
+	var out strings.Builder
+	stats, err := normalizeList("ethereum", strings.NewReader(input), &out, caseCanonical, NewExternalSorter(t.TempDir(), 1<<20))
+	if err != nil {
+		t.Fatalf("normalizeList failed: %v", err)
+	}
+
+	expected := "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f\n0xB53fCB3aeAe3851799b4eC244D6C1E9d80dca902\n"
+	if out.String() != expected {
+		t.Errorf("Expected output %q, got %q", expected, out.String())
+	}
+	if stats != (NormalizeStats{read: 4, written: 2, duplicates: 2}) {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Supported number formats for progress and summary output
+const (
+	numberFormatGrouped = "grouped" // Thousands separators, e.g. 1,250,000
+	numberFormatSI      = "si"      // SI units, e.g. 1.25M
+	numberFormatRaw     = "raw"     // Plain integers, e.g. 1250000
+)
+
+// numberFormat and groupSeparator control how counts and rates are displayed
+var (
+	numberFormat   = numberFormatGrouped
+	groupSeparator = ","
+)
+
+// Thousands separators for locales that don't use a comma
+var localeSeparators = map[string]string{
+	"de": ".", "es": ".", "it": ".", "nl": ".", "pt": ".", "da": ".", "id": ".", "tr": ".",
+	"fr": " ", "ru": " ", "sv": " ", "nb": " ", "fi": " ", "pl": " ", "cs": " ", "uk": " ",
+	"de_CH": "'",
+}
+
+// setupNumberFormat selects the display format, taking the thousands
+// separator from the locale environment
+func setupNumberFormat(format string) error {
+	switch format {
+	case numberFormatGrouped, numberFormatSI, numberFormatRaw:
+		numberFormat = format
+	default:
+		return fmt.Errorf("number format must be grouped, si, or raw")
+	}
+	groupSeparator = localeGroupSeparator()
+	return nil
+}
+
+// localeGroupSeparator returns the thousands separator for the locale in
+// LC_ALL, LC_NUMERIC or LANG, in that order of precedence
+func localeGroupSeparator() string {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		// Strip the encoding and modifier, e.g. de_DE.UTF-8@euro
+		if i := strings.IndexAny(locale, ".@"); i >= 0 {
+			locale = locale[:i]
+		}
+		if sep, ok := localeSeparators[locale]; ok {
+			return sep
+		}
+		language, _, _ := strings.Cut(locale, "_")
+		if sep, ok := localeSeparators[language]; ok {
+			return sep
+		}
+		return ","
+	}
+	return ","
+}
+
+// formatCount formats a count for display
+func formatCount(n int) string {
+	switch numberFormat {
+	case numberFormatSI:
+		return formatSI(float64(n), 0)
+	case numberFormatRaw:
+		return strconv.Itoa(n)
+	}
+	return groupDigits(strconv.Itoa(n))
+}
+
+// formatRate formats a per-second rate for display
+func formatRate(rate float64) string {
+	switch numberFormat {
+	case numberFormatSI:
+		return formatSI(rate, 2)
+	case numberFormatRaw:
+		return strconv.FormatFloat(rate, 'f', 2, 64)
+	}
+	whole, fraction, _ := strings.Cut(strconv.FormatFloat(rate, 'f', 2, 64), ".")
+	decimal := "."
+	if groupSeparator == "." {
+		decimal = ","
+	}
+	return groupDigits(whole) + decimal + fraction
+}
+
+// formatSI formats v with an SI suffix, using decimals places below 1000
+func formatSI(v float64, decimals int) string {
+	units := []string{"", "K", "M", "G", "T", "P"}
+	unit := 0
+	for math.Abs(v) >= 1000 && unit < len(units)-1 {
+		v /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatFloat(v, 'f', decimals, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64) + units[unit]
+}
+
+// groupDigits inserts the thousands separator into a string of digits
+func groupDigits(digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > len(sign) {
+			b.WriteString(groupSeparator)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestFormatCount tests count formatting in every number format
+func TestFormatCount(t *testing.T) {
+	defer func() { numberFormat, groupSeparator = numberFormatGrouped, "," }()
+
+	tests := []struct {
+		format    string
+		separator string
+		n         int
+		expected  string
+	}{
+		{numberFormatGrouped, ",", 0, "0"},
+		{numberFormatGrouped, ",", 999, "999"},
+		{numberFormatGrouped, ",", 1000, "1,000"},
+		{numberFormatGrouped, ",", 1234567890, "1,234,567,890"},
+		{numberFormatGrouped, ".", 1250000, "1.250.000"},
+		{numberFormatGrouped, ",", -12345, "-12,345"},
+		{numberFormatSI, ",", 999, "999"},
+		{numberFormatSI, ",", 1250000, "1.25M"},
+		{numberFormatSI, ",", 3000000000, "3.00G"},
+		{numberFormatRaw, ",", 1250000, "1250000"},
+	}
+
+	for _, tt := range tests {
+		numberFormat, groupSeparator = tt.format, tt.separator
+		if got := formatCount(tt.n); got != tt.expected {
+			t.Errorf("formatCount(%d) in %s format = %q, expected %q", tt.n, tt.format, got, tt.expected)
+		}
+	}
+}
+
+// TestFormatRate tests rate formatting with locale decimal separators
+func TestFormatRate(t *testing.T) {
+	defer func() { numberFormat, groupSeparator = numberFormatGrouped, "," }()
+
+	numberFormat, groupSeparator = numberFormatGrouped, ","
+	if got := formatRate(1234567.891); got != "1,234,567.89" {
+		t.Errorf("Expected 1,234,567.89, got %s", got)
+	}
+
+	groupSeparator = "."
+	if got := formatRate(1234567.891); got != "1.234.567,89" {
+		t.Errorf("Expected 1.234.567,89, got %s", got)
+	}
+
+	numberFormat = numberFormatSI
+	if got := formatRate(1250000); got != "1.25M" {
+		t.Errorf("Expected 1.25M, got %s", got)
+	}
+}
+
+// TestLocaleGroupSeparator tests thousands separator detection from the environment
+func TestLocaleGroupSeparator(t *testing.T) {
+	tests := map[string]string{
+		"":            ",",
+		"C":           ",",
+		"en_US.UTF-8": ",",
+		"de_DE.UTF-8": ".",
+		"de_CH.UTF-8": "'",
+		"fr_FR@euro":  " ",
+	}
+
+	for locale, expected := range tests {
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_NUMERIC", "")
+		t.Setenv("LANG", locale)
+		if got := localeGroupSeparator(); got != expected {
+			t.Errorf("Locale %q: expected separator %q, got %q", locale, expected, got)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Parquet output for --format parquet, and its page codecs for
+// --parquet-codec
+const (
+	outputFormatParquet = "parquet" // Parquet file of required columns
+
+	parquetCodecNone   = "none"
+	parquetCodecSnappy = "snappy"
+	parquetCodecGzip   = "gzip"
+)
+
+// defaultParquetRowGroup is the default number of rows per row group
+const defaultParquetRowGroup = 1000000
+
+// parquetPageBytes is the encoded size at which a column's data page is
+// compressed and closed
+const parquetPageBytes = 1 << 20
+
+// Parquet enum values written by ParquetWriter
+const (
+	parquetMagic         = "PAR1"
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+	parquetRequired      = 0
+	parquetConvertedUTF8 = 0
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+	parquetPageTypeData  = 0
+	parquetCodecIDNone   = 0
+	parquetCodecIDSnappy = 1
+	parquetCodecIDGzip   = 2
+	parquetFormatVersion = 1
+)
+
+// parquetColumn buffers the pages of one column of the current row group
+type parquetColumn struct {
+	outputColumn
+	index  bool   // The index column, written as INT64 instead of a UTF-8 BYTE_ARRAY
+	page   []byte // PLAIN values of the open page
+	values int    // Values in the open page
+	chunk  []byte // Closed pages of the row group, each after its header
+
+	chunkValues       int64
+	chunkUncompressed int64 // Size of the chunk's pages before compression, with headers
+}
+
+// parquetChunk is the metadata of a column chunk that has been written
+type parquetChunk struct {
+	offset       int64
+	values       int64
+	compressed   int64
+	uncompressed int64
+}
+
+// parquetRowGroup is the metadata of a row group that has been written
+type parquetRowGroup struct {
+	rows   int64
+	chunks []parquetChunk
+}
+
+// ParquetWriter writes records as a Parquet file, for loading into Spark
+// and other data-lake engines without a conversion step, with a required
+// column per output column. The index is written as an INT64 and every
+// other column as a UTF-8 string. Rows are buffered until rowGroup rows
+// make a row group, and Close writes the last row group and the footer.
+type ParquetWriter struct {
+	out      io.Writer
+	layout   *rowLayout
+	codec    string
+	rowGroup int
+	columns  []*parquetColumn
+
+	value   []byte // Reused buffer for the value of one column
+	rows    int    // Rows in the current row group
+	offset  int64  // Bytes written to out
+	groups  []parquetRowGroup
+	scratch []byte // Compression output, reused across pages
+	gzip    *gzip.Writer
+}
+
+// NewParquetWriter writes the magic to out and returns a writer for the
+// records that follow it
+func NewParquetWriter(out io.Writer, layout *rowLayout, columns []outputColumn, codec string, rowGroup int) (*ParquetWriter, error) {
+	if codec != parquetCodecNone && codec != parquetCodecSnappy && codec != parquetCodecGzip {
+		return nil, fmt.Errorf("unknown Parquet codec %q", codec)
+	}
+	if rowGroup < 1 {
+		return nil, fmt.Errorf("row groups need at least one row")
+	}
+	pw := &ParquetWriter{out: out, layout: layout, codec: codec, rowGroup: rowGroup}
+	for _, c := range columns {
+		pw.columns = append(pw.columns, &parquetColumn{outputColumn: c, index: c.kind == columnIndex})
+	}
+	if codec == parquetCodecGzip {
+		pw.gzip = gzip.NewWriter(nil)
+	}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// write writes p to the output, counting the offset the footer refers to
+func (pw *ParquetWriter) write(p []byte) error {
+	n, err := pw.out.Write(p)
+	pw.offset += int64(n)
+	return err
+}
+
+// WriteRecord adds one record's values to the open pages, writing the row
+// group once it has rowGroup rows
+func (pw *ParquetWriter) WriteRecord(record Record) error {
+	for _, c := range pw.columns {
+		if c.index {
+			c.page = binary.LittleEndian.AppendUint64(c.page, uint64(pw.layout.index(&record)))
+		} else {
+			var err error
+			if pw.value, err = pw.layout.appendValue(pw.value[:0], c.outputColumn, &record); err != nil {
+				return err
+			}
+			c.page = binary.LittleEndian.AppendUint32(c.page, uint32(len(pw.value)))
+			c.page = append(c.page, pw.value...)
+		}
+		c.values++
+		if len(c.page) >= parquetPageBytes {
+			if err := pw.closePage(c); err != nil {
+				return err
+			}
+		}
+	}
+	pw.rows++
+	if pw.rows == pw.rowGroup {
+		return pw.writeRowGroup()
+	}
+	return nil
+}
+
+// Flush does nothing: rows can only be written out as whole row groups
+func (pw *ParquetWriter) Flush() error {
+	return nil
+}
+
+// closePage compresses the open page of c and appends it to the chunk
+// after its header
+func (pw *ParquetWriter) closePage(c *parquetColumn) error {
+	if c.values == 0 {
+		return nil
+	}
+	data := c.page
+	switch pw.codec {
+	case parquetCodecSnappy:
+		data = snappyEncode(pw.scratch[:0], c.page)
+		pw.scratch = data[:0]
+	case parquetCodecGzip:
+		buf := bytes.NewBuffer(pw.scratch[:0])
+		pw.gzip.Reset(buf)
+		pw.gzip.Write(c.page)
+		if err := pw.gzip.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+		pw.scratch = data[:0]
+	}
+
+	// Required columns have no repetition or definition levels, so the
+	// page holds only the values
+	var header thriftCompact
+	header.i32(1, parquetPageTypeData)
+	header.i32(2, int32(len(c.page)))
+	header.i32(3, int32(len(data)))
+	header.begin(5)
+	header.i32(1, int32(c.values))
+	header.i32(2, parquetEncodingPlain)
+	header.i32(3, parquetEncodingRLE)
+	header.i32(4, parquetEncodingRLE)
+	header.end()
+	header.stop()
+
+	c.chunk = append(c.chunk, header.b...)
+	c.chunk = append(c.chunk, data...)
+	c.chunkValues += int64(c.values)
+	c.chunkUncompressed += int64(len(header.b) + len(c.page))
+	c.page = c.page[:0]
+	c.values = 0
+	return nil
+}
+
+// writeRowGroup writes the buffered rows as a row group, one column chunk
+// after another
+func (pw *ParquetWriter) writeRowGroup() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	group := parquetRowGroup{rows: int64(pw.rows)}
+	for _, c := range pw.columns {
+		if err := pw.closePage(c); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, parquetChunk{
+			offset:       pw.offset,
+			values:       c.chunkValues,
+			compressed:   int64(len(c.chunk)),
+			uncompressed: c.chunkUncompressed,
+		})
+		if err := pw.write(c.chunk); err != nil {
+			return err
+		}
+		c.chunk = c.chunk[:0]
+		c.chunkValues, c.chunkUncompressed = 0, 0
+	}
+	pw.groups = append(pw.groups, group)
+	pw.rows = 0
+	return nil
+}
+
+// Close writes the last row group and the footer: the file metadata, its
+// length and the magic
+func (pw *ParquetWriter) Close() error {
+	if err := pw.writeRowGroup(); err != nil {
+		return err
+	}
+	codec := map[string]int32{parquetCodecNone: parquetCodecIDNone, parquetCodecSnappy: parquetCodecIDSnappy, parquetCodecGzip: parquetCodecIDGzip}[pw.codec]
+
+	var meta thriftCompact
+	meta.i32(1, parquetFormatVersion)
+	// The schema is the root and its columns, flattened
+	meta.list(2, thriftStruct, len(pw.columns)+1)
+	meta.push()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(pw.columns)))
+	meta.end()
+	for _, c := range pw.columns {
+		meta.push()
+		if c.index {
+			meta.i32(1, parquetTypeInt64)
+			meta.i32(3, parquetRequired)
+			meta.binary(4, c.name)
+		} else {
+			meta.i32(1, parquetTypeByteArray)
+			meta.i32(3, parquetRequired)
+			meta.binary(4, c.name)
+			meta.i32(6, parquetConvertedUTF8)
+			meta.begin(10) // LogicalType STRING
+			meta.begin(1)
+			meta.end()
+			meta.end()
+		}
+		meta.end()
+	}
+	var rows int64
+	for _, group := range pw.groups {
+		rows += group.rows
+	}
+	meta.i64(3, rows)
+	meta.list(4, thriftStruct, len(pw.groups))
+	for _, group := range pw.groups {
+		meta.push()
+		meta.list(1, thriftStruct, len(group.chunks))
+		var uncompressed, compressed int64
+		for i, chunk := range group.chunks {
+			c := pw.columns[i]
+			meta.push()
+			meta.i64(2, chunk.offset)
+			meta.begin(3)
+			if c.index {
+				meta.i32(1, parquetTypeInt64)
+			} else {
+				meta.i32(1, parquetTypeByteArray)
+			}
+			meta.list(2, thriftI32, 2)
+			meta.listI32(parquetEncodingPlain)
+			meta.listI32(parquetEncodingRLE)
+			meta.list(3, thriftBinary, 1)
+			meta.listBinary(c.name)
+			meta.i32(4, codec)
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.uncompressed)
+			meta.i64(7, chunk.compressed)
+			meta.i64(9, chunk.offset)
+			meta.end()
+			meta.end()
+			uncompressed += chunk.uncompressed
+			compressed += chunk.compressed
+		}
+		meta.i64(2, uncompressed)
+		meta.i64(3, group.rows)
+		meta.i64(5, group.chunks[0].offset)
+		meta.i64(6, compressed)
+		meta.end()
+	}
+	meta.binary(6, "addrmint version "+version)
+	meta.stop()
+
+	footer := binary.LittleEndian.AppendUint32(meta.b, uint32(len(meta.b)))
+	return pw.write(append(footer, parquetMagic...))
+}
+
+// Thrift compact protocol types used by Parquet metadata
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftCompact encodes a Thrift struct in the compact protocol, which
+// Parquet uses for page headers and the footer. Integers are zig-zag
+// varints, encoded as Avro encodes longs.
+type thriftCompact struct {
+	b     []byte
+	last  int16   // ID of the previous field of the current struct
+	stack []int16 // last of the enclosing structs
+}
+
+// field writes a field header, with the ID as a delta from the previous
+// field when it fits
+func (t *thriftCompact) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.b = append(t.b, byte(delta)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.b = appendAvroLong(t.b, int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftCompact) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.b = appendAvroLong(t.b, int64(v))
+}
+
+func (t *thriftCompact) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.b = appendAvroLong(t.b, v)
+}
+
+func (t *thriftCompact) binary(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.listBinary(v)
+}
+
+// begin starts a struct field; end finishes it
+func (t *thriftCompact) begin(id int16) {
+	t.field(id, thriftStruct)
+	t.push()
+}
+
+// push starts a struct that is a list element
+func (t *thriftCompact) push() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+// end finishes the innermost struct
+func (t *thriftCompact) end() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// stop ends the fields of a struct
+func (t *thriftCompact) stop() {
+	t.b = append(t.b, 0)
+}
+
+// list writes the header of a list field of n elements of type elem
+func (t *thriftCompact) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.b = append(t.b, byte(n)<<4|elem)
+	} else {
+		t.b = append(t.b, 0xf0|elem)
+		t.b = binary.AppendUvarint(t.b, uint64(n))
+	}
+}
+
+func (t *thriftCompact) listI32(v int32) {
+	t.b = appendAvroLong(t.b, int64(v))
+}
+
+func (t *thriftCompact) listBinary(v string) {
+	t.b = binary.AppendUvarint(t.b, uint64(len(v)))
+	t.b = append(t.b, v...)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// thriftReader decodes Thrift compact structs into maps of field IDs to
+// values, so the tests read Parquet metadata without trusting the writer
+type thriftReader struct {
+	data []byte
+	t    *testing.T
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.t.Fatalf("Malformed varint")
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 4, 5, 6:
+		v := r.varint()
+		return int64(v>>1) ^ -int64(v&1)
+	case thriftBinary:
+		n := r.varint()
+		v := string(r.data[:n])
+		r.data = r.data[n:]
+		return v
+	case thriftList:
+		header := r.data[0]
+		r.data = r.data[1:]
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	r.t.Fatalf("Unexpected Thrift type %d", typ)
+	return nil
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	fields := map[int16]any{}
+	var id int16
+	for {
+		header := r.data[0]
+		r.data = r.data[1:]
+		if header == 0 {
+			return fields
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.value(4).(int64))
+		}
+		fields[id] = r.value(header & 0x0f)
+	}
+}
+
+// readParquet decodes a file written by ParquetWriter into its schema
+// names and its rows, comma-separated as they were written
+func readParquet(t *testing.T, file []byte) ([]string, []string, int) {
+	if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+		t.Fatalf("Missing Parquet magic")
+	}
+	length := binary.LittleEndian.Uint32(file[len(file)-8:])
+	footer := &thriftReader{data: file[len(file)-8-int(length) : len(file)-8], t: t}
+	meta := footer.structure()
+	if len(footer.data) != 0 {
+		t.Fatalf("Footer has %d trailing bytes", len(footer.data))
+	}
+
+	var names []string
+	for _, element := range meta[2].([]any)[1:] {
+		names = append(names, element.(map[int16]any)[4].(string))
+	}
+	rows := make([]string, 0, meta[3].(int64))
+	groups := meta[4].([]any)
+	for _, g := range groups {
+		group := g.(map[int16]any)
+		columns := make([][]string, len(names))
+		for i, c := range group[1].([]any) {
+			chunk := c.(map[int16]any)[3].(map[int16]any)
+			codec, values := chunk[4].(int64), chunk[5].(int64)
+			pages := &thriftReader{data: file[chunk[9].(int64):], t: t}
+			for int64(len(columns[i])) < values {
+				header := pages.structure()
+				data := pages.data[:header[3].(int64)]
+				pages.data = pages.data[len(data):]
+				switch codec {
+				case parquetCodecIDSnappy:
+					data = snappyDecode(t, data)
+				case parquetCodecIDGzip:
+					gz, err := gzip.NewReader(bytes.NewReader(data))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if data, err = io.ReadAll(gz); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if int64(len(data)) != header[2].(int64) {
+					t.Fatalf("Page is %d bytes, header says %d", len(data), header[2])
+				}
+				for n := header[5].(map[int16]any)[1].(int64); n > 0; n-- {
+					if chunk[1].(int64) == parquetTypeInt64 {
+						columns[i] = append(columns[i], strconv.FormatInt(int64(binary.LittleEndian.Uint64(data)), 10))
+						data = data[8:]
+					} else {
+						size := binary.LittleEndian.Uint32(data)
+						columns[i] = append(columns[i], string(data[4:4+size]))
+						data = data[4+size:]
+					}
+				}
+			}
+		}
+		for r := int64(0); r < group[3].(int64); r++ {
+			var row []string
+			for i := range columns {
+				row = append(row, columns[i][r])
+			}
+			rows = append(rows, strings.Join(row, ","))
+		}
+	}
+	return names, rows, len(groups)
+}
+
+// parquetTestRecords returns n records with linked values that hold
+// commas, quotes and line breaks, and the rows readParquet decodes them to
+func parquetTestRecords(n int) ([]Record, []string) {
+	var records []Record
+	var rows []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("Yilmaz, \"Mateo\" %d\n", i)
+		address := fmt.Sprintf("0x%040x", i*i)
+		records = append(records, Record{index: i, network: "ethereum", address: address, linked: &LinkedColumns{columns: []string{name}}})
+		sum := sha256.Sum256([]byte(address))
+		rows = append(rows, fmt.Sprintf("%d,ethereum,%s,%x,%s", 1000+i, name, sum[:3], address))
+	}
+	return records, rows
+}
+
+func TestParquetWriter(t *testing.T) {
+	layout := &rowLayout{linked: []string{"entity_name"}, generateHash: true, offset: 1000}
+	columns := layout.structuredColumns()
+	records, rows := parquetTestRecords(5)
+	for _, codec := range []string{parquetCodecNone, parquetCodecSnappy, parquetCodecGzip} {
+		var out bytes.Buffer
+		pw, err := NewParquetWriter(&out, layout, columns, codec, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range records {
+			if err := pw.WriteRecord(records[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := pw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		names, decoded, groups := readParquet(t, out.Bytes())
+		if !slices.Equal(names, columnNames(columns)) || !slices.Equal(decoded, rows) || groups != 3 {
+			t.Errorf("%s: expected %q in 3 row groups, got %v %q in %d", codec, rows, names, decoded, groups)
+		}
+	}
+
+	// Large row groups are split into pages
+	var out bytes.Buffer
+	layout = &rowLayout{indices: make([]int, 30000)}
+	pw, _ := NewParquetWriter(&out, layout, layout.outputColumns(false), parquetCodecSnappy, defaultParquetRowGroup)
+	rows = rows[:0]
+	for i := range layout.indices {
+		layout.indices[i] = i
+		address := fmt.Sprintf("0x%040x", i)
+		rows = append(rows, fmt.Sprintf("%d,%s", i, address))
+		pw.WriteRecord(Record{index: i, address: address})
+	}
+	pw.Close()
+	if _, decoded, _ := readParquet(t, out.Bytes()); !slices.Equal(decoded, rows) {
+		t.Errorf("Expected %d rows across pages, got %d", len(rows), len(decoded))
+	}
+
+	if _, err := NewParquetWriter(&out, layout, nil, "lzo", 1); err == nil {
+		t.Error("Expected an error for an unknown codec")
+	}
+}
+
+// parquetGolden is a file written by ParquetWriter and checked by
+// testdata/verify_parquet.py with pyarrow, an independent reader. The
+// test keeps the writer's output identical to it; after a deliberate
+// change, regenerate it with -update-parquet-golden and run the script.
+const parquetGolden = "testdata/addresses.parquet"
+
+var updateParquetGolden = flag.Bool("update-parquet-golden", false, "Rewrite "+parquetGolden)
+
+func TestParquetGolden(t *testing.T) {
+	defer func(v string) { version = v }(version)
+	version = "dev"
+
+	layout := &rowLayout{linked: []string{"entity_name"}, generateHash: true, offset: 1000}
+	records, _ := parquetTestRecords(5)
+	var out bytes.Buffer
+	pw, err := NewParquetWriter(&out, layout, layout.structuredColumns(), parquetCodecSnappy, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range records {
+		pw.WriteRecord(records[i])
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if *updateParquetGolden {
+		if err := os.WriteFile(parquetGolden, out.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := os.ReadFile(parquetGolden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), golden) {
+		t.Errorf("Output differs from %s; if the change is intended, regenerate it and check it with testdata/verify_parquet.py", parquetGolden)
+	}
+}
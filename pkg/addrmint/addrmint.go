@@ -0,0 +1,115 @@
+// Package addrmint derives blockchain addresses from 32-byte seeds. The
+// same seed always gives the same address, so a corpus can be regenerated
+// from its base seed alone.
+//
+// Each network has a Generator. New picks one by network name and options,
+// SeedDeriver turns a base seed and an index into the seed of one address,
+// and Pipeline runs both on a pool of workers for a range of indices:
+//
+//	p := &addrmint.Pipeline{Network: addrmint.Ethereum, BaseSeed: "2a"}
+//	err := p.Run(ctx, 0, 1000, func(r addrmint.Result) error {
+//		fmt.Println(r.Index, r.Address)
+//		return r.Err
+//	})
+package addrmint
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Supported networks
+const (
+	Ethereum = "ethereum"
+	Bitcoin  = "bitcoin"
+	Solana   = "solana"
+	TON      = "ton"
+)
+
+// Networks lists the supported networks
+var Networks = []string{Ethereum, Bitcoin, Solana, TON}
+
+// Supported crypto backends
+const (
+	BackendSDK    = "sdk"    // Use the chain SDKs for key derivation
+	BackendNative = "native" // Derive keys directly, bypassing SDK account construction
+)
+
+// Supported hash backends
+const (
+	HashBackendGeth   = "geth"   // go-ethereum's Keccak-256, taking a state from a shared pool per address
+	HashBackendReused = "reused" // The same x/crypto/sha3 Keccak-256, one state per generator reused across addresses
+)
+
+// Address is an address in its network's usual text encoding
+type Address string
+
+// String returns the address text
+func (a Address) String() string {
+	return string(a)
+}
+
+// Generator derives the address of a 32-byte seed. Seeds of any other
+// length are rejected. Generators returned by New are not safe for
+// concurrent use; give each goroutine its own.
+type Generator interface {
+	Generate(seed []byte) (Address, error)
+}
+
+// Options selects the backends of a generator. The zero value selects the
+// SDK backends and legacy Bitcoin addresses.
+type Options struct {
+	Backend      string          // Solana key derivation, BackendSDK or BackendNative
+	HashBackend  string          // Ethereum Keccak-256, HashBackendGeth or HashBackendReused
+	BitcoinTypes *BitcoinTypeMix // Bitcoin address types to pick from, nil for legacy only
+	Chain        string          // Chain to encode addresses for, mainnet when ""; see CheckChain
+}
+
+// New returns the generator of a network
+func New(network string, opts Options) (Generator, error) {
+	switch opts.Backend {
+	case "", BackendSDK, BackendNative:
+	default:
+		return nil, fmt.Errorf("unsupported crypto backend: %s", opts.Backend)
+	}
+	switch opts.HashBackend {
+	case "", HashBackendGeth, HashBackendReused:
+	default:
+		return nil, fmt.Errorf("unsupported hash backend: %s", opts.HashBackend)
+	}
+
+	if opts.Chain != "" {
+		if err := CheckChain(network, opts.Chain); err != nil {
+			return nil, err
+		}
+	}
+
+	switch network {
+	case Ethereum:
+		if opts.HashBackend == HashBackendReused {
+			return NewEthereumReusedGenerator(), nil
+		}
+		return EthereumGenerator{}, nil
+	case Bitcoin:
+		return BitcoinGenerator{Types: opts.BitcoinTypes, Chain: opts.Chain}, nil
+	case Solana:
+		if opts.Backend == BackendNative {
+			return SolanaNativeGenerator{}, nil
+		}
+		return SolanaGenerator{}, nil
+	case TON:
+		return TONGenerator{Testnet: opts.Chain == ChainTestnet}, nil
+	}
+	return nil, fmt.Errorf("unsupported network: %s", network)
+}
+
+// ErrZeroPrivateKey is returned for seeds that reduce to the zero scalar
+var ErrZeroPrivateKey = errors.New("invalid seed: private key is zero")
+
+// checkSeedLength rejects seeds that are not exactly 32 bytes
+func checkSeedLength(seed []byte) error {
+	if len(seed) != 32 {
+		return fmt.Errorf("invalid seed: expected 32 bytes, got %d", len(seed))
+	}
+	return nil
+}
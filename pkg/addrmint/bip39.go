@@ -0,0 +1,106 @@
+package addrmint
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// bip39English is the BIP-39 English wordlist, one word per line in
+// alphabetical order
+//
+//go:embed bip39_english.txt
+var bip39English string
+
+var bip39Words = strings.Fields(bip39English)
+
+// mnemonicSaltPrefix is prepended to the passphrase to salt PBKDF2
+const mnemonicSaltPrefix = "mnemonic"
+
+// mnemonicIterations is the PBKDF2-HMAC-SHA512 iteration count of BIP-39
+const mnemonicIterations = 2048
+
+// NewMnemonic generates a random BIP-39 English mnemonic of 12, 15, 18,
+// 21 or 24 words
+func NewMnemonic(words int) (string, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", fmt.Errorf("a mnemonic has 12, 15, 18, 21 or 24 words, not %d", words)
+	}
+	entropy := make([]byte, words*4/3)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return mnemonicFromEntropy(entropy), nil
+}
+
+// mnemonicFromEntropy encodes entropy with its checksum, the first
+// len(entropy)/4 bits of its SHA-256, as 11-bit word indices
+func mnemonicFromEntropy(entropy []byte) string {
+	checksum := sha256.Sum256(entropy)
+	bits := new(big.Int).SetBytes(entropy)
+	checksumBits := uint(len(entropy) / 4)
+	bits.Lsh(bits, checksumBits)
+	bits.Or(bits, big.NewInt(int64(checksum[0]>>(8-checksumBits))))
+
+	words := make([]string, (len(entropy)*8+int(checksumBits))/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = bip39Words[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, 11)
+	}
+	return strings.Join(words, " ")
+}
+
+// ValidateMnemonic checks that every word of a BIP-39 English mnemonic is
+// in the wordlist and that its checksum matches
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return fmt.Errorf("a mnemonic has 12, 15, 18, 21 or 24 words, not %d", len(words))
+	}
+	bits := new(big.Int)
+	for i, word := range words {
+		index, found := slices.BinarySearch(bip39Words, word)
+		if !found {
+			return fmt.Errorf("word %d is not in the BIP-39 English wordlist", i+1)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(index)))
+	}
+
+	// The last len(words)/3 bits are the checksum of the entropy before them
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumBits-1)).Int64()
+	entropy := bits.Rsh(bits, checksumBits).FillBytes(make([]byte, len(words)*4/3))
+	sum := sha256.Sum256(entropy)
+	if int64(sum[0]>>(8-checksumBits)) != checksum {
+		return errors.New("mnemonic checksum does not match; a word is wrong or out of order")
+	}
+	return nil
+}
+
+// MnemonicToSeed validates a BIP-39 English mnemonic and derives its
+// 64-byte binary seed with PBKDF2-HMAC-SHA512, using "mnemonic" followed by
+// the passphrase as the salt. Words are separated by single spaces first,
+// so extra whitespace does not change the seed. Passphrases must be ASCII,
+// where the NFKD normalization BIP-39 calls for changes nothing.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	for _, r := range passphrase {
+		if r >= utf8.RuneSelf {
+			return nil, errors.New("mnemonic passphrases must be ASCII")
+		}
+	}
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key(sha512.New, normalized, []byte(mnemonicSaltPrefix+passphrase), mnemonicIterations, 64)
+}
@@ -0,0 +1,92 @@
+package addrmint
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// TestMnemonicToSeed checks the Trezor reference vectors, which all use the
+// passphrase "TREZOR"
+func TestMnemonicToSeed(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			"00000000000000000000000000000000",
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			"dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := mnemonicFromEntropy(decodeSeed(t, tt.entropy)); got != tt.mnemonic {
+			t.Errorf("entropy %s: expected %q, got %q", tt.entropy, tt.mnemonic, got)
+		}
+		seed, err := MnemonicToSeed(tt.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("%q: %v", tt.mnemonic, err)
+		}
+		if hex.EncodeToString(seed) != tt.seed {
+			t.Errorf("%q: expected seed %s, got %x", tt.mnemonic, tt.seed, seed)
+		}
+
+		// Extra whitespace between words must not change the seed
+		spaced, err := MnemonicToSeed("  "+strings.ReplaceAll(tt.mnemonic, " ", " \n\t")+"\n", "TREZOR")
+		if err != nil || hex.EncodeToString(spaced) != tt.seed {
+			t.Errorf("%q: whitespace changed the seed to %x (%v)", tt.mnemonic, spaced, err)
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, words := range []int{12, 15, 18, 21, 24} {
+		mnemonic, err := NewMnemonic(words)
+		if err != nil {
+			t.Fatalf("%d words: %v", words, err)
+		}
+		if n := len(strings.Fields(mnemonic)); n != words {
+			t.Errorf("expected %d words, got %d", words, n)
+		}
+		if err := ValidateMnemonic(mnemonic); err != nil {
+			t.Errorf("%d words: generated mnemonic is invalid: %v", words, err)
+		}
+	}
+
+	for _, words := range []int{0, 11, 13, 27} {
+		if _, err := NewMnemonic(words); err == nil {
+			t.Errorf("%d words: expected error", words)
+		}
+	}
+}
+
+func TestValidateMnemonicErrors(t *testing.T) {
+	tests := map[string]string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon":  "checksum",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandonn": "word 12",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about":            "11",
+		"": "0",
+	}
+	for mnemonic, want := range tests {
+		err := ValidateMnemonic(mnemonic)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", mnemonic, want, err)
+		}
+	}
+
+	if _, err := MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "pässword"); err == nil {
+		t.Error("expected error for a non-ASCII passphrase")
+	}
+}
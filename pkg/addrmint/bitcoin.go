@@ -0,0 +1,184 @@
+package addrmint
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Bitcoin address types
+const (
+	BitcoinLegacy     = "legacy"      // P2PKH, 1...
+	BitcoinP2SHSegwit = "p2sh-segwit" // P2WPKH nested in P2SH, 3...
+	BitcoinSegwit     = "segwit"      // Native P2WPKH, bc1q...
+	BitcoinTaproot    = "taproot"     // P2TR with a BIP-86 key-path-only output key, bc1p...
+)
+
+// BitcoinTypes lists the Bitcoin address types
+var BitcoinTypes = []string{BitcoinLegacy, BitcoinP2SHSegwit, BitcoinSegwit, BitcoinTaproot}
+
+// BitcoinGenerator derives Bitcoin addresses from compressed public keys.
+// Each address takes its type from Types, or is legacy when Types is nil,
+// and is encoded for Chain, or mainnet when Chain is "".
+type BitcoinGenerator struct {
+	Types *BitcoinTypeMix
+	Chain string
+}
+
+// Generate derives the address of seed
+func (g BitcoinGenerator) Generate(seed []byte) (Address, error) {
+	addressType := BitcoinLegacy
+	if g.Types != nil {
+		if err := checkSeedLength(seed); err != nil {
+			return "", err
+		}
+		addressType = g.Types.Pick((*[32]byte)(seed))
+	}
+	address, err := bitcoinAddress(seed, addressType, BitcoinParams(g.Chain))
+	return Address(address), err
+}
+
+// BitcoinTypeMix is the share of each address type in a run. Each address
+// gets its type from its own seed, so the mix is the same on every rerun
+// and however the indices are split across workers or processes.
+type BitcoinTypeMix struct {
+	types      []string
+	cumulative []float64 // Running share up to and including each type, ending at 1
+}
+
+// ParseBitcoinTypeMix parses "type=weight,..." such as
+// legacy=0.2,segwit=0.6,taproot=0.2. Weights are relative and need not sum to 1.
+func ParseBitcoinTypeMix(s string) (*BitcoinTypeMix, error) {
+	mix := &BitcoinTypeMix{}
+	var total float64
+	for _, field := range strings.Split(s, ",") {
+		name, weightText, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not of the form type=weight", field)
+		}
+		if !slices.Contains(BitcoinTypes, name) {
+			return nil, fmt.Errorf("unknown address type %q (want %s)", name, strings.Join(BitcoinTypes, ", "))
+		}
+		if slices.Contains(mix.types, name) {
+			return nil, fmt.Errorf("address type %q is listed twice", name)
+		}
+		weight, err := strconv.ParseFloat(weightText, 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s", weightText, name)
+		}
+		if weight == 0 {
+			continue
+		}
+		total += weight
+		mix.types = append(mix.types, name)
+		mix.cumulative = append(mix.cumulative, total)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%q gives no address type a positive weight", s)
+	}
+	for i := range mix.cumulative {
+		mix.cumulative[i] /= total
+	}
+	mix.cumulative[len(mix.cumulative)-1] = 1
+	return mix, nil
+}
+
+// SingleBitcoinType returns the mix that gives every address the same type
+func SingleBitcoinType(addressType string) (*BitcoinTypeMix, error) {
+	if !slices.Contains(BitcoinTypes, addressType) {
+		return nil, fmt.Errorf("unknown address type %q (want %s)", addressType, strings.Join(BitcoinTypes, ", "))
+	}
+	return &BitcoinTypeMix{types: []string{addressType}, cumulative: []float64{1}}, nil
+}
+
+// Types returns the address types with a positive weight
+func (m *BitcoinTypeMix) Types() []string {
+	return slices.Clone(m.types)
+}
+
+// Pick returns the address type of the address derived from seed
+func (m *BitcoinTypeMix) Pick(seed *[32]byte) string {
+	if len(m.types) == 1 {
+		return m.types[0]
+	}
+	sum := LinkedSeed(seed, "btc-type", 0)
+	// 53 random bits give a uniform float64 in [0, 1)
+	u := float64(binary.BigEndian.Uint64(sum[:])>>11) / (1 << 53)
+	for i, c := range m.cumulative {
+		if u < c {
+			return m.types[i]
+		}
+	}
+	return m.types[len(m.types)-1]
+}
+
+// BitcoinAddress derives the mainnet Bitcoin address of the given type
+// from seed
+func BitcoinAddress(seed []byte, addressType string) (string, error) {
+	return bitcoinAddress(seed, addressType, &chaincfg.MainNetParams)
+}
+
+// bitcoinAddress derives the address of the given type from seed for the
+// chain of params
+func bitcoinAddress(seed []byte, addressType string, params *chaincfg.Params) (string, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return "", err
+	}
+	privKey, pubKey := btcec.PrivKeyFromBytes(seed)
+	if privKey.Key.IsZero() {
+		return "", ErrZeroPrivateKey
+	}
+
+	var address btcutil.Address
+	var err error
+	switch addressType {
+	case BitcoinLegacy:
+		address, err = btcutil.NewAddressPubKey(pubKey.SerializeCompressed(), params)
+	case BitcoinSegwit, BitcoinP2SHSegwit:
+		address, err = btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), params)
+		if err == nil && addressType == BitcoinP2SHSegwit {
+			// The redeem script is the P2WPKH output script: OP_0 <20-byte key hash>
+			script := append([]byte{0x00, 0x14}, address.ScriptAddress()...)
+			address, err = btcutil.NewAddressScriptHash(script, params)
+		}
+	case BitcoinTaproot:
+		address, err = btcutil.NewAddressTaproot(taprootOutputKey(pubKey), params)
+	default:
+		return "", fmt.Errorf("unsupported Bitcoin address type: %s", addressType)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create address: %w", err)
+	}
+	return address.EncodeAddress(), nil
+}
+
+// taprootOutputKey tweaks an internal key per BIP-86, committing to no
+// script tree: Q = P + hash_TapTweak(P)·G with P taken with an even Y. It
+// returns the x-only encoding of Q.
+func taprootOutputKey(internalKey *btcec.PublicKey) []byte {
+	xOnly := internalKey.SerializeCompressed()[1:]
+	tag := sha256.Sum256([]byte("TapTweak"))
+	h := sha256.New()
+	h.Write(tag[:])
+	h.Write(tag[:])
+	h.Write(xOnly)
+
+	var tweak btcec.ModNScalar
+	tweak.SetByteSlice(h.Sum(nil))
+
+	// The 0x02 prefix selects the point with the same X and an even Y
+	even, _ := btcec.ParsePubKey(append([]byte{0x02}, xOnly...))
+	var p, tG, q btcec.JacobianPoint
+	even.AsJacobian(&p)
+	btcec.ScalarBaseMultNonConst(&tweak, &tG)
+	btcec.AddNonConst(&p, &tG, &q)
+	q.ToAffine()
+	return btcec.NewPublicKey(&q.X, &q.Y).SerializeCompressed()[1:]
+}
@@ -0,0 +1,114 @@
+package addrmint
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// testBaseSeed is the base seed of the CLI's golden vectors (--seed 42)
+const testBaseSeed = "2a"
+
+func TestParseBitcoinTypeMix(t *testing.T) {
+	mix, err := ParseBitcoinTypeMix("legacy=0.2,segwit=0.6, taproot=0.2,p2sh-segwit=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(mix.types, ","); got != "legacy,segwit,taproot" {
+		t.Errorf("Expected legacy,segwit,taproot, got %s", got)
+	}
+	if mix.cumulative[len(mix.cumulative)-1] != 1 {
+		t.Errorf("Expected shares to end at 1, got %v", mix.cumulative)
+	}
+
+	for _, s := range []string{"", "legacy", "legacy=x", "legacy=-1", "p2pk=1", "legacy=1,legacy=2", "segwit=0"} {
+		if _, err := ParseBitcoinTypeMix(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestSingleBitcoinType(t *testing.T) {
+	mix, err := SingleBitcoinType(BitcoinTaproot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seed [32]byte
+	for i := 0; i < 100; i++ {
+		DeriveSeed(nil, testBaseSeed, i, &seed)
+		if got := mix.Pick(&seed); got != BitcoinTaproot {
+			t.Fatalf("Index %d: expected taproot, got %s", i, got)
+		}
+	}
+
+	for _, s := range []string{"", "p2pk", "taproot=1"} {
+		if _, err := SingleBitcoinType(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestBitcoinTypeMixPick(t *testing.T) {
+	mix, err := ParseBitcoinTypeMix("legacy=1,segwit=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	var seed [32]byte
+	for i := 0; i < 10000; i++ {
+		DeriveSeed(nil, testBaseSeed, i, &seed)
+		counts[mix.Pick(&seed)]++
+	}
+	if counts[BitcoinLegacy] < 2300 || counts[BitcoinLegacy] > 2700 || counts[BitcoinSegwit] != 10000-counts[BitcoinLegacy] {
+		t.Errorf("Expected about 2500 legacy and 7500 segwit, got %v", counts)
+	}
+}
+
+func TestGenerateBitcoinAddressType(t *testing.T) {
+	var seed [32]byte
+	DeriveSeed(nil, testBaseSeed, 0, &seed)
+	prefixes := map[string]string{
+		BitcoinLegacy:     "1",
+		BitcoinP2SHSegwit: "3",
+		BitcoinSegwit:     "bc1q",
+		BitcoinTaproot:    "bc1p",
+	}
+	for _, addressType := range BitcoinTypes {
+		address, err := BitcoinAddress(seed[:], addressType)
+		if err != nil {
+			t.Fatalf("%s: %v", addressType, err)
+		}
+		if !strings.HasPrefix(address, prefixes[addressType]) {
+			t.Errorf("%s: expected prefix %s, got %s", addressType, prefixes[addressType], address)
+		}
+		if _, err := btcutil.DecodeAddress(address, &chaincfg.MainNetParams); err != nil {
+			t.Errorf("%s: %v", addressType, err)
+		}
+	}
+
+	// Legacy addresses are the ones generated without a mix
+	legacy, _ := BitcoinAddress(seed[:], BitcoinLegacy)
+	if legacy != "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT" {
+		t.Errorf("Expected the selftest address, got %s", legacy)
+	}
+	if address, _ := (BitcoinGenerator{}).Generate(seed[:]); string(address) != legacy {
+		t.Errorf("Expected the generator to default to legacy %s, got %s", legacy, address)
+	}
+}
+
+func TestTaprootOutputKey(t *testing.T) {
+	// First receiving key of the BIP-86 test vectors
+	internal, _ := hex.DecodeString("02cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115")
+	key, err := btcec.ParsePubKey(internal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c"
+	if got := hex.EncodeToString(taprootOutputKey(key)); got != want {
+		t.Errorf("Expected output key %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,47 @@
+package addrmint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Chains a network's addresses can be generated for. Signet and regtest
+// only exist for Bitcoin.
+const (
+	ChainMainnet = "mainnet"
+	ChainTestnet = "testnet"
+	ChainSignet  = "signet"
+	ChainRegtest = "regtest"
+)
+
+// Chains lists the chains
+var Chains = []string{ChainMainnet, ChainTestnet, ChainSignet, ChainRegtest}
+
+// CheckChain reports whether addresses of network can be generated for
+// chain. Ethereum and Solana addresses are the same on every chain of
+// theirs, so only mainnet and testnet are accepted for them.
+func CheckChain(network, chain string) error {
+	if !slices.Contains(Chains, chain) {
+		return fmt.Errorf("unknown chain %q (want %s)", chain, strings.Join(Chains, ", "))
+	}
+	if network != Bitcoin && (chain == ChainSignet || chain == ChainRegtest) {
+		return fmt.Errorf("%s only exists for bitcoin, not %s", chain, network)
+	}
+	return nil
+}
+
+// BitcoinParams returns the parameters of a Bitcoin chain, mainnet for ""
+func BitcoinParams(chain string) *chaincfg.Params {
+	switch chain {
+	case ChainTestnet:
+		return &chaincfg.TestNet3Params
+	case ChainSignet:
+		return &chaincfg.SigNetParams
+	case ChainRegtest:
+		return &chaincfg.RegressionNetParams
+	}
+	return &chaincfg.MainNetParams
+}
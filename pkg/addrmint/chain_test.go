@@ -0,0 +1,77 @@
+package addrmint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+func TestBitcoinChains(t *testing.T) {
+	// Private key 1, whose addresses are well known on every chain
+	seed := make([]byte, 32)
+	seed[31] = 1
+	tests := []struct {
+		chain, addressType, expected string
+	}{
+		{"", BitcoinLegacy, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		{ChainTestnet, BitcoinLegacy, "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r"},
+		{ChainRegtest, BitcoinLegacy, "mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r"},
+		{ChainMainnet, BitcoinSegwit, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{ChainTestnet, BitcoinSegwit, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{ChainSignet, BitcoinSegwit, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{ChainRegtest, BitcoinSegwit, "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"},
+	}
+	for _, tt := range tests {
+		types, _ := SingleBitcoinType(tt.addressType)
+		address, err := generate(BitcoinGenerator{Types: types, Chain: tt.chain}, seed)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.chain, tt.addressType, err)
+		}
+		if address != tt.expected {
+			t.Errorf("%s %s: expected %s, got %s", tt.chain, tt.addressType, tt.expected, address)
+		}
+	}
+}
+
+func TestTONTestnet(t *testing.T) {
+	seed := decodeSeed(t, "5c3a8c8d6f3c4e6a1b2d3f4e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b")
+	mainnet, _ := generate(TONGenerator{}, seed)
+	testnet, err := generate(TONGenerator{Testnet: true}, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(testnet, "0Q") {
+		t.Errorf("Expected a testnet-only address starting with 0Q, got %s", testnet)
+	}
+	parsed, err := address.ParseAddr(testnet)
+	if err != nil || !parsed.IsTestnetOnly() {
+		t.Errorf("Expected %s to parse as testnet-only: %v", testnet, err)
+	}
+	// The wallet commits to the global ID, so the account differs too
+	if parsed.StringRaw() == address.MustParseAddr(mainnet).StringRaw() {
+		t.Errorf("Expected the testnet wallet to differ from the mainnet one")
+	}
+}
+
+func TestCheckChain(t *testing.T) {
+	for _, network := range Networks {
+		for _, chain := range []string{ChainMainnet, ChainTestnet} {
+			if err := CheckChain(network, chain); err != nil {
+				t.Errorf("%s %s: %v", network, chain, err)
+			}
+		}
+	}
+	if err := CheckChain(Bitcoin, ChainRegtest); err != nil {
+		t.Error(err)
+	}
+	if err := CheckChain(Ethereum, ChainSignet); err == nil {
+		t.Error("Expected signet to be refused for ethereum")
+	}
+	if err := CheckChain(Bitcoin, "testnet4"); err == nil {
+		t.Error("Expected an unknown chain to be refused")
+	}
+	if _, err := New(Solana, Options{Chain: ChainRegtest}); err == nil {
+		t.Error("Expected New to refuse regtest for solana")
+	}
+}
@@ -0,0 +1,67 @@
+package addrmint
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Contract deployment opcodes whose addresses can be predicted
+const (
+	ContractCreate  = "create"  // Address from the deployer and its nonce
+	ContractCreate2 = "create2" // Address from the deployer, a salt and the init code hash
+)
+
+// ContractGenerator predicts the EIP-55 checksummed addresses of contracts
+// a deployer creates. Its seeds are not keys but big-endian 256-bit
+// numbers, as written by NewIndexSeedDeriver: the deployer's nonce for
+// ContractCreate and the salt for ContractCreate2.
+type ContractGenerator struct {
+	Opcode       string
+	Deployer     common.Address
+	InitCodeHash common.Hash // Keccak-256 of the init code, for ContractCreate2
+}
+
+// NewContractGenerator parses a 0x-prefixed deployer address and, for
+// ContractCreate2, a 0x-prefixed init code hash
+func NewContractGenerator(opcode, deployer, initCodeHash string) (*ContractGenerator, error) {
+	if !common.IsHexAddress(deployer) {
+		return nil, fmt.Errorf("invalid deployer address %q", deployer)
+	}
+	g := &ContractGenerator{Opcode: opcode, Deployer: common.HexToAddress(deployer)}
+	switch opcode {
+	case ContractCreate:
+		if initCodeHash != "" {
+			return nil, fmt.Errorf("%s addresses do not depend on the init code", opcode)
+		}
+	case ContractCreate2:
+		hash, err := hexutil.Decode(initCodeHash)
+		if err != nil || len(hash) != common.HashLength {
+			return nil, fmt.Errorf("invalid init code hash %q: expected 0x followed by 64 hex digits", initCodeHash)
+		}
+		g.InitCodeHash = common.BytesToHash(hash)
+	default:
+		return nil, fmt.Errorf("unknown contract opcode %q (want %s or %s)", opcode, ContractCreate, ContractCreate2)
+	}
+	return g, nil
+}
+
+// Generate predicts the address of the contract created with the nonce or
+// salt in seed
+func (g *ContractGenerator) Generate(seed []byte) (Address, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return "", err
+	}
+	if g.Opcode == ContractCreate2 {
+		return Address(crypto.CreateAddress2(g.Deployer, [32]byte(seed), g.InitCodeHash[:]).Hex()), nil
+	}
+	for _, b := range seed[:24] {
+		if b != 0 {
+			return "", fmt.Errorf("nonce 0x%x does not fit in 64 bits", seed)
+		}
+	}
+	return Address(crypto.CreateAddress(g.Deployer, binary.BigEndian.Uint64(seed[24:])).Hex()), nil
+}
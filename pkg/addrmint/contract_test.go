@@ -0,0 +1,71 @@
+package addrmint
+
+import "testing"
+
+func TestContractAddresses(t *testing.T) {
+	deriver := NewIndexSeedDeriver()
+	var seed [32]byte
+	tests := []struct {
+		opcode, deployer, initCodeHash string
+		index                          int
+		expected                       string
+	}{
+		// Nonces 0 to 2 of a well-known deployer
+		{ContractCreate, "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0", "", 0, "0xcd234A471b72ba2F1Ccf0A70FCABA648a5eeCD8d"},
+		{ContractCreate, "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0", "", 1, "0x343c43A37D37dfF08AE8C4A11544c718AbB4fCF8"},
+		{ContractCreate, "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0", "", 2, "0xf778B86FA74E846c4f0a1fBd1335FE81c00a0C91"},
+		// EIP-1014 examples 0 and 1: init code 0x00 with a zero salt
+		{ContractCreate2, "0x0000000000000000000000000000000000000000", "0xbc36789e7a1e281436464229828f817d6612f7b477d66591ff96a9e064bcc98a", 0, "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"},
+		{ContractCreate2, "0xdeadbeef00000000000000000000000000000000", "0xbc36789e7a1e281436464229828f817d6612f7b477d66591ff96a9e064bcc98a", 0, "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"},
+	}
+	for _, tt := range tests {
+		g, err := NewContractGenerator(tt.opcode, tt.deployer, tt.initCodeHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deriver.Derive(tt.index, &seed)
+		address, err := generate(g, seed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if address != tt.expected {
+			t.Errorf("%s %s #%d: expected %s, got %s", tt.opcode, tt.deployer, tt.index, tt.expected, address)
+		}
+	}
+}
+
+func TestIndexSeedDeriver(t *testing.T) {
+	var seed [32]byte
+	NewIndexSeedDeriver().Derive(0x0102, &seed)
+	if seed != [32]byte{30: 1, 31: 2} {
+		t.Errorf("Expected the index as a big-endian number, got %x", seed)
+	}
+}
+
+func TestNewContractGeneratorInvalid(t *testing.T) {
+	const deployer = "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0"
+	tests := []struct{ opcode, deployer, initCodeHash string }{
+		{"create3", deployer, ""},
+		{ContractCreate, "0x1234", ""},
+		{ContractCreate, deployer, "0xbc36789e7a1e281436464229828f817d6612f7b477d66591ff96a9e064bcc98a"},
+		{ContractCreate2, deployer, ""},
+		{ContractCreate2, deployer, "0xbc36"},
+		{ContractCreate2, deployer, "bc36789e7a1e281436464229828f817d6612f7b477d66591ff96a9e064bcc98a"},
+	}
+	for _, tt := range tests {
+		if _, err := NewContractGenerator(tt.opcode, tt.deployer, tt.initCodeHash); err == nil {
+			t.Errorf("%s %s %q: expected an error", tt.opcode, tt.deployer, tt.initCodeHash)
+		}
+	}
+
+	// Nonces end at 2^64-1
+	g, _ := NewContractGenerator(ContractCreate, deployer, "")
+	if _, err := generate(g, make([]byte, 32)); err != nil {
+		t.Error(err)
+	}
+	seed := make([]byte, 32)
+	seed[23] = 1
+	if _, err := generate(g, seed); err == nil {
+		t.Error("Expected a nonce above 64 bits to be refused")
+	}
+}
@@ -0,0 +1,299 @@
+package addrmint
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+// Address encodings of chain descriptors
+const (
+	EncodingBech32      = "bech32"      // BIP-173 bech32 of the hash, with no witness version, as Cosmos chains use
+	EncodingBech32m     = "bech32m"     // BIP-350 bech32m of the hash, with no witness version
+	EncodingBase58Check = "base58check" // Base58 of the version bytes, the hash and a double SHA-256 checksum
+)
+
+// Curves of chain descriptors
+const (
+	CurveSecp256k1 = "secp256k1"
+	CurveEd25519   = "ed25519"
+)
+
+// Public key hashes of chain descriptors
+const (
+	HashHash160   = "hash160"   // RIPEMD-160 of SHA-256
+	HashKeccak256 = "keccak256" // Last 20 bytes of Keccak-256, of the point without its 0x04 prefix if uncompressed
+	HashSHA256    = "sha256"    // SHA-256
+	HashSHA256_20 = "sha256-20" // First 20 bytes of SHA-256
+	HashNone      = "none"      // The public key itself
+)
+
+// ChainDescriptor defines a network whose addresses are a hash of a public
+// key in bech32 or Base58Check, so such chains can be added without code.
+// A ChainDescriptor is a Generator and is safe for concurrent use.
+type ChainDescriptor struct {
+	Name         string
+	Encoding     string
+	Curve        string
+	Uncompressed bool   // Hash the uncompressed secp256k1 point instead of the compressed one
+	Hash         string // Hash of the public key the address encodes
+	HRP          string // Human-readable part of bech32 addresses
+	Version      []byte // Version bytes of Base58Check addresses
+}
+
+// descriptorFields lists the fields a descriptor file may set
+var descriptorFields = []string{"name", "encoding", "curve", "public_key", "hash", "hrp", "version"}
+
+// ParseChainDescriptor parses a descriptor file, a YAML mapping of the
+// fields name, encoding, curve, public_key, hash, hrp and version:
+//
+//	name: cosmoshub
+//	encoding: bech32
+//	curve: secp256k1
+//	hash: hash160
+//	hrp: cosmos
+//
+// Only plain "key: value" lines are read, with comments and quoted values;
+// nested YAML is rejected.
+func ParseChainDescriptor(data []byte) (*ChainDescriptor, error) {
+	fields := map[string]string{}
+	for n, line := range strings.Split(string(data), "\n") {
+		line = stripYAMLComment(line)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok || line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "-") {
+			return nil, fmt.Errorf("line %d: expected a top-level \"key: value\" line", n+1)
+		}
+		key = strings.TrimSpace(key)
+		if !slices.Contains(descriptorFields, key) {
+			return nil, fmt.Errorf("line %d: unknown field %q (want %s)", n+1, key, strings.Join(descriptorFields, ", "))
+		}
+		if _, dup := fields[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate field %q", n+1, key)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		fields[key] = value
+	}
+
+	d := &ChainDescriptor{
+		Name:     fields["name"],
+		Encoding: fields["encoding"],
+		Curve:    fields["curve"],
+		Hash:     fields["hash"],
+		HRP:      fields["hrp"],
+	}
+	if err := checkNetworkName(d.Name); err != nil {
+		return nil, err
+	}
+	switch d.Curve {
+	case CurveSecp256k1:
+		switch fields["public_key"] {
+		case "", "compressed":
+		case "uncompressed":
+			d.Uncompressed = true
+		default:
+			return nil, fmt.Errorf("%s: public_key must be compressed or uncompressed", d.Name)
+		}
+	case CurveEd25519:
+		if fields["public_key"] != "" {
+			return nil, fmt.Errorf("%s: public_key only applies to secp256k1", d.Name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: curve must be %s or %s", d.Name, CurveSecp256k1, CurveEd25519)
+	}
+	switch d.Hash {
+	case HashHash160, HashKeccak256, HashSHA256, HashSHA256_20, HashNone:
+	default:
+		return nil, fmt.Errorf("%s: hash must be %s, %s, %s, %s or %s", d.Name, HashHash160, HashKeccak256, HashSHA256, HashSHA256_20, HashNone)
+	}
+	switch d.Encoding {
+	case EncodingBech32, EncodingBech32m:
+		if d.HRP == "" || d.HRP != strings.ToLower(d.HRP) || fields["version"] != "" {
+			return nil, fmt.Errorf("%s: bech32 networks need a lowercase hrp and no version", d.Name)
+		}
+	case EncodingBase58Check:
+		version, err := parseVersionBytes(fields["version"])
+		if err != nil || fields["hrp"] != "" {
+			return nil, fmt.Errorf("%s: base58check networks need version bytes, such as 0x1e, and no hrp", d.Name)
+		}
+		d.Version = version
+	default:
+		return nil, fmt.Errorf("%s: encoding must be %s, %s or %s", d.Name, EncodingBech32, EncodingBech32m, EncodingBase58Check)
+	}
+	return d, nil
+}
+
+// stripYAMLComment removes a # comment that starts the line or follows
+// whitespace outside quotes
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseVersionBytes parses 0x-prefixed hex version bytes, or a decimal
+// version byte
+func parseVersionBytes(s string) ([]byte, error) {
+	if digits, ok := strings.CutPrefix(s, "0x"); ok {
+		version, err := hex.DecodeString(digits)
+		if err != nil || len(version) == 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		return version, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	return []byte{byte(n)}, nil
+}
+
+// checkNetworkName rejects descriptor names that are empty, clash with a
+// built-in network or cannot be typed as a flag value
+func checkNetworkName(name string) error {
+	if name == "" {
+		return fmt.Errorf("descriptor has no name")
+	}
+	if slices.Contains(Networks, name) {
+		return fmt.Errorf("%s is a built-in network", name)
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return fmt.Errorf("invalid network name %q: use lowercase letters, digits and -", name)
+		}
+	}
+	return nil
+}
+
+// LoadChainDescriptors parses every .yaml and .yml file in dir, keyed by
+// network name
+func LoadChainDescriptors(dir string) (map[string]*ChainDescriptor, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	descriptors := map[string]*ChainDescriptor{}
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		d, err := ParseChainDescriptor(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if _, dup := descriptors[d.Name]; dup {
+			return nil, fmt.Errorf("%s: network %s is defined twice", path, d.Name)
+		}
+		descriptors[d.Name] = d
+	}
+	return descriptors, nil
+}
+
+// PublicKey returns the public key of seed on the descriptor's curve, in
+// the form its addresses hash
+func (d *ChainDescriptor) PublicKey(seed []byte) ([]byte, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return nil, err
+	}
+	if d.Curve == CurveEd25519 {
+		return ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey), nil
+	}
+	// Keys outside the curve order are rejected, as wallets would
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetBytes((*[32]byte)(seed)); overflow != 0 {
+		return nil, fmt.Errorf("invalid seed: private key exceeds the curve order")
+	}
+	if scalar.IsZero() {
+		return nil, ErrZeroPrivateKey
+	}
+	pubKey := btcec.PrivKeyFromScalar(&scalar).PubKey()
+	if d.Uncompressed {
+		return pubKey.SerializeUncompressed(), nil
+	}
+	return pubKey.SerializeCompressed(), nil
+}
+
+// Generate derives the address of seed
+func (d *ChainDescriptor) Generate(seed []byte) (Address, error) {
+	publicKey, err := d.PublicKey(seed)
+	if err != nil {
+		return "", err
+	}
+
+	var payload []byte
+	switch d.Hash {
+	case HashHash160:
+		digest := sha256.Sum256(publicKey)
+		hasher := ripemd160.New()
+		hasher.Write(digest[:])
+		payload = hasher.Sum(nil)
+	case HashKeccak256:
+		if d.Uncompressed {
+			publicKey = publicKey[1:]
+		}
+		keccak := sha3.NewLegacyKeccak256()
+		keccak.Write(publicKey)
+		payload = keccak.Sum(nil)[12:]
+	case HashSHA256:
+		digest := sha256.Sum256(publicKey)
+		payload = digest[:]
+	case HashSHA256_20:
+		digest := sha256.Sum256(publicKey)
+		payload = digest[:20]
+	default:
+		payload = publicKey
+	}
+
+	switch d.Encoding {
+	case EncodingBech32, EncodingBech32m:
+		data, err := bech32.ConvertBits(payload, 8, 5, true)
+		if err != nil {
+			return "", err
+		}
+		var address string
+		if d.Encoding == EncodingBech32m {
+			address, err = bech32.EncodeM(d.HRP, data)
+		} else {
+			address, err = bech32.Encode(d.HRP, data)
+		}
+		return Address(address), err
+	}
+	versioned := append(slices.Clip(d.Version), payload...)
+	first := sha256.Sum256(versioned)
+	checksum := sha256.Sum256(first[:])
+	return Address(base58.Encode(append(versioned, checksum[:4]...))), nil
+}
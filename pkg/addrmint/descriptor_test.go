@@ -0,0 +1,148 @@
+package addrmint
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mr-tron/base58"
+)
+
+func TestParseChainDescriptor(t *testing.T) {
+	d, err := ParseChainDescriptor([]byte(`---
+# Cosmos Hub accounts
+name: cosmoshub
+encoding: bech32   # no witness version
+curve: secp256k1
+hash: "hash160"
+hrp: 'cosmos'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "cosmoshub" || d.Encoding != EncodingBech32 || d.Hash != HashHash160 || d.HRP != "cosmos" || d.Uncompressed {
+		t.Errorf("Unexpected descriptor %+v", d)
+	}
+
+	d, err = ParseChainDescriptor([]byte("name: zcash\nencoding: base58check\ncurve: secp256k1\nhash: hash160\nversion: 0x1cb8\n"))
+	if err != nil || !bytes.Equal(d.Version, []byte{0x1c, 0xb8}) {
+		t.Errorf("Expected version 1cb8, got %+v (%v)", d, err)
+	}
+
+	for _, bad := range []string{
+		"encoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp: x\n",                                         // no name
+		"name: bitcoin\nencoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp: x\n",                          // built-in
+		"name: Coin\nencoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp: x\n",                             // uppercase name
+		"name: c\nencoding: bech32\ncurve: p256\nhash: hash160\nhrp: x\n",                                     // curve
+		"name: c\nencoding: bech32\ncurve: secp256k1\nhash: md5\nhrp: x\n",                                    // hash
+		"name: c\nencoding: bech32\ncurve: secp256k1\nhash: hash160\n",                                        // no hrp
+		"name: c\nencoding: base58check\ncurve: secp256k1\nhash: hash160\nversion: 300\n",                     // version
+		"name: c\nencoding: base58check\ncurve: ed25519\npublic_key: compressed\nhash: none\nversion: 0x00\n", // public_key
+		"name: c\nencoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp: x\nhrp: y\n",                        // duplicate
+		"name: c\nencoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp: x\ncolor: red\n",                    // unknown field
+		"name: c\nencoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp:\n  nested: x\n",                     // nested
+		"name: c\nencoding: base64\ncurve: secp256k1\nhash: hash160\nhrp: x\n",                                // encoding
+		"name: c\nencoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp: x\nversion: 0x00\n",                 // version with bech32
+		"name: c\nencoding: bech32\ncurve: secp256k1\npublic_key: hybrid\nhash: hash160\nhrp: x\n",            // public_key
+		"name: c\nencoding: base58check\ncurve: secp256k1\nhash: hash160\nversion: 0x00\nhrp: x\n",            // hrp with base58check
+	} {
+		if _, err := ParseChainDescriptor([]byte(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestChainDescriptorGenerate(t *testing.T) {
+	var one [32]byte
+	one[31] = 1
+	hash160, _ := hex.DecodeString("751e76e8199196d454941c45d1b3a323f1433bd6")
+
+	// Version 0 with hash160 of the compressed key is a legacy Bitcoin address
+	legacy := &ChainDescriptor{Name: "btclike", Encoding: EncodingBase58Check, Curve: CurveSecp256k1, Hash: HashHash160, Version: []byte{0}}
+	if addr, err := legacy.Generate(one[:]); err != nil || addr != "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH" {
+		t.Errorf("Expected 1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH, got %s (%v)", addr, err)
+	}
+
+	// Cosmos-style bech32 carries the hash with no witness version
+	cosmos := &ChainDescriptor{Name: "cosmoshub", Encoding: EncodingBech32, Curve: CurveSecp256k1, Hash: HashHash160, HRP: "cosmos"}
+	addr, err := cosmos.Generate(one[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hrp, data, err := bech32.DecodeToBase256(string(addr))
+	if err != nil || hrp != "cosmos" || !bytes.Equal(data, hash160) {
+		t.Errorf("Expected cosmos1 bech32 of %x, got %s (%v)", hash160, addr, err)
+	}
+
+	// Tron is Base58Check of 0x41 and the Ethereum address
+	tron := &ChainDescriptor{Name: "tron", Encoding: EncodingBase58Check, Curve: CurveSecp256k1, Uncompressed: true, Hash: HashKeccak256, Version: []byte{0x41}}
+	addr, err = tron.Generate(one[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := base58.Decode(string(addr))
+	ethereum := common.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	if err != nil || len(decoded) != 25 || decoded[0] != 0x41 || !bytes.Equal(decoded[1:21], ethereum[:]) {
+		t.Errorf("Expected the Tron address of %s, got %s (%v)", ethereum.Hex(), addr, err)
+	}
+	if !strings.HasPrefix(string(addr), "T") {
+		t.Errorf("Expected a T... address, got %s", addr)
+	}
+
+	// Ed25519 keys can be encoded as they are
+	raw := &ChainDescriptor{Name: "edchain", Encoding: EncodingBech32m, Curve: CurveEd25519, Hash: HashNone, HRP: "ed"}
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	addr, err = raw.Generate(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, data, _, err := bech32.DecodeGeneric(string(addr)); err != nil || len(data) != 52 {
+		t.Errorf("Expected a bech32m address of a 32-byte key, got %s (%v)", addr, err)
+	}
+
+	var zero, overflow [32]byte
+	for i := range overflow {
+		overflow[i] = 0xff
+	}
+	for _, seed := range [][]byte{zero[:], overflow[:], one[:16]} {
+		if _, err := cosmos.Generate(seed); err == nil {
+			t.Errorf("Expected an error for seed %x", seed)
+		}
+	}
+}
+
+func TestLoadChainDescriptors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"cosmoshub.yaml": "name: cosmoshub\nencoding: bech32\ncurve: secp256k1\nhash: hash160\nhrp: cosmos\n",
+		"dogecoin.yml":   "name: dogecoin\nencoding: base58check\ncurve: secp256k1\nhash: hash160\nversion: 0x1e\n",
+		"README.md":      "not a descriptor",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	descriptors, err := LoadChainDescriptors(dir)
+	if err != nil || len(descriptors) != 2 || descriptors["dogecoin"] == nil {
+		t.Fatalf("Expected cosmoshub and dogecoin, got %v (%v)", descriptors, err)
+	}
+	var seed [32]byte
+	seed[31] = 1
+	if addr, err := descriptors["dogecoin"].Generate(seed[:]); err != nil || !strings.HasPrefix(string(addr), "D") {
+		t.Errorf("Expected a D... address, got %s (%v)", addr, err)
+	}
+
+	// Two files may not define the same network
+	if err := os.WriteFile(filepath.Join(dir, "copy.yaml"), []byte(files["cosmoshub.yaml"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadChainDescriptors(dir); err == nil {
+		t.Error("Expected an error for a network defined twice")
+	}
+}
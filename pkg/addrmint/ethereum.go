@@ -0,0 +1,55 @@
+package addrmint
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// EthereumGenerator derives EIP-55 checksummed Ethereum addresses using
+// go-ethereum's pooled Keccak-256 hasher
+type EthereumGenerator struct{}
+
+// Generate derives the address of seed
+func (EthereumGenerator) Generate(seed []byte) (Address, error) {
+	// Create private key from seed
+	privateKey, err := crypto.ToECDSA(seed)
+	if err != nil {
+		return "", fmt.Errorf("failed to create private key: %w", err)
+	}
+
+	// Get Ethereum address
+	return Address(crypto.PubkeyToAddress(privateKey.PublicKey).Hex()), nil
+}
+
+// EthereumReusedGenerator derives the same addresses as EthereumGenerator
+// using its own Keccak-256 state instead of go-ethereum's shared hasher pool.
+// The hash implementation is the same; only the pool round trip is saved.
+type EthereumReusedGenerator struct {
+	keccak crypto.KeccakState
+}
+
+// NewEthereumReusedGenerator creates a generator with its own Keccak-256 state
+func NewEthereumReusedGenerator() *EthereumReusedGenerator {
+	return &EthereumReusedGenerator{keccak: sha3.NewLegacyKeccak256().(crypto.KeccakState)}
+}
+
+// Generate derives the address of seed
+func (g *EthereumReusedGenerator) Generate(seed []byte) (Address, error) {
+	// Create private key from seed
+	privateKey, err := crypto.ToECDSA(seed)
+	if err != nil {
+		return "", fmt.Errorf("failed to create private key: %w", err)
+	}
+
+	// Address is the last 20 bytes of the Keccak-256 of the uncompressed public key
+	pubBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+	var digest [32]byte
+	g.keccak.Reset()
+	g.keccak.Write(pubBytes[1:])
+	g.keccak.Read(digest[:])
+
+	return Address(common.BytesToAddress(digest[12:]).Hex()), nil
+}
@@ -0,0 +1,63 @@
+package addrmint
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// addFuzzSeeds adds seeds of interesting lengths and values to the corpus
+func addFuzzSeeds(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x01})
+	f.Add(make([]byte, 31))
+	f.Add(make([]byte, 32))
+	f.Add(make([]byte, 33))
+	for _, seed := range []string{
+		"c8c5e5a7f326a2b5f3eee778db6856430d808c32b16e18d8228a93e3d94791a3",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		// secp256k1 group order, which reduces to the zero scalar
+		"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+	} {
+		b, _ := hex.DecodeString(seed)
+		f.Add(b)
+	}
+}
+
+// FuzzGenerators checks that no generator panics on malformed seeds, that
+// seeds of the wrong length are rejected and that backends agree
+func FuzzGenerators(f *testing.F) {
+	addFuzzSeeds(f)
+	reused := NewEthereumReusedGenerator()
+
+	f.Fuzz(func(t *testing.T, seed []byte) {
+		generators := map[string]Generator{
+			"ethereum":        EthereumGenerator{},
+			"ethereum/reused": reused,
+			"bitcoin":         BitcoinGenerator{},
+			"solana":          SolanaGenerator{},
+			"solana/native":   SolanaNativeGenerator{},
+			"ton":             TONGenerator{},
+		}
+
+		addrs := make(map[string]Address)
+		for name, generator := range generators {
+			addr, err := generator.Generate(seed)
+			if len(seed) != 32 && err == nil {
+				t.Errorf("%s: expected error for %d byte seed, got %s", name, len(seed), addr)
+			}
+			if err == nil && addr == "" {
+				t.Errorf("%s: empty address without error", name)
+			}
+			if err == nil {
+				addrs[name] = addr
+			}
+		}
+
+		if addrs["ethereum"] != addrs["ethereum/reused"] {
+			t.Errorf("Ethereum backends disagree: %q != %q", addrs["ethereum"], addrs["ethereum/reused"])
+		}
+		if addrs["solana"] != addrs["solana/native"] {
+			t.Errorf("Solana backends disagree: %q != %q", addrs["solana"], addrs["solana/native"])
+		}
+	})
+}
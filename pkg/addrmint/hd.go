@@ -0,0 +1,252 @@
+package addrmint
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Hierarchical deterministic derivation schemes. Unlike DerivationSchemes
+// they derive from a master seed along a path, so the keys match those of
+// wallets that use the same seed and path.
+const (
+	DerivationBIP32  = "bip32"  // BIP-32 secp256k1 derivation, for Ethereum and Bitcoin
+	DerivationSLIP10 = "slip10" // SLIP-10 ed25519 derivation, for Solana and TON
+)
+
+// HDIndexPlaceholder marks the path component that takes the address index
+const HDIndexPlaceholder = "{i}"
+
+// MaxHDIndex is the largest index a path component can hold
+const MaxHDIndex = 1<<31 - 1
+
+// hardenedOffset is added to the index of hardened path components
+const hardenedOffset = 1 << 31
+
+// HDScheme returns the HD derivation scheme of a network's keys
+func HDScheme(network string) string {
+	if network == Solana || network == TON {
+		return DerivationSLIP10
+	}
+	return DerivationBIP32
+}
+
+// HDPath is a derivation path such as m/44'/60'/0'/0/{i}, with exactly one
+// component replaced by the address index. Hardened components end in '
+// or h.
+type HDPath struct {
+	text     string
+	prefix   []uint32 // Components before the index
+	hardened bool     // Whether the index component is hardened
+	suffix   []uint32 // Components after the index
+}
+
+// ParseHDPath parses a derivation path containing HDIndexPlaceholder
+func ParseHDPath(path string) (*HDPath, error) {
+	fields := strings.Split(path, "/")
+	if fields[0] != "m" {
+		return nil, fmt.Errorf("derivation path %q must start with m/", path)
+	}
+	p := &HDPath{text: path}
+	seen := false
+	for _, field := range fields[1:] {
+		name, hardened := strings.CutSuffix(field, "'")
+		if !hardened {
+			name, hardened = strings.CutSuffix(field, "h")
+		}
+		if name == HDIndexPlaceholder {
+			if seen {
+				return nil, fmt.Errorf("derivation path %q contains %s more than once", path, HDIndexPlaceholder)
+			}
+			seen = true
+			p.hardened = hardened
+			continue
+		}
+		n, err := strconv.ParseUint(name, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid component %q in derivation path %q", field, path)
+		}
+		component := uint32(n)
+		if hardened {
+			component += hardenedOffset
+		}
+		if seen {
+			p.suffix = append(p.suffix, component)
+		} else {
+			p.prefix = append(p.prefix, component)
+		}
+	}
+	if !seen {
+		return nil, fmt.Errorf("derivation path %q must contain %s for the address index", path, HDIndexPlaceholder)
+	}
+	return p, nil
+}
+
+// String returns the path as it was given
+func (p *HDPath) String() string {
+	return p.text
+}
+
+// Index returns the path of one address, with the index in place of
+// HDIndexPlaceholder
+func (p *HDPath) Index(index int) string {
+	return strings.Replace(p.text, HDIndexPlaceholder, strconv.Itoa(index), 1)
+}
+
+// BitcoinType returns the address type that the path's purpose stands for:
+// legacy for BIP-44, p2sh-segwit for BIP-49, segwit for BIP-84 and taproot
+// for BIP-86. It returns "" for other paths.
+func (p *HDPath) BitcoinType() string {
+	if len(p.prefix) == 0 {
+		return ""
+	}
+	switch p.prefix[0] {
+	case hardenedOffset + 44:
+		return BitcoinLegacy
+	case hardenedOffset + 49:
+		return BitcoinP2SHSegwit
+	case hardenedOffset + 84:
+		return BitcoinSegwit
+	case hardenedOffset + 86:
+		return BitcoinTaproot
+	}
+	return ""
+}
+
+// extendedKey is a private key with its chain code
+type extendedKey struct {
+	key       [32]byte
+	chainCode [32]byte
+}
+
+// HDDeriver derives the key at a path for each address index. The key
+// at the components before the index is derived once, so each index costs
+// only the remaining steps. An HDDeriver reuses scratch state, so each
+// goroutine needs its own; see Clone.
+type HDDeriver struct {
+	scheme string
+	path   *HDPath
+	parent extendedKey // Key at the path's prefix
+	data   []byte
+	sum    []byte
+}
+
+// NewHDDeriver derives the master key of a 16 to 64 byte seed, such as the
+// binary seed of a BIP-39 mnemonic, and walks it down to the index
+// component of path. SLIP-10 only defines hardened ed25519 derivation, so
+// every component must be hardened under DerivationSLIP10.
+func NewHDDeriver(scheme string, path *HDPath, seed []byte) (*HDDeriver, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("an HD seed has 16 to 64 bytes, not %d", len(seed))
+	}
+	var hmacKey string
+	switch scheme {
+	case DerivationBIP32:
+		hmacKey = "Bitcoin seed"
+	case DerivationSLIP10:
+		hmacKey = "ed25519 seed"
+		for _, component := range append(path.prefix, path.suffix...) {
+			if component < hardenedOffset {
+				return nil, fmt.Errorf("SLIP-10 ed25519 derivation needs every component of %s to be hardened", path)
+			}
+		}
+		if !path.hardened {
+			return nil, fmt.Errorf("SLIP-10 ed25519 derivation needs %s' to be hardened in %s", HDIndexPlaceholder, path)
+		}
+	default:
+		return nil, fmt.Errorf("unknown HD derivation scheme %q", scheme)
+	}
+
+	d := &HDDeriver{scheme: scheme, path: path}
+	mac := hmac.New(sha512.New, []byte(hmacKey))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	copy(d.parent.key[:], sum[:32])
+	copy(d.parent.chainCode[:], sum[32:])
+	if scheme == DerivationBIP32 && !validSecp256k1Key(&d.parent.key) {
+		return nil, errors.New("the seed gives an invalid BIP-32 master key")
+	}
+	for _, component := range path.prefix {
+		if !d.child(&d.parent, component, &d.parent) {
+			return nil, fmt.Errorf("%s derives an invalid key before the index", path)
+		}
+	}
+	return d, nil
+}
+
+// Clone returns a deriver for the same path with its own scratch state
+func (d *HDDeriver) Clone() *HDDeriver {
+	return &HDDeriver{scheme: d.scheme, path: d.path, parent: d.parent}
+}
+
+// Derive computes the key of index into out. BIP-32 rejects about one key
+// in 2^127; out is zeroed for those indices and for indices above
+// MaxHDIndex, which generators reject with ErrZeroPrivateKey.
+func (d *HDDeriver) Derive(index int, out *[32]byte) {
+	*out = [32]byte{}
+	if index < 0 || index > MaxHDIndex {
+		return
+	}
+	component := uint32(index)
+	if d.path.hardened {
+		component += hardenedOffset
+	}
+	var key extendedKey
+	if !d.child(&d.parent, component, &key) {
+		return
+	}
+	for _, component := range d.path.suffix {
+		if !d.child(&key, component, &key) {
+			return
+		}
+	}
+	*out = key.key
+}
+
+// child derives the child key at component of parent into out, which may
+// be parent itself. It reports false for keys BIP-32 declares invalid.
+func (d *HDDeriver) child(parent *extendedKey, component uint32, out *extendedKey) bool {
+	d.data = d.data[:0]
+	if component >= hardenedOffset || d.scheme == DerivationSLIP10 {
+		d.data = append(d.data, 0)
+		d.data = append(d.data, parent.key[:]...)
+	} else {
+		private, _ := btcec.PrivKeyFromBytes(parent.key[:])
+		d.data = append(d.data, private.PubKey().SerializeCompressed()...)
+	}
+	d.data = binary.BigEndian.AppendUint32(d.data, component)
+
+	mac := hmac.New(sha512.New, parent.chainCode[:])
+	mac.Write(d.data)
+	d.sum = mac.Sum(d.sum[:0])
+
+	if d.scheme == DerivationBIP32 {
+		// The child key is IL + parent key mod n
+		var tweak, key btcec.ModNScalar
+		if tweak.SetByteSlice(d.sum[:32]) {
+			return false
+		}
+		key.SetBytes(&parent.key)
+		if tweak.Add(&key).IsZero() {
+			return false
+		}
+		tweak.PutBytes(&out.key)
+	} else {
+		copy(out.key[:], d.sum[:32])
+	}
+	copy(out.chainCode[:], d.sum[32:])
+	return true
+}
+
+// validSecp256k1Key reports whether key is a nonzero scalar below the
+// curve order
+func validSecp256k1Key(key *[32]byte) bool {
+	var k btcec.ModNScalar
+	return k.SetBytes(key) == 0 && !k.IsZero()
+}
@@ -0,0 +1,162 @@
+package addrmint
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// testMnemonic is the BIP-39 phrase of all-zero entropy, whose wallet
+// addresses are published by many wallets' test suites
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// TestHDDeriverVectors checks test vector 1 of BIP-32 and of SLIP-10 for
+// ed25519, both from the seed 000102...0f
+func TestHDDeriverVectors(t *testing.T) {
+	seed := decodeSeed(t, "000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		scheme string
+		path   string
+		index  int
+		key    string
+	}{
+		{DerivationBIP32, "m/{i}'", 0, "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{DerivationBIP32, "m/0'/{i}", 1, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{DerivationBIP32, "m/{i}'/1", 0, "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{DerivationSLIP10, "m/{i}'", 0, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+	}
+
+	for _, tt := range tests {
+		path, err := ParseHDPath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := NewHDDeriver(tt.scheme, path, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var key [32]byte
+		d.Derive(tt.index, &key)
+		if hex.EncodeToString(key[:]) != tt.key {
+			t.Errorf("%s %s index %d: expected %s, got %x", tt.scheme, tt.path, tt.index, tt.key, key)
+		}
+	}
+}
+
+// TestHDWalletAddresses checks the first account of testMnemonic against
+// the addresses wallets show for it
+func TestHDWalletAddresses(t *testing.T) {
+	seed, err := MnemonicToSeed(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		network string
+		path    string
+		address string
+	}{
+		{Ethereum, "m/44'/60'/0'/0/{i}", "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"},
+		{Bitcoin, "m/44'/0'/0'/0/{i}", "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{Bitcoin, "m/49'/0'/0'/0/{i}", "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+		{Bitcoin, "m/84'/0'/0'/0/{i}", "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{Bitcoin, "m/86'/0'/0'/0/{i}", "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+		{Solana, "m/44'/501'/{i}'/0'", "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk"},
+	}
+
+	for _, tt := range tests {
+		path, err := ParseHDPath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := NewHDDeriver(HDScheme(tt.network), path, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var key [32]byte
+		NewHDSeedDeriver(d).Derive(0, &key)
+		types, err := SingleBitcoinType(path.BitcoinType())
+		if tt.network != Bitcoin {
+			types = nil
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		generator, err := New(tt.network, Options{BitcoinTypes: types})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := generate(generator, key[:]); err != nil || got != tt.address {
+			t.Errorf("%s %s: expected %s, got %s (%v)", tt.network, tt.path, tt.address, got, err)
+		}
+	}
+}
+
+func TestParseHDPath(t *testing.T) {
+	path, err := ParseHDPath("m/49h/0h/0h/0/{i}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.BitcoinType() != BitcoinP2SHSegwit || path.String() != "m/49h/0h/0h/0/{i}" {
+		t.Errorf("Unexpected path %s of type %q", path, path.BitcoinType())
+	}
+	if got := path.Index(17); got != "m/49h/0h/0h/0/17" {
+		t.Errorf("Expected the path of index 17, got %s", got)
+	}
+
+	for path, want := range map[string]string{
+		"44'/60'/0'/0/{i}":  "must start with m/",
+		"m/44'/60'/0'/0/0":  "must contain {i}",
+		"m/{i}/{i}":         "more than once",
+		"m/44'/x/{i}":       "invalid component",
+		"m/2147483648/{i}":  "invalid component",
+		"m/44'//{i}":        "invalid component",
+		"m/44'/60'/0'/0/i'": "invalid component",
+	} {
+		if _, err := ParseHDPath(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", path, want, err)
+		}
+	}
+}
+
+func TestHDDeriverErrors(t *testing.T) {
+	seed := decodeSeed(t, "000102030405060708090a0b0c0d0e0f")
+	path, _ := ParseHDPath("m/44'/501'/0'/{i}")
+	if _, err := NewHDDeriver(DerivationSLIP10, path, seed); err == nil {
+		t.Error("Expected an error for non-hardened ed25519 derivation")
+	}
+	path, _ = ParseHDPath("m/44'/60'/0'/0/{i}")
+	if _, err := NewHDDeriver(DerivationBIP32, path, seed[:15]); err == nil {
+		t.Error("Expected an error for a 15-byte seed")
+	}
+
+	// Indices past the last path component have no key
+	d, err := NewHDDeriver(DerivationBIP32, path, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := [32]byte{1}
+	d.Derive(MaxHDIndex+1, &key)
+	if key != [32]byte{} {
+		t.Errorf("Expected no key past index %d, got %x", MaxHDIndex, key)
+	}
+}
+
+func TestPipelineDerivationPath(t *testing.T) {
+	seed, err := MnemonicToSeed(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Pipeline{Network: Ethereum, DerivationPath: "m/44'/60'/0'/0/{i}", MasterSeed: seed, Workers: 3, BatchSize: 2}
+	var addresses []Address
+	err = p.Run(context.Background(), 0, 5, func(r Result) error {
+		addresses = append(addresses, r.Address)
+		return r.Err
+	})
+	if err != nil || len(addresses) != 5 || addresses[0] != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Errorf("Unexpected addresses %v (%v)", addresses, err)
+	}
+
+	if err := p.Run(context.Background(), MaxHDIndex, 2, func(Result) error { return nil }); err == nil {
+		t.Error("Expected an error for indices past the end of the path")
+	}
+}
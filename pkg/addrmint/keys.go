@@ -0,0 +1,53 @@
+package addrmint
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PublicKey returns the public key behind the address of seed on network:
+// the uncompressed secp256k1 point for Ethereum, the compressed point for
+// Bitcoin and the Ed25519 public key for Solana and TON
+func PublicKey(network string, seed []byte) ([]byte, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return nil, err
+	}
+	switch network {
+	case Ethereum:
+		privateKey, err := crypto.ToECDSA(seed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create private key: %w", err)
+		}
+		return crypto.FromECDSAPub(&privateKey.PublicKey), nil
+	case Bitcoin:
+		privKey, pubKey := btcec.PrivKeyFromBytes(seed)
+		if privKey.Key.IsZero() {
+			return nil, ErrZeroPrivateKey
+		}
+		return pubKey.SerializeCompressed(), nil
+	case Solana, TON:
+		return ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey), nil
+	}
+	return nil, fmt.Errorf("unsupported network: %s", network)
+}
+
+// WIF encodes seed as the Wallet Import Format private key of the
+// compressed public key that Bitcoin addresses on chain are derived from
+func WIF(seed []byte, chain string) (string, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return "", err
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(seed)
+	if privKey.Key.IsZero() {
+		return "", ErrZeroPrivateKey
+	}
+	wif, err := btcutil.NewWIF(privKey, BitcoinParams(chain), true)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode WIF: %w", err)
+	}
+	return wif.String(), nil
+}
@@ -0,0 +1,69 @@
+package addrmint
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestPublicKey(t *testing.T) {
+	var one [32]byte
+	one[31] = 1
+	// RFC 8032 test vector 1
+	ed25519Seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+
+	tests := []struct {
+		network string
+		seed    []byte
+		want    string
+	}{
+		{Ethereum, one[:], "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"},
+		{Bitcoin, one[:], "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+		{Solana, ed25519Seed, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+		{TON, ed25519Seed, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"},
+	}
+	for _, tt := range tests {
+		key, err := PublicKey(tt.network, tt.seed)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.network, err)
+		}
+		if got := hex.EncodeToString(key); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.network, tt.want, got)
+		}
+	}
+
+	var zero [32]byte
+	for _, network := range []string{Ethereum, Bitcoin} {
+		if _, err := PublicKey(network, zero[:]); err == nil {
+			t.Errorf("%s: expected an error for the zero key", network)
+		}
+	}
+	if _, err := PublicKey("dogecoin", one[:]); err == nil {
+		t.Error("Expected an error for an unsupported network")
+	}
+}
+
+func TestWIF(t *testing.T) {
+	var one [32]byte
+	one[31] = 1
+	tests := []struct {
+		chain string
+		want  string
+	}{
+		{ChainMainnet, "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn"},
+		{ChainTestnet, "cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA"},
+	}
+	for _, tt := range tests {
+		wif, err := WIF(one[:], tt.chain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wif != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.chain, tt.want, wif)
+		}
+	}
+
+	var zero [32]byte
+	if _, err := WIF(zero[:], ChainMainnet); err == nil {
+		t.Error("Expected an error for the zero key")
+	}
+}
@@ -0,0 +1,422 @@
+package addrmint
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultBatchSize is the number of indices a pipeline worker takes at once
+const defaultBatchSize = 1000
+
+// Result is the address derived for one index
+type Result struct {
+	Index   int
+	Seed    [32]byte
+	Address Address
+	Err     error // Set when the seed has no address, such as ErrZeroPrivateKey
+	Worker  int   // Worker that generated the address, numbered from 1
+	Value   any   // Whatever the worker's processor attached, if any
+}
+
+// Pipeline generates the addresses of a range of indices on a pool of
+// workers and delivers them in index order. Each worker has its own
+// generator and seed deriver, and takes BatchSize consecutive indices at a
+// time. At most Backlog batches are in flight, so memory stays bounded
+// however far ahead the fastest workers get.
+type Pipeline struct {
+	Network   string
+	Options   Options
+	Scheme    string // Seed derivation scheme, DerivationV1 when empty
+	BaseSeed  string
+	Workers   int // Number of workers, GOMAXPROCS when zero
+	BatchSize int // Indices per batch, 1000 when zero
+	Backlog   int // Batches in flight, at least one and two per worker when zero
+
+	// DerivationPath, when set, derives each index along this HD path
+	// from MasterSeed instead of from Scheme and BaseSeed
+	DerivationPath string
+	MasterSeed     []byte
+
+	// Indices, when set, lists the indices to generate. Run's offset and
+	// count then select positions in the list rather than indices.
+	Indices []int
+
+	// NewGenerator and NewSeeds, when set, create each worker's generator
+	// and seed deriver in place of New and the fields above. Generators
+	// that are io.Closers are closed when their worker exits.
+	NewGenerator func() (Generator, error)
+	NewSeeds     func() *SeedDeriver
+
+	// NewProcessor, when set, creates a function that each worker calls on
+	// its results before they are emitted, for work that should run in
+	// parallel too. It may set the result's Err and Value.
+	NewProcessor func(worker int) func(*Result)
+
+	// RunWorker, when set, runs the work of each worker on that worker's
+	// goroutine, e.g. to pin it to a CPU or to recover its panics
+	RunWorker func(worker int, work func())
+
+	// OnHung, when set, is called for each worker that has spent
+	// HungTimeout on one index. A goroutine stuck in a cgo call or a
+	// syscall cannot be interrupted, so when OnHung returns true a new
+	// worker takes over the hung one's batch and generates the index
+	// again; the hung goroutine drops its result if it ever returns. An
+	// index is retried once: if the replacement hangs on it too, the run
+	// fails rather than abandoning goroutines forever.
+	HungTimeout time.Duration
+	OnHung      func(worker, index int, busy time.Duration) bool
+}
+
+// batch is a run of consecutive positions handed to one worker
+type batch struct {
+	n       int
+	results []Result
+}
+
+// Run calls emit for the count indices starting at offset, in order. Failed
+// addresses are passed to emit with Err set; returning an error from emit
+// stops the run and Run returns that error. Cancelling ctx stops the run
+// too.
+func (p *Pipeline) Run(ctx context.Context, offset, count int, emit func(Result) error) error {
+	if p.NewGenerator == nil {
+		if _, err := New(p.Network, p.Options); err != nil {
+			return err
+		}
+	}
+	if offset < 0 || count < 0 {
+		return errors.New("offset and count cannot be negative")
+	}
+	if p.HungTimeout < 0 {
+		return errors.New("HungTimeout cannot be negative")
+	}
+	if p.Indices != nil && offset+count > len(p.Indices) {
+		return fmt.Errorf("positions %d to %d are past the %d listed indices", offset, offset+count-1, len(p.Indices))
+	}
+	workers := cmp.Or(p.Workers, runtime.GOMAXPROCS(0))
+	batchSize := cmp.Or(p.BatchSize, defaultBatchSize)
+	backlog := max(cmp.Or(p.Backlog, 2*workers), 1)
+	scheme := cmp.Or(p.Scheme, DerivationV1)
+	var hd *HDDeriver
+	switch {
+	case p.NewSeeds != nil:
+	case p.DerivationPath == "":
+		if _, err := NewSeedDeriver(scheme, p.BaseSeed); err != nil {
+			return err
+		}
+	default:
+		path, err := ParseHDPath(p.DerivationPath)
+		if err != nil {
+			return err
+		}
+		if hd, err = NewHDDeriver(HDScheme(p.Network), path, p.MasterSeed); err != nil {
+			return err
+		}
+		last := offset + count - 1
+		if p.Indices != nil && count > 0 {
+			last = slices.Max(p.Indices[offset : offset+count])
+		}
+		if last > MaxHDIndex {
+			return fmt.Errorf("HD paths end at index %d", MaxHDIndex)
+		}
+	}
+	newSeeds := p.NewSeeds
+	if newSeeds == nil {
+		newSeeds = func() *SeedDeriver {
+			if hd != nil {
+				return NewHDSeedDeriver(hd)
+			}
+			seeds, _ := NewSeedDeriver(scheme, p.BaseSeed)
+			return seeds
+		}
+	}
+	newGenerator := p.NewGenerator
+	if newGenerator == nil {
+		newGenerator = func() (Generator, error) {
+			return New(p.Network, p.Options)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The first worker that cannot start stops the run
+	var failOnce sync.Once
+	var failed error
+	fail := func(err error) {
+		failOnce.Do(func() {
+			failed = err
+			cancel()
+		})
+	}
+
+	batches := make(chan int)
+	done := make(chan *batch)
+	inFlight := make(chan struct{}, backlog)
+
+	// Hand out batch numbers, waiting while too many are in flight
+	go func() {
+		defer close(batches)
+		for n := 0; n*batchSize < count; n++ {
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case batches <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// work runs one worker until the batches run out, reporting false if
+	// its slot was handed to a replacement instead
+	work := func(slot *workerSlot) bool {
+		generator, err := newGenerator()
+		if err != nil {
+			fail(err)
+			return true
+		}
+		if closer, ok := generator.(io.Closer); ok {
+			defer closer.Close()
+		}
+		seeds := newSeeds()
+		var process func(*Result)
+		if p.NewProcessor != nil {
+			process = p.NewProcessor(slot.worker)
+		}
+
+		// A replacement starts where the hung worker stopped
+		b, pos := slot.resume()
+		for {
+			if b == nil {
+				n, ok := <-batches
+				if !ok {
+					return true
+				}
+				first := n * batchSize
+				b, pos = &batch{n: n, results: make([]Result, min(batchSize, count-first))}, 0
+			}
+			for ; pos < len(b.results); pos++ {
+				position := offset + b.n*batchSize + pos
+				r := Result{Index: position, Worker: slot.worker}
+				if p.Indices != nil {
+					r.Index = p.Indices[position]
+				}
+				gen := slot.begin(b, pos, r.Index)
+				seeds.Derive(r.Index, &r.Seed)
+				r.Address, r.Err = generator.Generate(r.Seed[:])
+				if process != nil {
+					process(&r)
+				}
+				if !slot.finish(gen) {
+					return false
+				}
+				b.results[pos] = r
+			}
+			select {
+			case done <- b:
+			case <-ctx.Done():
+				return true
+			}
+			b = nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	start := func(slot *workerSlot) {
+		go func() {
+			run := func() {
+				if work(slot) {
+					wg.Done()
+				}
+			}
+			if p.RunWorker != nil {
+				p.RunWorker(slot.worker, run)
+			} else {
+				run()
+			}
+		}()
+	}
+	slots := make([]*workerSlot, workers)
+	for w := range slots {
+		slots[w] = &workerSlot{worker: w + 1}
+		wg.Add(1)
+		start(slots[w])
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Watch for workers stuck on one index
+	if p.OnHung != nil && p.HungTimeout > 0 {
+		stop := make(chan struct{})
+		var watching sync.WaitGroup
+		watching.Add(1)
+		defer func() {
+			close(stop)
+			watching.Wait()
+		}()
+		go func() {
+			defer watching.Done()
+			p.watch(slots, start, fail, stop)
+		}()
+	}
+
+	// Batches finish out of order and wait here for their turn. A failed
+	// run does not wait for hung workers, which may never return.
+	pending := make(map[int][]Result)
+	next := 0
+	for {
+		var b *batch
+		var ok bool
+		select {
+		case b, ok = <-done:
+		case <-ctx.Done():
+		}
+		if !ok {
+			break
+		}
+		pending[b.n] = b.results
+		for results, ok := pending[next]; ok; results, ok = pending[next] {
+			delete(pending, next)
+			next++
+			for _, r := range results {
+				if err := emit(r); err != nil {
+					return err
+				}
+			}
+			<-inFlight
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+	return ctx.Err()
+}
+
+// watch reports workers that spend longer than HungTimeout on one index
+// until stop is closed, starting a replacement when OnHung asks for one.
+// A replacement that hangs on the same index fails the run.
+func (p *Pipeline) watch(slots []*workerSlot, start func(*workerSlot), fail func(error), stop <-chan struct{}) {
+	// Check a few times per timeout, but no more often than every
+	// millisecond, as tiny timeouts would make the interval zero
+	ticker := time.NewTicker(max(min(p.HungTimeout/4, time.Second), time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			for _, slot := range slots {
+				index, busy, gen, retried, hung := slot.checkHung(now, p.HungTimeout)
+				if !hung || !p.OnHung(slot.worker, index, busy) {
+					continue
+				}
+				if retried {
+					fail(fmt.Errorf("worker %d hung on index %d again after a replacement took it over", slot.worker, index))
+					return
+				}
+				if slot.handOver(gen) {
+					start(slot)
+				}
+			}
+		}
+	}
+}
+
+// workerSlot is the state of one worker that the hung-worker watchdog can
+// see. When a worker hangs, a replacement takes over its slot, along with
+// its batch and the position it was stuck on.
+type workerSlot struct {
+	worker int
+
+	mu        sync.Mutex
+	gen       int       // Incremented when the slot is handed to a replacement
+	busySince time.Time // When the current index was started, zero while waiting
+	index     int       // Index the worker is on
+	batch     *batch    // Batch the worker is filling
+	pos       int       // Position of index in batch
+	reported  bool      // Whether the current index was reported as hung
+	takeover  bool      // Whether a replacement should resume batch at pos
+	retried   bool      // Whether the current index was handed to a replacement before
+}
+
+// begin records that the worker started on the index at pos in b, and
+// returns the generation to pass to finish
+func (s *workerSlot) begin(b *batch, pos, index int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b != s.batch || pos != s.pos {
+		s.retried = false
+	}
+	s.batch, s.pos, s.index = b, pos, index
+	s.busySince = time.Now()
+	s.reported = false
+	return s.gen
+}
+
+// finish records that the worker is done with its index. It reports false
+// when the slot was handed to a replacement meanwhile, in which case the
+// worker must drop its result and exit.
+func (s *workerSlot) finish(gen int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen != s.gen {
+		return false
+	}
+	s.busySince = time.Time{}
+	return true
+}
+
+// resume returns the batch and position left by a hung worker, for its
+// replacement to pick up, or nil for a fresh slot
+func (s *workerSlot) resume() (*batch, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.takeover {
+		return nil, 0
+	}
+	s.takeover = false
+	return s.batch, s.pos
+}
+
+// checkHung reports whether the worker has spent at least timeout on its
+// current index, returning the index, the time spent on it, the
+// generation to pass to handOver and whether a replacement already took
+// the index over. Each attempt at an index is reported once.
+func (s *workerSlot) checkHung(now time.Time, timeout time.Duration) (int, time.Duration, int, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	busy := now.Sub(s.busySince)
+	if s.busySince.IsZero() || busy < timeout || s.reported {
+		return 0, 0, 0, false, false
+	}
+	s.reported = true
+	return s.index, busy, s.gen, s.retried, true
+}
+
+// handOver marks the slot for a replacement worker to take over. It reports
+// false if the worker got past the reported index meanwhile, in which case
+// there is nothing to take over.
+func (s *workerSlot) handOver(gen int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen != s.gen || !s.reported || s.busySince.IsZero() {
+		return false
+	}
+	s.gen++
+	s.takeover = true
+	s.retried = true
+	s.busySince = time.Time{}
+	return true
+}
@@ -0,0 +1,237 @@
+package addrmint
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	p := &Pipeline{Network: Ethereum, Options: Options{HashBackend: HashBackendReused}, Scheme: DerivationV2, BaseSeed: testBaseSeed, Workers: 4, BatchSize: 7}
+	seeds := mustSeedDeriver(t, DerivationV2, testBaseSeed)
+	next := 100
+	err := p.Run(context.Background(), 100, 250, func(r Result) error {
+		if r.Index != next {
+			t.Fatalf("Expected index %d, got %d", next, r.Index)
+		}
+		next++
+		var seed [32]byte
+		seeds.Derive(r.Index, &seed)
+		want, err := EthereumGenerator{}.Generate(seed[:])
+		if r.Err != nil || err != nil || r.Address != want || r.Seed != seed {
+			t.Errorf("Index %d: expected %s, got %s (%v)", r.Index, want, r.Address, r.Err)
+		}
+		return nil
+	})
+	if err != nil || next != 350 {
+		t.Errorf("Expected indices 100 to 349, stopped at %d: %v", next, err)
+	}
+
+	// Errors from emit and cancellation stop the run
+	stop := errors.New("stop")
+	emitted := 0
+	err = p.Run(context.Background(), 0, 10000, func(Result) error {
+		emitted++
+		if emitted == 20 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || emitted != 20 {
+		t.Errorf("Expected the run to stop after 20 results, got %d: %v", emitted, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	err = p.Run(ctx, 0, 10000, func(Result) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled run to fail, got %v", err)
+	}
+
+	if err := (&Pipeline{Network: "dogecoin"}).Run(context.Background(), 0, 1, nil); err == nil {
+		t.Error("Expected an error for an unknown network")
+	}
+	if err := (&Pipeline{Network: Ethereum, Scheme: "v3"}).Run(context.Background(), 0, 1, nil); err == nil {
+		t.Error("Expected an error for an unknown derivation scheme")
+	}
+}
+
+// closingGenerator counts the generators closed by their workers
+type closingGenerator struct {
+	Generator
+	closed *atomic.Int32
+}
+
+func (g closingGenerator) Close() error {
+	g.closed.Add(1)
+	return nil
+}
+
+func TestPipelineHooks(t *testing.T) {
+	var closed, started atomic.Int32
+	p := &Pipeline{
+		Workers:   3,
+		BatchSize: 4,
+		Indices:   []int{2, 7, 8, 40, 41, 42, 99, 1000, 1001, 5000},
+		NewGenerator: func() (Generator, error) {
+			return closingGenerator{SolanaGenerator{}, &closed}, nil
+		},
+		NewSeeds: func() *SeedDeriver {
+			return mustSeedDeriver(t, DerivationV2, testBaseSeed)
+		},
+		NewProcessor: func(worker int) func(*Result) {
+			return func(r *Result) {
+				r.Value = worker
+			}
+		},
+		RunWorker: func(worker int, work func()) {
+			started.Add(1)
+			work()
+		},
+	}
+
+	// Offset and count select positions of the indices list
+	seeds := mustSeedDeriver(t, DerivationV2, testBaseSeed)
+	var got []int
+	err := p.Run(context.Background(), 1, 8, func(r Result) error {
+		got = append(got, r.Index)
+		var seed [32]byte
+		seeds.Derive(r.Index, &seed)
+		want, _ := SolanaGenerator{}.Generate(seed[:])
+		if r.Address != want || r.Worker < 1 || r.Worker > 3 || r.Value != r.Worker {
+			t.Errorf("Index %d: expected %s from a worker's processor, got %s from %d with %v", r.Index, want, r.Address, r.Worker, r.Value)
+		}
+		return nil
+	})
+	if err != nil || !slices.Equal(got, p.Indices[1:9]) {
+		t.Errorf("Expected indices %v, got %v: %v", p.Indices[1:9], got, err)
+	}
+	if started.Load() != 3 || closed.Load() != 3 {
+		t.Errorf("Expected 3 workers to start and close their generators, got %d and %d", started.Load(), closed.Load())
+	}
+	if err := p.Run(context.Background(), 5, 6, nil); err == nil {
+		t.Error("Expected an error for positions past the indices list")
+	}
+
+	// A worker that cannot create its generator stops the run
+	broken := errors.New("no plugin")
+	p.NewGenerator = func() (Generator, error) {
+		return nil, broken
+	}
+	if err := p.Run(context.Background(), 0, 10, func(Result) error { return nil }); !errors.Is(err, broken) {
+		t.Errorf("Expected the generator error, got %v", err)
+	}
+}
+
+// TestPipelineHungWorker hangs a worker on one index and checks that its
+// replacement produces every index exactly once, in order
+func TestPipelineHungWorker(t *testing.T) {
+	// The first attempt at index 5 hangs until the test ends
+	release := make(chan struct{})
+	defer close(release)
+	var hangs, reports atomic.Int32
+	p := &Pipeline{
+		Network:   Ethereum,
+		Workers:   1,
+		BatchSize: 4,
+		NewProcessor: func(int) func(*Result) {
+			return func(r *Result) {
+				if r.Index == 5 && hangs.Add(1) == 1 {
+					<-release
+				}
+			}
+		},
+		HungTimeout: 20 * time.Millisecond,
+		OnHung: func(worker, index int, busy time.Duration) bool {
+			if worker != 1 || index != 5 || busy < 20*time.Millisecond {
+				t.Errorf("Unexpected report of worker %d on index %d after %s", worker, index, busy)
+			}
+			reports.Add(1)
+			return true
+		},
+	}
+
+	var got []int
+	err := p.Run(context.Background(), 0, 10, func(r Result) error {
+		got = append(got, r.Index)
+		return nil
+	})
+	if err != nil || !slices.Equal(got, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) || reports.Load() != 1 {
+		t.Errorf("Expected indices 0 to 9 after one restart, got %v after %d: %v", got, reports.Load(), err)
+	}
+}
+
+// sleepingGenerator takes longer than any test's hung-worker timeout on
+// every index
+type sleepingGenerator struct {
+	release <-chan struct{}
+}
+
+func (g sleepingGenerator) Generate([]byte) (Address, error) {
+	<-g.release
+	return "", nil
+}
+
+func TestPipelineHungReplacement(t *testing.T) {
+	// Replacements hang on the index too, so the run fails instead of
+	// restarting workers forever
+	release := make(chan struct{})
+	defer close(release)
+	var reports atomic.Int32
+	p := &Pipeline{
+		Network: Ethereum,
+		Workers: 1,
+		NewGenerator: func() (Generator, error) {
+			return sleepingGenerator{release}, nil
+		},
+		HungTimeout: 10 * time.Millisecond,
+		OnHung: func(worker, index int, busy time.Duration) bool {
+			reports.Add(1)
+			return true
+		},
+	}
+	err := p.Run(context.Background(), 0, 4, func(Result) error {
+		t.Error("Expected no results")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "hung on index 0 again") || reports.Load() != 2 {
+		t.Errorf("Expected the run to fail at the second hang, got %d reports: %v", reports.Load(), err)
+	}
+}
+
+func TestPipelineTinyHungTimeout(t *testing.T) {
+	// Timeouts under 4ns must not make the watchdog's interval zero
+	p := &Pipeline{
+		Network:     Ethereum,
+		Workers:     1,
+		HungTimeout: 3 * time.Nanosecond,
+		OnHung:      func(int, int, time.Duration) bool { return false },
+	}
+	count := 0
+	err := p.Run(context.Background(), 0, 10, func(Result) error {
+		count++
+		return nil
+	})
+	if err != nil || count != 10 {
+		t.Errorf("Expected 10 results, got %d: %v", count, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, network := range Networks {
+		if _, err := New(network, Options{}); err != nil {
+			t.Errorf("%s: %v", network, err)
+		}
+	}
+	invalid := []Options{{Backend: "gpu"}, {HashBackend: "sha3"}}
+	for _, opts := range invalid {
+		if _, err := New(Ethereum, opts); err == nil {
+			t.Errorf("Expected an error for %+v", opts)
+		}
+	}
+}
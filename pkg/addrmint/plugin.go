@@ -0,0 +1,188 @@
+package addrmint
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Plugins are external binaries that derive the addresses of networks
+// AddrMint does not build in, so that chains with heavy or unusual
+// dependencies can be added without linking them into AddrMint.
+//
+// The plugin of network NAME is the executable addrmint-NAME in the plugin
+// directory (addrmint-NAME.exe on Windows). It is started without
+// arguments and speaks a line protocol over stdin and stdout:
+//
+//   - On start, the plugin writes the greeting "addrmint-plugin 1".
+//   - For each address, AddrMint writes the 32-byte seed as 64 lowercase
+//     hex digits on one line.
+//   - The plugin answers each seed, in order, with "ok ADDRESS", or with
+//     "error MESSAGE" if the seed has no address.
+//   - When stdin is closed, the plugin exits.
+//
+// Anything the plugin writes to stderr is passed through. Addresses must
+// be deterministic: the same seed must always give the same address.
+const (
+	pluginPrefix   = "addrmint-"
+	pluginGreeting = "addrmint-plugin 1"
+)
+
+// pluginPath returns the path of the plugin of network in dir
+func pluginPath(dir, network string) string {
+	name := pluginPrefix + network
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(dir, name)
+}
+
+// FindPlugin returns the path of the plugin of network in dir, or an error
+// listing the plugins dir holds if there is none
+func FindPlugin(dir, network string) (string, error) {
+	if network == "" || strings.ContainsAny(network, `/\`) {
+		return "", fmt.Errorf("invalid network name %q", network)
+	}
+	path := pluginPath(dir, network)
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() && (runtime.GOOS == "windows" || info.Mode()&0111 != 0) {
+		return path, nil
+	}
+	plugins, _ := ListPlugins(dir)
+	if len(plugins) == 0 {
+		return "", fmt.Errorf("no plugin for %s: %s has no %s* executables", network, dir, pluginPrefix)
+	}
+	return "", fmt.Errorf("no plugin for %s in %s (found %s)", network, dir, strings.Join(plugins, ", "))
+}
+
+// ListPlugins returns the networks of the plugins in dir, sorted
+func ListPlugins(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var networks []string
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), pluginPrefix)
+		if runtime.GOOS == "windows" {
+			name, ok = strings.CutSuffix(name, ".exe")
+		}
+		if !ok || name == "" || entry.IsDir() {
+			continue
+		}
+		networks = append(networks, name)
+	}
+	slices.Sort(networks)
+	return networks, nil
+}
+
+// PluginGenerator derives addresses by running a plugin binary. Each
+// generator runs its own plugin process, so give each worker its own, and
+// Close it when done.
+type PluginGenerator struct {
+	path   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	in     *bufio.Writer
+	out    *bufio.Reader
+	line   []byte
+	broken error // Set once the process can no longer be talked to
+}
+
+// StartPlugin starts the plugin at path and waits for its greeting
+func StartPlugin(path string) (*PluginGenerator, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+	p := &PluginGenerator{
+		path:  path,
+		cmd:   cmd,
+		stdin: stdin,
+		in:    bufio.NewWriter(stdin),
+		out:   bufio.NewReader(stdout),
+		line:  make([]byte, 0, 2*32+1),
+	}
+	greeting, err := p.readLine()
+	if err == nil && greeting != pluginGreeting {
+		err = fmt.Errorf("unexpected greeting %q, want %q", greeting, pluginGreeting)
+	}
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// readLine reads one line of the plugin's output without its newline
+func (p *PluginGenerator) readLine() (string, error) {
+	line, err := p.out.ReadString('\n')
+	if err == io.EOF {
+		return "", errors.New("plugin exited")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Generate sends seed to the plugin and returns the address it answers
+// with. Errors the plugin reports for a seed leave the plugin usable;
+// any other failure breaks it for good.
+func (p *PluginGenerator) Generate(seed []byte) (Address, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return "", err
+	}
+	if p.broken != nil {
+		return "", p.broken
+	}
+
+	p.line = hex.AppendEncode(p.line[:0], seed)
+	p.line = append(p.line, '\n')
+	p.in.Write(p.line)
+	if err := p.in.Flush(); err != nil {
+		p.broken = fmt.Errorf("plugin %s: %w", p.path, err)
+		return "", p.broken
+	}
+	reply, err := p.readLine()
+	if err != nil {
+		p.broken = fmt.Errorf("plugin %s: %w", p.path, err)
+		return "", p.broken
+	}
+	status, value, _ := strings.Cut(reply, " ")
+	switch {
+	case status == "ok" && value != "":
+		return Address(value), nil
+	case status == "error":
+		return "", fmt.Errorf("plugin %s: %s", filepath.Base(p.path), value)
+	}
+	p.broken = fmt.Errorf("plugin %s: invalid reply %q", p.path, reply)
+	return "", p.broken
+}
+
+// Close closes the plugin's stdin and waits for it to exit
+func (p *PluginGenerator) Close() error {
+	p.stdin.Close()
+	err := p.cmd.Wait()
+	if p.broken == nil {
+		p.broken = errors.New("plugin closed")
+	}
+	return err
+}
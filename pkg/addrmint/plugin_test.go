@@ -0,0 +1,85 @@
+package addrmint
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testPluginScript answers with the first 8 hex digits of each seed, and
+// rejects seeds starting with a zero byte
+const testPluginScript = `#!/bin/sh
+echo "addrmint-plugin 1"
+while read -r seed; do
+	case "$seed" in
+	00*) echo "error zero byte" ;;
+	*) echo "ok test1$(echo "$seed" | cut -c1-8)" ;;
+	esac
+done
+`
+
+// writeTestPlugin writes a shell script plugin of network to dir
+func writeTestPlugin(t *testing.T, dir, network, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("Test plugins are shell scripts")
+	}
+	if err := os.WriteFile(filepath.Join(dir, pluginPrefix+network), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPluginGenerator(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPlugin(t, dir, "testchain", testPluginScript)
+	writeTestPlugin(t, dir, "mute", "#!/bin/sh\nexit 0\n")
+
+	path, err := FindPlugin(dir, "testchain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := StartPlugin(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := make([]byte, 32)
+	seed[0], seed[1], seed[2], seed[3] = 0xde, 0xad, 0xbe, 0xef
+	for i := 0; i < 3; i++ {
+		if addr, err := p.Generate(seed); err != nil || addr != "test1deadbeef" {
+			t.Errorf("Expected test1deadbeef, got %s (%v)", addr, err)
+		}
+	}
+	// A rejected seed leaves the plugin usable
+	seed[0] = 0
+	if _, err := p.Generate(seed); err == nil || !strings.Contains(err.Error(), "zero byte") {
+		t.Errorf("Expected the plugin's error, got %v", err)
+	}
+	seed[0] = 0xde
+	if addr, err := p.Generate(seed); err != nil || addr != "test1deadbeef" {
+		t.Errorf("Expected test1deadbeef after an error, got %s (%v)", addr, err)
+	}
+	if _, err := p.Generate(seed[:16]); err == nil {
+		t.Error("Expected an error for a short seed")
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Expected the plugin to exit cleanly, got %v", err)
+	}
+	if _, err := p.Generate(seed); err == nil {
+		t.Error("Expected an error after Close")
+	}
+
+	if _, err := StartPlugin(filepath.Join(dir, pluginPrefix+"mute")); err == nil {
+		t.Error("Expected an error for a plugin without a greeting")
+	}
+	if networks, _ := ListPlugins(dir); !slices.Equal(networks, []string{"mute", "testchain"}) {
+		t.Errorf("Expected mute and testchain, got %v", networks)
+	}
+	for _, network := range []string{"missing", "../testchain", ""} {
+		if _, err := FindPlugin(dir, network); err == nil {
+			t.Errorf("Expected no plugin for %q", network)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package addrmint
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strconv"
+)
+
+// Seed derivation schemes. The scheme is part of what makes a corpus
+// reproducible, so a scheme never changes once released; changes get a new
+// version instead.
+const (
+	DerivationV1 = "v1" // SHA-256(base seed || decimal index), the original scheme
+	DerivationV2 = "v2" // HKDF-SHA256 keyed by the base seed, expanded per index
+)
+
+// DerivationIndex names the seeds of NewIndexSeedDeriver, which are the
+// indices themselves. It is not a key derivation scheme and is not listed
+// in DerivationSchemes.
+const DerivationIndex = "index"
+
+// DerivationSchemes lists the seed derivation schemes
+var DerivationSchemes = []string{DerivationV1, DerivationV2}
+
+// derivationV2Salt is the HKDF salt of scheme v2. It separates v2 keys from
+// any other use of the same base seed.
+const derivationV2Salt = "addrmint/seed-derivation/v2"
+
+// SeedDeriver derives the 32-byte seed of each address index from the base
+// seed of a run.
+//
+// Scheme v1 hashes the base seed followed by the decimal index:
+//
+//	seed(i) = SHA-256(baseSeed || decimal(i))
+//
+// Scheme v2 is HKDF-SHA256 (RFC 5869) used as a PRF in counter mode. The
+// base seed is extracted once into a pseudorandom key, and each index is
+// expanded with its 8-byte big-endian encoding as the info:
+//
+//	prk     = HMAC-SHA256(derivationV2Salt, baseSeed)
+//	seed(i) = HKDF-Expand(prk, uint64be(i), 32) = HMAC-SHA256(prk, uint64be(i) || 0x01)
+//
+// Seeds of different indices are independent under the PRF assumption on
+// HMAC-SHA256, and anyone holding the base seed can recompute any single
+// index without the others. A SeedDeriver reuses scratch state, so each
+// goroutine needs its own.
+//
+// A SeedDeriver created by NewHDSeedDeriver derives along an HD path
+// instead, and one created by NewIndexSeedDeriver writes the index itself.
+type SeedDeriver struct {
+	scheme   string
+	baseSeed string
+	hd       *HDDeriver
+	buf      []byte    // Scratch input for v1
+	prf      hash.Hash // HMAC keyed with the v2 pseudorandom key
+	info     [9]byte   // Index and HKDF block counter for v2
+	sum      []byte
+}
+
+// NewSeedDeriver creates a deriver for a scheme from DerivationSchemes.
+// Any other scheme is an error, since it would silently give other keys.
+func NewSeedDeriver(scheme, baseSeed string) (*SeedDeriver, error) {
+	d := &SeedDeriver{scheme: scheme, baseSeed: baseSeed}
+	switch scheme {
+	case DerivationV1:
+		d.buf = make([]byte, 0, len(baseSeed)+20)
+	case DerivationV2:
+		extract := hmac.New(sha256.New, []byte(derivationV2Salt))
+		extract.Write([]byte(baseSeed))
+		d.prf = hmac.New(sha256.New, extract.Sum(nil))
+		d.info[8] = 1 // A single 32-byte HKDF block
+		d.sum = make([]byte, 0, sha256.Size)
+	default:
+		return nil, fmt.Errorf("unsupported derivation scheme: %q", scheme)
+	}
+	return d, nil
+}
+
+// NewHDSeedDeriver creates a deriver that gives each index the key of an
+// HD path, using its own copy of hd's scratch state
+func NewHDSeedDeriver(hd *HDDeriver) *SeedDeriver {
+	return &SeedDeriver{scheme: hd.scheme, hd: hd.Clone()}
+}
+
+// NewIndexSeedDeriver creates a deriver whose seed of each index is the
+// index as a big-endian 256-bit number. Such seeds are public, so they are
+// only for generators that take numbers rather than keys, such as the
+// nonces and salts of ContractGenerator.
+func NewIndexSeedDeriver() *SeedDeriver {
+	return &SeedDeriver{scheme: DerivationIndex}
+}
+
+// Derive computes the seed of index into out, without allocating except
+// for HD paths
+func (d *SeedDeriver) Derive(index int, out *[32]byte) {
+	if d.scheme == DerivationIndex {
+		*out = [32]byte{}
+		binary.BigEndian.PutUint64(out[24:], uint64(index))
+		return
+	}
+	if d.hd != nil {
+		d.hd.Derive(index, out)
+		return
+	}
+	if d.prf == nil {
+		d.buf = DeriveSeed(d.buf, d.baseSeed, index, out)
+		return
+	}
+	binary.BigEndian.PutUint64(d.info[:8], uint64(index))
+	d.prf.Reset()
+	d.prf.Write(d.info[:])
+	d.sum = d.prf.Sum(d.sum[:0])
+	copy(out[:], d.sum)
+}
+
+// DeriveSeed computes the v1 seed SHA-256(baseSeed || decimal index) into
+// out without allocating. The scratch buffer is returned so callers can keep
+// reusing it.
+func DeriveSeed(buf []byte, baseSeed string, index int, out *[32]byte) []byte {
+	buf = append(buf[:0], baseSeed...)
+	buf = strconv.AppendInt(buf, int64(index), 10)
+	*out = sha256.Sum256(buf)
+	return buf
+}
+
+// LinkedSeed derives the seed of the n-th item with the given role, such as
+// an associated account or a label, from the seed of the address it belongs
+// to: SHA-256(seed || role || decimal n)
+func LinkedSeed(seed *[32]byte, role string, n int) [32]byte {
+	buf := make([]byte, 0, len(seed)+len(role)+20)
+	buf = append(buf, seed[:]...)
+	buf = append(buf, role...)
+	buf = strconv.AppendInt(buf, int64(n), 10)
+	return sha256.Sum256(buf)
+}
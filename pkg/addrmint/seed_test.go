@@ -0,0 +1,91 @@
+package addrmint
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// mustSeedDeriver creates the deriver of a known scheme
+func mustSeedDeriver(tb testing.TB, scheme, baseSeed string) *SeedDeriver {
+	d, err := NewSeedDeriver(scheme, baseSeed)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return d
+}
+
+func TestSeedDeriver(t *testing.T) {
+	v1 := mustSeedDeriver(t, DerivationV1, testBaseSeed)
+	v2 := mustSeedDeriver(t, DerivationV2, testBaseSeed)
+	prk, err := hkdf.Extract(sha256.New, []byte(testBaseSeed), []byte(derivationV2Salt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf []byte
+	for _, index := range []int{0, 1, 7, 1 << 40} {
+		// v1 is the original scheme
+		var got, want [32]byte
+		v1.Derive(index, &got)
+		buf = DeriveSeed(buf, testBaseSeed, index, &want)
+		if got != want {
+			t.Errorf("v1 index %d: expected %x, got %x", index, want, got)
+		}
+
+		// v2 is plain HKDF-SHA256 with the index as the info
+		info := binary.BigEndian.AppendUint64(nil, uint64(index))
+		expanded, err := hkdf.Expand(sha256.New, prk, string(info), 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v2.Derive(index, &got)
+		if hex.EncodeToString(got[:]) != hex.EncodeToString(expanded) {
+			t.Errorf("v2 index %d: expected %x, got %x", index, expanded, got)
+		}
+	}
+
+	// Pin v2 so that its seeds never change
+	var seed [32]byte
+	v2.Derive(42, &seed)
+	if got, want := hex.EncodeToString(seed[:]), "ff39a17702a98345ae130b0c5652178ad53f499aedb6d4b68b7b17dec1eb613a"; got != want {
+		t.Errorf("v2 index 42: expected %s, got %s", want, got)
+	}
+
+	index := 0
+	allocs := testing.AllocsPerRun(1000, func() {
+		v2.Derive(index, &seed)
+		index++
+	})
+	if allocs != 0 {
+		t.Errorf("Expected v2 derivation to perform 0 allocations, got %.1f", allocs)
+	}
+}
+
+// BenchmarkSeedDerivers measures per-index seed derivation of each scheme
+func BenchmarkSeedDerivers(b *testing.B) {
+	path, _ := ParseHDPath("m/44'/60'/0'/0/{i}")
+	hd, err := NewHDDeriver(DerivationBIP32, path, make([]byte, 32))
+	if err != nil {
+		b.Fatal(err)
+	}
+	derivers := []struct {
+		name    string
+		deriver *SeedDeriver
+	}{
+		{DerivationV1, mustSeedDeriver(b, DerivationV1, "c8c5e5a7f326a2b5")},
+		{DerivationV2, mustSeedDeriver(b, DerivationV2, "c8c5e5a7f326a2b5")},
+		{DerivationBIP32, NewHDSeedDeriver(hd)},
+	}
+	for _, d := range derivers {
+		b.Run(d.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var seed [32]byte
+			for i := 0; i < b.N; i++ {
+				d.deriver.Derive(i, &seed)
+			}
+		})
+	}
+}
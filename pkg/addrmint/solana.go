@@ -0,0 +1,51 @@
+package addrmint
+
+import (
+	"crypto/sha512"
+	"fmt"
+
+	"filippo.io/edwards25519"
+	"github.com/blocto/solana-go-sdk/types"
+	"github.com/mr-tron/base58"
+)
+
+// SolanaGenerator derives Solana addresses with the Solana SDK, using the
+// seed as the Ed25519 private key seed
+type SolanaGenerator struct{}
+
+// Generate derives the address of seed
+func (SolanaGenerator) Generate(seed []byte) (Address, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return "", err
+	}
+
+	// Use seed bytes as private key
+	account, err := types.AccountFromSeed(seed)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Solana account: %w", err)
+	}
+	return Address(account.PublicKey.ToBase58()), nil
+}
+
+// SolanaNativeGenerator derives the same addresses as SolanaGenerator
+// directly on the edwards25519 curve, avoiding the allocations of the SDK
+// account types
+type SolanaNativeGenerator struct{}
+
+// Generate derives the address of seed
+func (SolanaNativeGenerator) Generate(seed []byte) (Address, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return "", err
+	}
+
+	// Expand the seed and clamp the scalar as specified by RFC 8032
+	digest := sha512.Sum512(seed)
+	scalar, err := edwards25519.NewScalar().SetBytesWithClamping(digest[:32])
+	if err != nil {
+		return "", fmt.Errorf("failed to create Solana scalar: %w", err)
+	}
+
+	// Public key is the scalar multiplied by the base point
+	pubKey := new(edwards25519.Point).ScalarBaseMult(scalar)
+	return Address(base58.Encode(pubKey.Bytes())), nil
+}
@@ -0,0 +1,50 @@
+package addrmint
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+// TONGenerator derives non-bounceable addresses of V5R1 wallets, the most
+// common modern TON wallet, using the seed as the Ed25519 private key seed.
+// Mainnet addresses start with UQ; with Testnet set, the wallet is derived
+// for the testnet and its testnet-only addresses start with 0Q.
+type TONGenerator struct {
+	Testnet bool
+}
+
+// V5R1 wallets commit to the network's global ID. The configs are held as
+// interface values so that passing them does not allocate per address.
+var (
+	tonMainnetConfig wallet.VersionConfig = wallet.ConfigV5R1Final{NetworkGlobalID: wallet.MainnetGlobalID, Workchain: 0}
+	tonTestnetConfig wallet.VersionConfig = wallet.ConfigV5R1Final{NetworkGlobalID: wallet.TestnetGlobalID, Workchain: 0}
+)
+
+// Generate derives the address of seed
+func (g TONGenerator) Generate(seed []byte) (Address, error) {
+	if err := checkSeedLength(seed); err != nil {
+		return "", err
+	}
+
+	// Create ed25519 private key from seed
+	privKey := ed25519.NewKeyFromSeed(seed)
+	pubKey := privKey.Public().(ed25519.PublicKey)
+
+	config := tonMainnetConfig
+	if g.Testnet {
+		config = tonTestnetConfig
+	}
+	addr, err := wallet.AddressFromPubKey(pubKey, config, 0, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to create TON address: %w", err)
+	}
+
+	// Return non-bounceable user-friendly address (UQ... or 0Q... format)
+	addr = addr.Bounce(false)
+	if g.Testnet {
+		addr.SetTestnetOnly(true)
+	}
+	return Address(addr.String()), nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// Policies for --low-space when free space drops below --min-free-mb
+const lowSpacePause = "pause" // Hold the output until space is freed; onErrorAbort fails the run
+
+const (
+	estimateSampleRows = 64              // Rows generated to estimate the output size
+	diskCheckInterval  = 5 * time.Second // How often free space is checked during a run
+)
+
+// errFreeSpaceUnsupported is returned on platforms where free space can't be read
+var errFreeSpaceUnsupported = errors.New("free space is not reported on this platform")
+
+// checkWritable verifies that a file can be written at path without
+// creating or truncating it
+func checkWritable(path string) error {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		return fmt.Errorf("%s is a directory", path)
+	case err == nil:
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		return file.Close()
+	case !os.IsNotExist(err):
+		return err
+	}
+	dir := filepath.Dir(path)
+	file, err := os.CreateTemp(dir, ".addrmint-preflight-*")
+	if err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return fmt.Errorf("cannot create files in %s: %w", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// fileExists reports whether anything exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// estimateOutputBytes estimates how many bytes the run writes to the output
+// and to the --hash-map file. It generates the first rows the way the
+// workers do, from template with each row's seed filled in and passed
+// through process, formats them in columns, and scales their average size
+// up to count rows.
+func estimateOutputBytes(template Job, seeds *addrmint.SeedDeriver, count int, process func(*addrmint.Result), layout *rowLayout, columns []outputColumn, hashMap bool) (int64, int64) {
+	var (
+		generators      workerGenerators
+		rows            int
+		output, mapping int
+		line            []byte
+	)
+	defer generators.Close()
+	for i := 0; i < min(count, estimateSampleRows); i++ {
+		job := template
+		r := addrmint.Result{Index: layout.index(&Record{index: i})}
+		seeds.Derive(r.Index, &job.seed)
+		address, err := generateAddress(&job, &generators)
+		if err != nil {
+			continue
+		}
+		r.Seed, r.Address = job.seed, addrmint.Address(address)
+		if process != nil {
+			process(&r)
+		}
+		record := resultRecord(&r, template.network)
+		record.index = i
+		if record.err != nil {
+			continue
+		}
+		row, hashEnd, err := layout.appendRow(line[:0], columns, &record)
+		if err != nil {
+			continue
+		}
+		line = row
+		// Rows end in a newline, and --hash-map cuts them after the hash
+		if hashMap && hashEnd >= 0 {
+			output += hashEnd + 1
+			mapping += len(row) + 1
+		} else {
+			output += len(row) + 1
+		}
+		rows++
+	}
+	if rows == 0 {
+		return 0, 0
+	}
+	scale := float64(count) / float64(rows)
+	return int64(float64(output) * scale), int64(float64(mapping) * scale)
+}
+
+// checkFreeSpace fails if the filesystem holding path has less than need
+// bytes free on top of the reserve. Platforms that don't report free space
+// pass.
+func checkFreeSpace(path string, need, reserve int64) error {
+	dir := filepath.Dir(path)
+	free, err := freeSpace(dir)
+	if errors.Is(err, errFreeSpaceUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if free < need+reserve {
+		return fmt.Errorf("%s needs about %s MiB plus %s MiB kept free, but only %s MiB is free",
+			dir, formatCount(int(need>>20)), formatCount(int(reserve>>20)), formatCount(int(free>>20)))
+	}
+	return nil
+}
+
+// SpaceGuard passes writes through to the output while watching the free
+// space of its filesystem. Once less than the reserve is left it either
+// fails the run or, with pause, holds writes back until space is freed,
+// which stalls the workers behind the full output queue.
+type SpaceGuard struct {
+	w        io.Writer
+	dir      string
+	reserve  int64
+	pause    bool
+	interval time.Duration
+	free     func(dir string) (int64, error)
+	next     time.Time   // When free space is checked again
+	paused   atomic.Bool // Set while writes are held back
+}
+
+// NewSpaceGuard watches the filesystem holding path, checking the free space
+// every diskCheckInterval
+func NewSpaceGuard(w io.Writer, path string, reserve int64, pause bool) *SpaceGuard {
+	return &SpaceGuard{w: w, dir: filepath.Dir(path), reserve: reserve, pause: pause, interval: diskCheckInterval, free: freeSpace}
+}
+
+// Write writes p once there is enough free space
+func (g *SpaceGuard) Write(p []byte) (int, error) {
+	if now := time.Now(); now.After(g.next) {
+		g.wait()
+		g.next = time.Now().Add(g.interval)
+	}
+	return g.w.Write(p)
+}
+
+// Flush flushes the output if it buffers
+func (g *SpaceGuard) Flush() error {
+	if buffered, ok := g.w.(FlushWriter); ok {
+		return buffered.Flush()
+	}
+	return nil
+}
+
+// Paused reports whether writes are held back until space is freed
+func (g *SpaceGuard) Paused() bool {
+	return g.paused.Load()
+}
+
+// wait returns once the free space is at least the reserve, failing the
+// run instead unless the guard pauses
+func (g *SpaceGuard) wait() {
+	paused := false
+	for {
+		free, err := g.free(g.dir)
+		if err != nil || free >= g.reserve {
+			if paused {
+				fmt.Fprintf(os.Stderr, "%s MiB free on %s; resuming\n", formatCount(int(free>>20)), g.dir)
+				g.paused.Store(false)
+			}
+			return
+		}
+		if !g.pause {
+			fatalf("Only %s MiB free on %s, below --min-free-mb %s; stopping the run",
+				formatCount(int(free>>20)), g.dir, formatCount(int(g.reserve>>20)))
+		}
+		if !paused {
+			printWarning("Only %s MiB free on %s, below --min-free-mb %s; pausing until space is freed",
+				formatCount(int(free>>20)), g.dir, formatCount(int(g.reserve>>20)))
+			paused = true
+			g.paused.Store(true)
+		}
+		time.Sleep(g.interval)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "out.txt")
+	if err := checkWritable(missing); err != nil {
+		t.Errorf("Expected a new file in a writable directory to pass, got %v", err)
+	}
+	if fileExists(missing) {
+		t.Error("checkWritable created the file it checked")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("checkWritable left %d files behind", len(entries))
+	}
+
+	existing := filepath.Join(dir, "existing.txt")
+	os.WriteFile(existing, []byte("keep"), 0o644)
+	if err := checkWritable(existing); err != nil {
+		t.Errorf("Expected an existing file to pass, got %v", err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "keep" {
+		t.Error("checkWritable modified an existing file")
+	}
+
+	if err := checkWritable(dir); err == nil {
+		t.Error("Expected an error for a directory")
+	}
+	if err := checkWritable(filepath.Join(dir, "missing", "out.txt")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestEstimateOutputBytes(t *testing.T) {
+	template := Job{network: "ethereum", backend: backendSDK, hashBackend: hashBackendGeth}
+	layout := &rowLayout{}
+	output, mapping := estimateOutputBytes(template, v1Seeds(t, selftestSeed), 1000, nil, layout, layout.outputColumns(false), false)
+	if output != 1000*43 || mapping != 0 {
+		t.Errorf("Expected 43,000 bytes of Ethereum addresses, got %d and %d", output, mapping)
+	}
+	layout = &rowLayout{generateHash: true}
+	output, mapping = estimateOutputBytes(template, v1Seeds(t, selftestSeed), 1000, nil, layout, layout.outputColumns(false), true)
+	if output != 1000*7 || mapping != 1000*50 {
+		t.Errorf("Expected hashes in the output and rows in the mapping, got %d and %d", output, mapping)
+	}
+	layout = &rowLayout{hashOnly: true, indices: []int{5, 123456}}
+	output, _ = estimateOutputBytes(template, v1Seeds(t, selftestSeed), 2, nil, layout, layout.outputColumns(false), false)
+	if output != (2+64+1)+(7+64+1) {
+		t.Errorf("Expected indexed keyed hashes, got %d", output)
+	}
+}
+
+func TestSpaceGuard(t *testing.T) {
+	var out bytes.Buffer
+	free := int64(10)
+	checks := 0
+	guard := NewSpaceGuard(&out, "out.txt", 100, true)
+	guard.interval = time.Millisecond
+	guard.free = func(string) (int64, error) {
+		// Space is freed while the guard waits
+		checks++
+		if checks == 3 {
+			free = 1000
+		}
+		return free, nil
+	}
+	if _, err := guard.Write([]byte("row\n")); err != nil {
+		t.Fatal(err)
+	}
+	if checks != 3 || out.String() != "row\n" {
+		t.Errorf("Expected the write to wait for space, got %d checks and %q", checks, out.String())
+	}
+
+	// Free space isn't checked again until the interval has passed
+	guard.interval = time.Hour
+	guard.next = time.Time{}
+	guard.Write([]byte("row\n"))
+	guard.Write([]byte("row\n"))
+	if checks != 4 {
+		t.Errorf("Expected one check per interval, got %d", checks-3)
+	}
+}
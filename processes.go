@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Flags that the parent process sets itself for every shard instead of
+// forwarding the user's values
+var shardControlledFlags = map[string]bool{
+	"processes":           true,
+	"count":               true,
+	"seed":                true,
+	"output":              true,
+	"workers":             true,
+	"shard-index":         true,
+	"shard-offset":        true,
+	"mnemonic":            true,
+	"mnemonic-passphrase": true,
+	"generate-mnemonic":   true,
+	"range":               true,
+	"salt-file":           true,
+	"hash-key":            true,
+	"manifest":            true,
+	"offset-index":        true,
+}
+
+// shardSeedEnv and shardHashKeyEnv hand the base seed and the --hash-only key
+// from the parent process to its shards, keeping them out of their command
+// lines as tempKeyEnv does for the temp file key
+const (
+	shardSeedEnv    = "ADDRMINT_SHARD_SEED"
+	shardHashKeyEnv = "ADDRMINT_SHARD_HASH_KEY"
+)
+
+// shardProgressPrefix marks machine-readable progress lines emitted by shard processes
+const shardProgressPrefix = "progress "
+
+// Shard describes the contiguous index range handled by one child process
+type Shard struct {
+	index  int
+	offset int
+	count  int
+}
+
+// splitShards divides count addresses into n contiguous shards of near-equal size
+func splitShards(count, n int) []Shard {
+	shards := make([]Shard, 0, n)
+	offset := 0
+	for i := 0; i < n; i++ {
+		size := count / n
+		if i < count%n {
+			size++
+		}
+		shards = append(shards, Shard{index: i, offset: offset, count: size})
+		offset += size
+	}
+	return shards
+}
+
+// shardArgs builds the command line for a child process from the flags the
+// user set explicitly, overriding the ones that the parent controls
+func shardArgs(shard Shard, output string, workers int) []string {
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		if !shardControlledFlags[f.Name] {
+			args = append(args, "--"+f.Name+"="+f.Value.String())
+		}
+	})
+	return append(args,
+		"--count="+strconv.Itoa(shard.count),
+		"--workers="+strconv.Itoa(workers),
+		"--output="+output,
+		"--shard-index="+strconv.Itoa(shard.index),
+		"--shard-offset="+strconv.Itoa(shard.offset),
+	)
+}
+
+// sum adds up per-shard counters
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// runShardedProcesses forks one AddrMint child per shard, aggregates their
+// progress and concatenates their outputs in index order. The shards cover
+// count addresses starting at index offset. The children derive from
+// baseSeed and hash with hashKey if it is set, both passed in their
+// environment. The shard files are encrypted with c if it is set. The
+// shards are written to merged, which writes to output, and the number of
+// bytes merged is returned.
+func runShardedProcesses(processes, count, offset, workers int, baseSeed string, hashKey []byte, output *os.File, merged io.Writer, c *TempCipher, progressBar *ProgressBar) (int64, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate executable: %v", err)
+	}
+
+	// Shard outputs live next to the final output so the merge stays on one filesystem
+	tempDir := os.TempDir()
+	if output != os.Stdout {
+		tempDir = filepath.Dir(output.Name())
+	}
+
+	// Split the workers between the children
+	childWorkers := workers / processes
+	if childWorkers < 1 {
+		childWorkers = 1
+	}
+
+	shards := splitShards(count, processes)
+	for i := range shards {
+		shards[i].offset += offset
+	}
+	shardFiles := make([]string, len(shards))
+	defer func() {
+		for _, name := range shardFiles {
+			if name != "" {
+				os.Remove(name)
+			}
+		}
+	}()
+
+	env := append(os.Environ(), shardSeedEnv+"="+baseSeed)
+	if hashKey != nil {
+		env = append(env, shardHashKeyEnv+"="+hex.EncodeToString(hashKey))
+	}
+	if c != nil {
+		env = append(env, c.Env())
+	}
+
+	var (
+		mu        sync.Mutex
+		generated = make([]int, len(shards))
+		written   = make([]int, len(shards))
+		errs      = make([]error, len(shards))
+		wg        sync.WaitGroup
+	)
+
+	for i, shard := range shards {
+		tempFile, err := os.CreateTemp(tempDir, fmt.Sprintf("addrmint-shard-%d-*.tmp", shard.index))
+		if err != nil {
+			return 0, fmt.Errorf("failed to create shard file: %v", err)
+		}
+		tempFile.Close()
+		shardFiles[i] = tempFile.Name()
+
+		cmd := exec.Command(executable, shardArgs(shard, tempFile.Name(), childWorkers)...)
+		cmd.Env = env
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			return 0, fmt.Errorf("failed to attach to shard %d: %v", shard.index, err)
+		}
+		if err := cmd.Start(); err != nil {
+			return 0, fmt.Errorf("failed to start shard %d: %v", shard.index, err)
+		}
+
+		wg.Add(1)
+		go func(i int, cmd *exec.Cmd, stderr io.Reader) {
+			defer wg.Done()
+
+			// Progress lines are aggregated, everything else is kept for error reporting
+			var diagnostics bytes.Buffer
+			scanner := bufio.NewScanner(stderr)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if strings.HasPrefix(line, shardProgressPrefix) {
+					var g, w int
+					if _, err := fmt.Sscanf(line[len(shardProgressPrefix):], "%d %d", &g, &w); err != nil {
+						continue
+					}
+					mu.Lock()
+					generated[i], written[i] = g, w
+					progressBar.UpdateWritten(sum(generated), sum(written))
+					mu.Unlock()
+					continue
+				}
+				diagnostics.WriteString(line)
+				diagnostics.WriteByte('\n')
+			}
+
+			if err := cmd.Wait(); err != nil {
+				errs[i] = fmt.Errorf("shard %d failed: %v\n%s", shards[i].index, err, diagnostics.String())
+			}
+		}(i, cmd, stderr)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Concatenate the shard outputs in order
+	var total int64
+	for _, name := range shardFiles {
+		shardFile, err := os.Open(name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open shard output: %v", err)
+		}
+		n, err := io.Copy(merged, c.Reader(shardFile))
+		total += n
+		shardFile.Close()
+		if err != nil {
+			return total, fmt.Errorf("failed to merge shard output: %v", err)
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestSplitShards tests that shards cover every index exactly once
+func TestSplitShards(t *testing.T) {
+	tests := []struct {
+		count     int
+		processes int
+	}{
+		{10, 1},
+		{10, 3},
+		{7, 7},
+		{1000001, 16},
+	}
+
+	for _, tt := range tests {
+		shards := splitShards(tt.count, tt.processes)
+		if len(shards) != tt.processes {
+			t.Errorf("Expected %d shards, got %d", tt.processes, len(shards))
+			continue
+		}
+
+		next := 0
+		for i, shard := range shards {
+			if shard.index != i {
+				t.Errorf("Expected shard index %d, got %d", i, shard.index)
+			}
+			if shard.offset != next {
+				t.Errorf("Shard %d starts at %d, expected %d", i, shard.offset, next)
+			}
+			if shard.count < tt.count/tt.processes || shard.count > tt.count/tt.processes+1 {
+				t.Errorf("Shard %d has unbalanced size %d", i, shard.count)
+			}
+			next += shard.count
+		}
+		if next != tt.count {
+			t.Errorf("Shards cover %d addresses, expected %d", next, tt.count)
+		}
+	}
+}
+
+// TestShardArgs tests that the parent controls the shard-specific flags
+func TestShardArgs(t *testing.T) {
+	args := shardArgs(Shard{index: 2, offset: 40, count: 20}, "/tmp/shard", 4)
+
+	expected := map[string]bool{
+		"--count=20":          true,
+		"--workers=4":         true,
+		"--output=/tmp/shard": true,
+		"--shard-index=2":     true,
+		"--shard-offset=40":   true,
+	}
+	for _, arg := range args {
+		delete(expected, arg)
+	}
+	for arg := range expected {
+		t.Errorf("Missing shard argument %s in %v", arg, args)
+	}
+}
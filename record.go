@@ -0,0 +1,290 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// Record is one generated address with its typed fields. Workers produce
+// records and leave every formatting decision to the output layer.
+type Record struct {
+	index   int
+	network string
+	address string
+	keys    *KeyMaterial   // Only set for outputs that include keys
+	linked  *LinkedColumns // Values generated alongside the address, if any
+	path    string         // Derivation path, empty for keys used directly from the seed
+
+	err error // Why the address could not be generated
+
+	// HMAC of the address for --hash-only, valid when hashed is set
+	keyedHash [sha256.Size]byte
+	worker    int32 // Worker that generated the record
+	hashed    bool
+}
+
+// Policies for addresses that fail to generate
+const (
+	onErrorAbort = "abort" // Stop the run at the first failure
+	onErrorSkip  = "skip"  // Leave the address out and keep going
+)
+
+// KeyMaterial holds the keys behind an address. It is kept out of line so
+// that Record stays small enough for maps to store it without allocating.
+type KeyMaterial struct {
+	publicKey  []byte
+	privateKey []byte // The seed, which is the private key on every network
+}
+
+// LinkedColumns are values generated alongside an address, such as the
+// signers of a multisig. They are written as columns before the address.
+type LinkedColumns struct {
+	columns []string
+}
+
+// ColumnLinker generates the linked columns of the address derived from
+// seed. It runs on the workers.
+type ColumnLinker func(seed *[32]byte) (*LinkedColumns, error)
+
+// chainLinkers writes the columns of first, which may be nil, before those
+// of second
+func chainLinkers(first, second ColumnLinker) ColumnLinker {
+	if first == nil {
+		return second
+	}
+	return func(seed *[32]byte) (*LinkedColumns, error) {
+		a, err := first(seed)
+		if err != nil {
+			return nil, err
+		}
+		b, err := second(seed)
+		if err != nil {
+			return nil, err
+		}
+		return &LinkedColumns{columns: append(a.columns[:len(a.columns):len(a.columns)], b.columns...)}, nil
+	}
+}
+
+// Output formats for --format
+const (
+	outputFormatPlain = "plain" // Comma-separated rows without a header
+	outputFormatAvro  = "avro"  // Avro object container file
+)
+
+// Kinds of the columns of written rows
+const (
+	columnIndex      = iota // Index of the address, or its listed index in targeted runs
+	columnNetwork           // Network of the address
+	columnPath              // Derivation path of the address in HD runs
+	columnLinked            // One of the linked columns
+	columnPrivateKey        // Private key of --include-keys, as --key-format says
+	columnPublicKey         // Public key of --include-keys, 0x-prefixed hex
+	columnKeyedHash         // HMAC of the address for --hash-only
+	columnHash              // Short SHA-256 prefix of --generate-hash
+	columnAddress           // The address itself
+)
+
+// outputColumn is one named column of the written rows
+type outputColumn struct {
+	name   string
+	kind   int
+	linked int // Position among the record's linked columns, for columnLinked
+}
+
+// rowLayout holds the options that decide the columns of the written rows,
+// and formats the value of each column from the typed fields of a record.
+// Every output format writes its rows through it.
+type rowLayout struct {
+	linked       []string // Names of the linked columns
+	keys         bool     // Whether records carry their keys, for --include-keys
+	keyFormat    string   // Encoding of private keys, keyFormatHex when empty
+	chain        string   // Chain of WIF private keys
+	path         bool     // Whether records carry their derivation path
+	hashOnly     bool
+	generateHash bool
+	indices      []int // Listed indices of a targeted run
+	offset       int   // Index of record 0 in other runs
+}
+
+// outputColumns lists the columns of plain and Avro rows: the listed index
+// in targeted runs, the linked and key columns, then the hash or address.
+// With hashMap the address goes to the mapping file instead.
+func (l *rowLayout) outputColumns(hashMap bool) []outputColumn {
+	var columns []outputColumn
+	if l.indices != nil {
+		// Rows of a targeted run carry their index so they can be patched in
+		columns = append(columns, outputColumn{name: "index", kind: columnIndex})
+	}
+	return l.appendValueColumns(columns, hashMap)
+}
+
+// structuredColumns lists the columns of the formats that name their
+// fields: the index and network of each record, its derivation path in HD
+// runs, then the columns of plain rows. --hash-map is not supported with
+// them.
+func (l *rowLayout) structuredColumns() []outputColumn {
+	columns := []outputColumn{{name: "index", kind: columnIndex}, {name: "network", kind: columnNetwork}}
+	if l.path {
+		columns = append(columns, outputColumn{name: "path", kind: columnPath})
+	}
+	return l.appendValueColumns(columns, false)
+}
+
+// appendValueColumns appends the linked and key columns, then the hash or
+// address columns
+func (l *rowLayout) appendValueColumns(columns []outputColumn, hashMap bool) []outputColumn {
+	for i, name := range l.linked {
+		columns = append(columns, outputColumn{name: name, kind: columnLinked, linked: i})
+	}
+	// Keys are written last, next to the address they belong to
+	if l.keys {
+		columns = append(columns, outputColumn{name: "private_key", kind: columnPrivateKey}, outputColumn{name: "public_key", kind: columnPublicKey})
+	}
+	switch {
+	case l.hashOnly:
+		// Only the keyed hash is written, never the address
+		return append(columns, outputColumn{name: "keyed_hash", kind: columnKeyedHash})
+	case l.generateHash && hashMap:
+		return append(columns, outputColumn{name: "hash", kind: columnHash})
+	case l.generateHash:
+		return append(columns, outputColumn{name: "hash", kind: columnHash}, outputColumn{name: "address", kind: columnAddress})
+	}
+	return append(columns, outputColumn{name: "address", kind: columnAddress})
+}
+
+// columnNames returns the names of columns
+func columnNames(columns []outputColumn) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// index returns the index a record is written with
+func (l *rowLayout) index(record *Record) int {
+	if l.indices != nil {
+		return l.indices[record.index]
+	}
+	return l.offset + record.index
+}
+
+// appendValue appends the value of column c of record as text
+func (l *rowLayout) appendValue(b []byte, c outputColumn, record *Record) ([]byte, error) {
+	switch c.kind {
+	case columnIndex:
+		return strconv.AppendInt(b, int64(l.index(record)), 10), nil
+	case columnNetwork:
+		return append(b, record.network...), nil
+	case columnPath:
+		return append(b, record.path...), nil
+	case columnLinked:
+		return append(b, record.linked.columns[c.linked]...), nil
+	case columnPrivateKey:
+		if l.keyFormat == keyFormatWIF {
+			wif, err := addrmint.WIF(record.keys.privateKey, l.chain)
+			return append(b, wif...), err
+		}
+		return hex.AppendEncode(append(b, "0x"...), record.keys.privateKey), nil
+	case columnPublicKey:
+		return hex.AppendEncode(append(b, "0x"...), record.keys.publicKey), nil
+	case columnKeyedHash:
+		return hex.AppendEncode(b, record.keyedHash[:]), nil
+	case columnHash:
+		// Hash the address in the room after b, then keep the first 6
+		// characters of the hash for a shorter representation
+		n := len(b)
+		b = append(b, record.address...)
+		sum := sha256.Sum256(b[n:])
+		return hex.AppendEncode(b[:n], sum[:3]), nil
+	}
+	return append(b, record.address...), nil
+}
+
+// appendRow appends the values of columns comma-separated, without a
+// newline. It also returns where the hash column ends, or -1.
+func (l *rowLayout) appendRow(b []byte, columns []outputColumn, record *Record) ([]byte, int, error) {
+	hashEnd := -1
+	for i, c := range columns {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		var err error
+		if b, err = l.appendValue(b, c, record); err != nil {
+			return b, 0, err
+		}
+		if c.kind == columnHash {
+			hashEnd = len(b)
+		}
+	}
+	return b, hashEnd, nil
+}
+
+// RecordEncoder writes records in an output format that is not plain rows,
+// encoding each column from the record's fields through the run's layout.
+// Flush writes out what the encoder buffers, and Close ends the file.
+type RecordEncoder interface {
+	WriteRecord(record Record) error
+	Flush() error
+	Close() error
+}
+
+// writeRecord writes a record as a plain row, and to the encoder of the
+// output format if there is one
+func (rc *ResultCollector) writeRecord(record *Record) error {
+	if record.err != nil {
+		return rc.writeError(record)
+	}
+	if rc.encoder != nil {
+		if err := rc.encoder.WriteRecord(*record); err != nil {
+			return err
+		}
+	}
+
+	line, hashEnd, err := rc.layout.appendRow(rc.line[:0], rc.columns, record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if rc.hashMap != nil && hashEnd >= 0 {
+		// The mapping file keeps the full row, the output everything up
+		// to the hash
+		if _, err := rc.hashMap.Write(line); err != nil {
+			return err
+		}
+		line[hashEnd] = '\n'
+		line = line[:hashEnd+1]
+	}
+	rc.line = line
+	_, err = rc.writer.Write(line)
+	return err
+}
+
+// writeError handles a record that failed to generate according to the
+// --on-error policy
+func (rc *ResultCollector) writeError(record *Record) error {
+	if !rc.skipErrors {
+		fatalf("Failed to generate address %d: %v", record.index, record.err)
+	}
+	rc.failed++
+	if rc.errors == nil {
+		return nil
+	}
+	index := record.index
+	if rc.layout.indices != nil {
+		index = rc.layout.indices[index]
+	}
+	line := strconv.AppendInt(rc.line[:0], int64(index), 10)
+	line = append(line, ',')
+	// The error is quoted as a CSV field
+	line = append(line, '"')
+	line = append(line, strings.ReplaceAll(record.err.Error(), `"`, `""`)...)
+	line = append(line, '"', '\n')
+	rc.line = line
+	_, err := rc.errors.Write(line)
+	return err
+}
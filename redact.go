@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// redactedText replaces secrets in messages
+const redactedText = "[REDACTED]"
+
+// minSecretLength keeps short values, which would match ordinary text,
+// out of the registry; seeds that short are guessable anyway
+const minSecretLength = 8
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string // Values registered with registerSecret
+)
+
+// secretPattern matches values shaped like key material whether or not they
+// were registered: 32 or more bytes in hex, as private keys, seeds and HMAC
+// keys are, or 64 or more base58 characters, as Solana keypairs are.
+// Addresses and the short hashes of --generate-hash are shorter.
+var secretPattern = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{64,}\b|\b[1-9A-HJ-NP-Za-km-z]{64,}\b`)
+
+// stackArguments matches the argument words of stack trace frames, which
+// can hold pieces of seeds and keys passed by value. Words the runtime is
+// unsure of end in ?.
+var stackArguments = regexp.MustCompile(`\((?:0x[0-9a-f]+\??|\.\.\.|\{|\}|, )+\)`)
+
+// registerSecret hides s from every later warning, error and panic
+func registerSecret(s string) {
+	if len(s) < minSecretLength {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, s)
+}
+
+// redact replaces registered secrets and anything shaped like key material
+// in s
+func redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redactedText)
+	}
+	secretsMu.RUnlock()
+	return secretPattern.ReplaceAllString(s, redactedText)
+}
+
+// redactStack redacts a stack trace, dropping the argument words of its
+// frames
+func redactStack(stack string) string {
+	return redact(stackArguments.ReplaceAllString(stack, "(...)"))
+}
+
+// redactPanic reports a panic with its message and stack trace redacted
+// and exits. It is deferred at the top of main and of the worker
+// goroutines, which handle seeds and keys.
+func redactPanic() {
+	value := recover()
+	if value == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "panic: %s\n\n%s", redact(fmt.Sprint(value)), redactStack(string(debug.Stack())))
+	os.Exit(2)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	registerSecret("correct-horse-battery")
+	registerSecret("short") // Too short to redact safely
+
+	key := strings.Repeat("ab", 32)
+	keypair := strings.Repeat("5Kd3NBUAdUnhyzenEwVLy9pBKxSwXvE9FMPyR4UKZvpe", 2)
+	tests := map[string]string{
+		"auth failed for correct-horse-battery":               "auth failed for [REDACTED]",
+		"invalid key 0x" + key + " rejected":                  "invalid key [REDACTED] rejected",
+		"invalid key " + key:                                  "invalid key [REDACTED]",
+		"bad keypair " + keypair:                              "bad keypair [REDACTED]",
+		"short stays":                                         "short stays",
+		"address 0x52908400098527886E0F7030069857D2E4169EE7":  "address 0x52908400098527886E0F7030069857D2E4169EE7",
+		"address 7EcDhSYGxXyscszYEp35KHN8vvw3svAuLKTzXwCFLtV": "address 7EcDhSYGxXyscszYEp35KHN8vvw3svAuLKTzXwCFLtV",
+	}
+	for in, want := range tests {
+		if got := redact(in); got != want {
+			t.Errorf("redact(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRedactStack(t *testing.T) {
+	stack := "main.generateEthereumAddress({0xc000012345, 0x20, 0x20})\n\t/src/main.go:10 +0x1d\n" +
+		"main.worker(0x1, 0xc0000a2000, ...)\n\t/src/main.go:20 +0x2f\n" +
+		"main.(*AsyncWriter).Write(0xc0000b3000, {0xc0000c4000?, 0x2b, 0x0?})\n\t/src/asyncwriter.go:77 +0xdf\n"
+	got := redactStack(stack)
+	if strings.Contains(got, "0xc000012345") || strings.Contains(got, "0xc0000a2000") || strings.Contains(got, "0xc0000c4000") {
+		t.Errorf("Expected frame arguments to be dropped, got:\n%s", got)
+	}
+	if !strings.Contains(got, "main.worker(...)") || !strings.Contains(got, "/src/main.go:20") {
+		t.Errorf("Expected frames and lines to be kept, got:\n%s", got)
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis structures --redis-url rows can be loaded into
+const (
+	redisTypeSet   = "set"   // A plain set, loaded with SADD
+	redisTypeBloom = "bloom" // A RedisBloom filter, loaded with BF.MADD
+)
+
+const (
+	redisBatchMembers = 512 // Members sent per SADD or BF.MADD command
+	redisPipeline     = 32  // Commands in flight before their replies are read
+)
+
+// RedisWriter loads every row written to it into a Redis set or Bloom
+// filter. Rows are sent in multi-member commands that are pipelined, so
+// loading is bound by bandwidth rather than round trips. It implements
+// FlushWriter; Flush sends the pending rows and waits for their replies.
+type RedisWriter struct {
+	conn    net.Conn
+	out     *bufio.Writer
+	in      *bufio.Reader
+	command string
+	key     string
+
+	partial  []byte   // Start of a row whose newline has not been written yet
+	members  [][]byte // Rows of the command being built
+	inFlight int      // Commands sent whose replies have not been read
+}
+
+// DialRedis connects to a redis://[user:password@]host[:port][/db] URL,
+// authenticating and selecting the database if the URL names them
+func DialRedis(rawURL, key, structure string) (*RedisWriter, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported scheme %q, expected redis://", u.Scheme)
+	}
+	if password, ok := u.User.Password(); ok {
+		registerSecret(password)
+	}
+	var command string
+	switch structure {
+	case redisTypeSet:
+		command = "SADD"
+	case redisTypeBloom:
+		command = "BF.MADD"
+	default:
+		return nil, fmt.Errorf("unknown Redis type %q", structure)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	conn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	rw := &RedisWriter{
+		conn:    conn,
+		out:     bufio.NewWriterSize(conn, 64*1024),
+		in:      bufio.NewReader(conn),
+		command: command,
+		key:     key,
+	}
+
+	if password, ok := u.User.Password(); ok {
+		args := []string{"AUTH", password}
+		if user := u.User.Username(); user != "" {
+			args = []string{"AUTH", user, password}
+		}
+		if err := rw.call(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if _, err := strconv.Atoi(db); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("invalid database %q", db)
+		}
+		if err := rw.call("SELECT", db); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select database %s: %w", db, err)
+		}
+	}
+	return rw, nil
+}
+
+// Check verifies that the key can be loaded: it must be missing or hold the
+// structure being loaded, and Bloom filters need the RedisBloom module
+func (rw *RedisWriter) Check() error {
+	keyType, err := rw.value("TYPE", rw.key)
+	if err != nil {
+		return err
+	}
+	bloom := rw.command == "BF.MADD"
+	want := "set"
+	if bloom {
+		want = "Bloom filter"
+	}
+	switch {
+	case keyType == "none" && bloom:
+		// COMMAND INFO returns a nil entry for commands the server doesn't know
+		info, err := rw.value("COMMAND", "INFO", "BF.MADD")
+		if err != nil {
+			return err
+		}
+		if list, ok := info.([]any); !ok || len(list) == 0 || list[0] == nil {
+			return errors.New("the RedisBloom module is not loaded")
+		}
+	case keyType == "none", keyType == "set" && !bloom, keyType == "MBbloom--" && bloom:
+	default:
+		return fmt.Errorf("key %q holds a %v, not a %s", rw.key, keyType, want)
+	}
+	return nil
+}
+
+// Write queues every complete row in p as a member. The trailing part of a
+// row is kept until its newline arrives.
+func (rw *RedisWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			rw.partial = append(rw.partial, p...)
+			break
+		}
+		row := p[:i]
+		if len(rw.partial) > 0 {
+			row = append(rw.partial, row...)
+			rw.partial = nil
+		}
+		// Rows are copied because callers reuse their buffers
+		rw.members = append(rw.members, bytes.Clone(row))
+		p = p[i+1:]
+		if len(rw.members) == redisBatchMembers {
+			if err := rw.send(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush sends the queued rows and waits until Redis has accepted all of them
+func (rw *RedisWriter) Flush() error {
+	if len(rw.members) > 0 {
+		if err := rw.send(); err != nil {
+			return err
+		}
+	}
+	if err := rw.out.Flush(); err != nil {
+		return err
+	}
+	for rw.inFlight > 0 {
+		rw.inFlight--
+		if err := readRedisReply(rw.in); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection without flushing
+func (rw *RedisWriter) Close() error {
+	return rw.conn.Close()
+}
+
+// send writes the queued members as one command, reading the replies of
+// earlier commands once the pipeline is full
+func (rw *RedisWriter) send() error {
+	writeRedisArrayHeader(rw.out, 2+len(rw.members))
+	writeRedisBulk(rw.out, []byte(rw.command))
+	writeRedisBulk(rw.out, []byte(rw.key))
+	for _, member := range rw.members {
+		writeRedisBulk(rw.out, member)
+	}
+	rw.members = rw.members[:0]
+	rw.inFlight++
+	if rw.inFlight < redisPipeline {
+		return nil
+	}
+	return rw.Flush()
+}
+
+// call sends one command and waits for its reply
+func (rw *RedisWriter) call(args ...string) error {
+	_, err := rw.value(args...)
+	return err
+}
+
+// value sends one command and returns its reply, failing on error replies
+func (rw *RedisWriter) value(args ...string) (any, error) {
+	writeRedisArrayHeader(rw.out, len(args))
+	for _, arg := range args {
+		writeRedisBulk(rw.out, []byte(arg))
+	}
+	if err := rw.out.Flush(); err != nil {
+		return nil, err
+	}
+	value, err := readRedisValue(rw.in)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := value.(redisError); ok {
+		return nil, err
+	}
+	return value, nil
+}
+
+// writeRedisArrayHeader starts a RESP array of n elements
+func writeRedisArrayHeader(w *bufio.Writer, n int) {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(n))
+	w.WriteString("\r\n")
+}
+
+// writeRedisBulk writes b as a RESP bulk string
+func writeRedisBulk(w *bufio.Writer, b []byte) {
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(b)))
+	w.WriteString("\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+// readRedisReply reads one RESP reply, returning the error it carries if
+// it is an error reply or an array containing one
+func readRedisReply(r *bufio.Reader) error {
+	value, err := readRedisValue(r)
+	if err != nil {
+		return err
+	}
+	return firstRedisError(value)
+}
+
+// readRedisValue reads one RESP reply as a string, an int64, a []any, nil
+// or a redisError. The error is only set when the reply can't be read.
+func readRedisValue(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply from Redis")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return redisError(line[1:]), nil
+	case ':', '$', '*':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed reply %q", line)
+		}
+		switch {
+		case line[0] == ':':
+			return n, nil
+		case n < 0:
+			return nil, nil
+		case line[0] == '$':
+			buf := make([]byte, n+2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, err
+			}
+			return string(buf[:n]), nil
+		}
+		values := make([]any, n)
+		for i := range values {
+			if values[i], err = readRedisValue(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
+
+// firstRedisError returns the first error reply in a value, if any
+func firstRedisError(value any) error {
+	switch v := value.(type) {
+	case redisError:
+		return v
+	case []any:
+		for _, element := range v {
+			if err := firstRedisError(element); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// redisError is an error reply sent by the server
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeRedis accepts one connection and records the commands it receives.
+// Members named "bad" are rejected. The key "corpus" holds a set and
+// "names" a string, and RedisBloom is not loaded.
+func fakeRedis(t *testing.T) (string, <-chan [][]string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan [][]string, 1)
+	go func() {
+		var commands [][]string
+		defer func() { received <- commands }()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			args, err := readRedisCommand(r)
+			if err != nil {
+				return
+			}
+			commands = append(commands, args)
+			switch args[0] {
+			case "SADD":
+				if strings.Contains(strings.Join(args[2:], " "), "bad") {
+					fmt.Fprintf(conn, "-ERR bad member\r\n")
+				} else {
+					fmt.Fprintf(conn, ":%d\r\n", len(args)-2)
+				}
+			case "BF.MADD":
+				fmt.Fprintf(conn, "*%d\r\n", len(args)-2)
+				for _, member := range args[2:] {
+					if member == "bad" {
+						fmt.Fprintf(conn, "-ERR bad member\r\n")
+					} else {
+						fmt.Fprintf(conn, ":1\r\n")
+					}
+				}
+			case "TYPE":
+				types := map[string]string{"corpus": "set", "names": "string"}
+				fmt.Fprintf(conn, "+%s\r\n", cmp.Or(types[args[1]], "none"))
+			case "COMMAND":
+				fmt.Fprintf(conn, "*1\r\n*-1\r\n")
+			default:
+				fmt.Fprintf(conn, "+OK\r\n")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+// readRedisCommand reads one RESP array of bulk strings
+func readRedisCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisWriter(t *testing.T) {
+	addr, received := fakeRedis(t)
+	rw, err := DialRedis("redis://user:secret@"+addr+"/2", "corpus", redisTypeSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rows arrive in chunks that split them
+	var all strings.Builder
+	for i := 0; i < 1500; i++ {
+		all.WriteString("row" + strconv.Itoa(i) + "\n")
+	}
+	data := all.String()
+	for len(data) > 0 {
+		n := min(len(data), 1000)
+		if _, err := rw.Write([]byte(data[:n])); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Close()
+
+	commands := <-received
+	if got := strings.Join(commands[0], " "); got != "AUTH user secret" {
+		t.Errorf("Expected AUTH user secret, got %s", got)
+	}
+	if got := strings.Join(commands[1], " "); got != "SELECT 2" {
+		t.Errorf("Expected SELECT 2, got %s", got)
+	}
+	var members []string
+	for _, command := range commands[2:] {
+		if command[0] != "SADD" || command[1] != "corpus" || len(command)-2 > redisBatchMembers {
+			t.Fatalf("Unexpected command %.40v", command)
+		}
+		members = append(members, command[2:]...)
+	}
+	if len(members) != 1500 || members[0] != "row0" || members[1499] != "row1499" {
+		t.Errorf("Expected rows row0 to row1499, got %d members", len(members))
+	}
+}
+
+func TestRedisWriterErrors(t *testing.T) {
+	for _, structure := range []string{redisTypeSet, redisTypeBloom} {
+		addr, received := fakeRedis(t)
+		rw, err := DialRedis("redis://"+addr, "corpus", structure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rw.Write([]byte("good\nbad\n"))
+		if err := rw.Flush(); err == nil || !strings.Contains(err.Error(), "bad member") {
+			t.Errorf("%s: expected the rejected member to fail the flush, got %v", structure, err)
+		}
+		rw.Close()
+		<-received
+	}
+
+	if _, err := DialRedis("http://localhost", "corpus", redisTypeSet); err == nil {
+		t.Error("Expected an error for a non-Redis URL")
+	}
+	if _, err := DialRedis("redis://localhost", "corpus", "list"); err == nil {
+		t.Error("Expected an error for an unknown structure")
+	}
+}
+
+func TestRedisWriterCheck(t *testing.T) {
+	tests := []struct {
+		key, structure string
+		ok             bool
+	}{
+		{"corpus", redisTypeSet, true},
+		{"fresh", redisTypeSet, true},
+		{"names", redisTypeSet, false},
+		{"corpus", redisTypeBloom, false},
+		{"fresh", redisTypeBloom, false}, // RedisBloom is missing
+	}
+	for _, tt := range tests {
+		addr, received := fakeRedis(t)
+		rw, err := DialRedis("redis://"+addr, tt.key, tt.structure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rw.Check(); (err == nil) != tt.ok {
+			t.Errorf("%s %s: expected ok %v, got %v", tt.structure, tt.key, tt.ok, err)
+		}
+		rw.Close()
+		<-received
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+const (
+	retryCheckpointBytes = 1 << 20     // Rows written between flushes that confirm their delivery
+	maxRetryBackoff      = time.Minute // Longest delay between reconnection attempts
+)
+
+// errSinkDown is the failure of a sink that could not be reconnected
+var errSinkDown = errors.New("not connected")
+
+// networkSink is a sink behind a connection, such as Redis or NATS
+type networkSink interface {
+	FlushWriter
+	io.Closer
+}
+
+// resumableSink is a network sink that numbers its messages, so that it
+// can carry on numbering after the rows delivered over an earlier connection
+type resumableSink interface {
+	Resume(rows int64)
+}
+
+// RetryWriter keeps a network sink going through transient failures. It
+// holds on to the rows written since the sink last confirmed delivery with
+// a flush, and when the sink fails it reconnects with exponential backoff
+// and jitter and replays them, so rows may be delivered twice but never
+// lost. A resumableSink is told how many rows came before the replay, so
+// that it can mark the replayed messages for the server to drop
+// duplicates. Rows that still fail after the last retry go to the
+// dead-letter file if there is one; the sink then gets a single
+// reconnection attempt per checkpoint until it comes back. Without a
+// dead-letter file the error is returned. It implements FlushWriter.
+type RetryWriter struct {
+	name    string
+	dial    func() (networkSink, error)
+	sink    networkSink // nil while disconnected
+	retries int
+	backoff time.Duration
+	jitter  float64 // Fraction of each delay that is randomized
+	sleep   func(time.Duration)
+
+	pending   []byte // Rows not yet confirmed by a flush, ending with any partial row
+	confirmed int64  // Rows before pending, delivered or dead-lettered
+	down      bool   // The retries ran out and rows go to the dead-letter file
+
+	deadLetterPath string
+	deadLetterFile *os.File
+	deadLetter     *bufio.Writer
+	deadRows       int64 // Rows written to the dead-letter file
+}
+
+// NewRetryWriter wraps a connected sink; dial opens a new connection to it
+func NewRetryWriter(name string, sink networkSink, dial func() (networkSink, error), retries int, backoff time.Duration, jitter float64) *RetryWriter {
+	return &RetryWriter{name: name, dial: dial, sink: sink, retries: retries, backoff: backoff, jitter: jitter, sleep: time.Sleep}
+}
+
+// SetDeadLetter creates the file that rows go to once the retries run out
+func (rw *RetryWriter) SetDeadLetter(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	rw.deadLetterPath = path
+	rw.deadLetterFile = file
+	rw.deadLetter = bufio.NewWriter(file)
+	return nil
+}
+
+// Write sends p to the sink, flushing it at every checkpoint to confirm
+// delivery
+func (rw *RetryWriter) Write(p []byte) (int, error) {
+	rw.pending = append(rw.pending, p...)
+	if rw.sink != nil {
+		if _, err := rw.sink.Write(p); err != nil {
+			if err := rw.recover(err); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if len(rw.pending) >= retryCheckpointBytes {
+		if err := rw.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush waits until the sink has accepted every complete row
+func (rw *RetryWriter) Flush() error {
+	err := errSinkDown
+	if rw.sink != nil {
+		err = rw.sink.Flush()
+	}
+	if err != nil {
+		if err := rw.recover(err); err != nil {
+			return err
+		}
+	} else {
+		rw.delivered()
+	}
+	if rw.deadLetter != nil {
+		return rw.deadLetter.Flush()
+	}
+	return nil
+}
+
+// Close closes the connection and the dead-letter file without flushing
+func (rw *RetryWriter) Close() error {
+	if rw.deadLetterFile != nil {
+		rw.deadLetterFile.Close()
+	}
+	if rw.sink != nil {
+		return rw.sink.Close()
+	}
+	return nil
+}
+
+// DeadLetterRows returns the number of rows written to the dead-letter file
+func (rw *RetryWriter) DeadLetterRows() int64 {
+	return rw.deadRows
+}
+
+// delivered forgets the complete pending rows, keeping a trailing partial row
+func (rw *RetryWriter) delivered() {
+	end := bytes.LastIndexByte(rw.pending, '\n') + 1
+	rw.confirmed += int64(bytes.Count(rw.pending[:end], []byte{'\n'}))
+	rw.pending = append(rw.pending[:0], rw.pending[end:]...)
+}
+
+// recover reconnects after the sink failed with err and replays the pending
+// rows, falling back to the dead-letter file when the retries run out
+func (rw *RetryWriter) recover(err error) error {
+	attempts := rw.retries
+	if rw.down {
+		attempts = 1
+	}
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if !rw.down {
+			delay := rw.delay(attempt)
+			printWarning("The %s sink failed: %v; reconnecting in %s (attempt %d of %d)", rw.name, err, delay.Round(time.Millisecond), attempt, attempts)
+			rw.sleep(delay)
+		}
+		if err = rw.reconnect(); err == nil {
+			if rw.down {
+				fmt.Fprintf(os.Stderr, "The %s sink is back after %s rows went to the dead-letter file\n", rw.name, formatCount(int(rw.deadRows)))
+			}
+			rw.down = false
+			rw.delivered()
+			return nil
+		}
+	}
+	if rw.deadLetter == nil {
+		return err
+	}
+	if !rw.down {
+		printWarning("The %s sink is still failing: %v; writing its rows to %s until it is back", rw.name, err, rw.deadLetterPath)
+		rw.down = true
+	}
+	end := bytes.LastIndexByte(rw.pending, '\n') + 1
+	if _, err := rw.deadLetter.Write(rw.pending[:end]); err != nil {
+		return fmt.Errorf("failed to write the dead-letter file: %w", err)
+	}
+	rw.deadRows += int64(bytes.Count(rw.pending[:end], []byte{'\n'}))
+	rw.delivered()
+	return nil
+}
+
+// reconnect replaces the sink with a new connection and replays the
+// pending rows over it
+func (rw *RetryWriter) reconnect() error {
+	if rw.sink != nil {
+		rw.sink.Close()
+		rw.sink = nil
+	}
+	sink, err := rw.dial()
+	if err != nil {
+		return err
+	}
+	rw.sink = sink
+	if resumable, ok := sink.(resumableSink); ok {
+		resumable.Resume(rw.confirmed)
+	}
+	if _, err := sink.Write(rw.pending); err != nil {
+		return err
+	}
+	return sink.Flush()
+}
+
+// delay returns how long to wait before the given attempt: the backoff,
+// doubled for every earlier attempt up to maxRetryBackoff, less a random
+// share of up to the jitter fraction
+func (rw *RetryWriter) delay(attempt int) time.Duration {
+	delay := rw.backoff
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryBackoff)
+	return delay - time.Duration(rw.jitter*rand.Float64()*float64(delay))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNetworkSink delivers complete rows on Flush unless it is broken
+type fakeNetworkSink struct {
+	delivered *[]string
+	broken    bool
+	buf       []byte
+	resumed   int64 // Rows before the replay, as passed to Resume
+}
+
+func (s *fakeNetworkSink) Resume(rows int64) {
+	s.resumed = rows
+}
+
+func (s *fakeNetworkSink) Write(p []byte) (int, error) {
+	s.buf = append(s.buf, p...)
+	return len(p), nil
+}
+
+func (s *fakeNetworkSink) Flush() error {
+	if s.broken {
+		return errors.New("connection reset")
+	}
+	end := bytes.LastIndexByte(s.buf, '\n') + 1
+	for _, row := range strings.Split(string(s.buf[:end]), "\n") {
+		if row != "" {
+			*s.delivered = append(*s.delivered, row)
+		}
+	}
+	s.buf = s.buf[end:]
+	return nil
+}
+
+func (s *fakeNetworkSink) Close() error {
+	return nil
+}
+
+func TestRetryWriter(t *testing.T) {
+	var delivered []string
+	dials := 0
+	dial := func() (networkSink, error) {
+		dials++
+		if dials < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return &fakeNetworkSink{delivered: &delivered}, nil
+	}
+	rw := NewRetryWriter("test", &fakeNetworkSink{delivered: &delivered, broken: true}, dial, 3, time.Second, 0)
+	var delays []time.Duration
+	rw.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	// The partial row is kept across the reconnection
+	rw.Write([]byte("a\nb\nc"))
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Write([]byte("\nd\n"))
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(delivered, " "); got != "a b c d" {
+		t.Errorf("Expected every row to be delivered once, got %q", got)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	if len(delays) != len(want) || delays[0] != want[0] || delays[1] != want[1] || delays[2] != want[2] {
+		t.Errorf("Expected delays %v, got %v", want, delays)
+	}
+}
+
+func TestRetryWriterDeadLetter(t *testing.T) {
+	var delivered []string
+	up := false
+	var last *fakeNetworkSink
+	dial := func() (networkSink, error) {
+		if !up {
+			return nil, errors.New("connection refused")
+		}
+		last = &fakeNetworkSink{delivered: &delivered}
+		return last, nil
+	}
+	rw := NewRetryWriter("test", &fakeNetworkSink{delivered: &delivered, broken: true}, dial, 2, time.Second, 0.5)
+	rw.sleep = func(time.Duration) {}
+	path := filepath.Join(t.TempDir(), "dead.txt")
+	if err := rw.SetDeadLetter(path); err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+
+	rw.Write([]byte("a\nb\n"))
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rw.Write([]byte("c\n"))
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	up = true
+	rw.Write([]byte("d\n"))
+	if err := rw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "a\nb\nc\n" || rw.DeadLetterRows() != 3 {
+		t.Errorf("Expected rows a to c in the dead-letter file, got %q (%d rows)", data, rw.DeadLetterRows())
+	}
+	if strings.Join(delivered, " ") != "d" {
+		t.Errorf("Expected row d to be delivered once the sink was back, got %q", delivered)
+	}
+	if last.resumed != 3 {
+		t.Errorf("Expected the new connection to resume after 3 rows, got %d", last.resumed)
+	}
+
+	// Without a dead-letter file the failure is returned
+	rw = NewRetryWriter("test", &fakeNetworkSink{delivered: &delivered, broken: true}, func() (networkSink, error) {
+		return nil, errors.New("connection refused")
+	}, 2, time.Second, 0)
+	rw.sleep = func(time.Duration) {}
+	rw.Write([]byte("e\n"))
+	if err := rw.Flush(); err == nil {
+		t.Error("Expected the flush to fail once the retries ran out")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	rw := NewRetryWriter("test", nil, nil, 10, time.Second, 0.5)
+	for attempt := 1; attempt <= 10; attempt++ {
+		full := min(time.Second<<(attempt-1), maxRetryBackoff)
+		if delay := rw.delay(attempt); delay > full || delay < full/2 {
+			t.Errorf("Attempt %d: expected a delay between %s and %s, got %s", attempt, full/2, full, delay)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package main
+
+import "time"
+
+// processCPUTime is not supported on this platform
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
+
+// runUsage is not supported on this platform
+func runUsage() (time.Duration, int64, bool) {
+	return 0, 0, false
+}
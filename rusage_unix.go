@@ -0,0 +1,35 @@
+//go:build unix
+
+package main
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// processCPUTime returns the user and system CPU time used by the process
+func processCPUTime() (time.Duration, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), true
+}
+
+// runUsage returns the CPU time used by the process and its finished child
+// processes, and the peak resident set size of the largest of them
+func runUsage() (time.Duration, int64, bool) {
+	var self, children syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &self) != nil || syscall.Getrusage(syscall.RUSAGE_CHILDREN, &children) != nil {
+		return 0, 0, false
+	}
+	cpu := self.Utime.Nano() + self.Stime.Nano() + children.Utime.Nano() + children.Stime.Nano()
+
+	// Linux and the BSDs report the peak RSS in KiB, macOS in bytes
+	peak := max(int64(self.Maxrss), int64(children.Maxrss))
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		peak *= 1024
+	}
+	return time.Duration(cpu), peak, true
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// sampledLine is a line kept by reservoir sampling with its input position
+type sampledLine struct {
+	index int
+	line  string
+}
+
+// sampleLines copies the first headers lines of in to out and then samples
+// the rest: each line with probability rate, or exactly count lines by
+// reservoir sampling when count is positive. Sampled lines keep their input
+// order, and the same seed always selects the same lines.
+func sampleLines(in io.Reader, out io.Writer, headers int, rate float64, count int, seed int64) (int, int, error) {
+	rng := rand.New(rand.NewSource(seed))
+	scanner := bufio.NewScanner(in)
+
+	// Header rows are always kept
+	for i := 0; i < headers && scanner.Scan(); i++ {
+		if _, err := fmt.Fprintln(out, scanner.Text()); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	read, written := 0, 0
+	var reservoir []sampledLine
+	for scanner.Scan() {
+		read++
+		if count > 0 {
+			// Algorithm R: the i-th line replaces a random slot with probability count/i
+			if len(reservoir) < count {
+				reservoir = append(reservoir, sampledLine{index: read, line: scanner.Text()})
+			} else if j := rng.Intn(read); j < count {
+				reservoir[j] = sampledLine{index: read, line: scanner.Text()}
+			}
+			continue
+		}
+		if rng.Float64() < rate {
+			if _, err := fmt.Fprintln(out, scanner.Text()); err != nil {
+				return read, written, err
+			}
+			written++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return read, written, err
+	}
+
+	// Restore the input order of the reservoir
+	sort.Slice(reservoir, func(i, j int) bool { return reservoir[i].index < reservoir[j].index })
+	for _, sampled := range reservoir {
+		if _, err := fmt.Fprintln(out, sampled.line); err != nil {
+			return read, written, err
+		}
+		written++
+	}
+	return read, written, nil
+}
+
+// runSample implements the sample subcommand and returns the exit code
+func runSample(args []string) int {
+	fs := flag.NewFlagSet("sample", flag.ExitOnError)
+	rate := fs.Float64("rate", 0, "Fraction of lines to keep, between 0 and 1")
+	count := fs.Int("count", 0, "Exact number of lines to keep (reservoir sampling)")
+	seed := fs.Int64("seed", 1, "Random seed; the same seed selects the same lines")
+	headers := fs.Int("header", 0, "Number of header rows to copy unsampled")
+	output := fs.String("output", "", "Output file path (default: stdout)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint sample (--rate FRACTION | --count N) [--seed N] [--header N] [--output FILE] [FILE]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if (*rate > 0) == (*count > 0) {
+		fatalf("Exactly one of --rate or --count is required")
+	}
+	if *rate < 0 || *rate > 1 {
+		fatalf("Rate must be between 0 and 1")
+	}
+	if *count < 0 || *headers < 0 {
+		fatalf("Count and header rows cannot be negative")
+	}
+	if fs.NArg() > 1 {
+		fatalf("sample takes at most one input file")
+	}
+
+	// Read from the named file, or stdin when none is given
+	in := os.Stdin
+	if fs.NArg() == 1 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fatalf("Failed to open input file: %v", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer := bufio.NewWriterSize(out, 64*1024)
+	read, written, err := sampleLines(in, writer, *headers, *rate, *count, *seed)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		fatalf("Failed to sample lines: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Sampled %s of %s lines\n", formatCount(written), formatCount(read))
+	return 0
+}
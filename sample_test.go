@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns a header followed by n numbered lines
+func numberedLines(n int) string {
+	var b strings.Builder
+	b.WriteString("address\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "line%d\n", i)
+	}
+	return b.String()
+}
+
+func TestSampleLinesCount(t *testing.T) {
+	input := numberedLines(1000)
+
+	var out strings.Builder
+	read, written, err := sampleLines(strings.NewReader(input), &out, 1, 0, 50, 7)
+	if err != nil {
+		t.Fatalf("sampleLines failed: %v", err)
+	}
+	if read != 1000 || written != 50 {
+		t.Errorf("Expected 1000 read and 50 written, got %d and %d", read, written)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if lines[0] != "address" {
+		t.Errorf("Expected header row to be kept, got %q", lines[0])
+	}
+	if len(lines) != 51 {
+		t.Fatalf("Expected 51 lines, got %d", len(lines))
+	}
+
+	// Samples keep their input order
+	previous := -1
+	for _, line := range lines[1:] {
+		var n int
+		fmt.Sscanf(line, "line%d", &n)
+		if n <= previous {
+			t.Errorf("Sample out of order: line%d after line%d", n, previous)
+		}
+		previous = n
+	}
+
+	// The same seed selects the same lines, another seed different ones
+	var again, other strings.Builder
+	sampleLines(strings.NewReader(input), &again, 1, 0, 50, 7)
+	sampleLines(strings.NewReader(input), &other, 1, 0, 50, 8)
+	if again.String() != out.String() {
+		t.Errorf("Expected the same sample for the same seed")
+	}
+	if other.String() == out.String() {
+		t.Errorf("Expected a different sample for a different seed")
+	}
+
+	// Asking for more lines than available keeps them all
+	var all strings.Builder
+	if _, written, _ := sampleLines(strings.NewReader(input), &all, 1, 0, 5000, 7); written != 1000 || all.String() != input {
+		t.Errorf("Expected every line to be kept, got %d", written)
+	}
+}
+
+func TestSampleLinesRate(t *testing.T) {
+	input := numberedLines(10000)
+
+	var out strings.Builder
+	read, written, err := sampleLines(strings.NewReader(input), &out, 0, 0.1, 0, 1)
+	if err != nil {
+		t.Fatalf("sampleLines failed: %v", err)
+	}
+	// The header is sampled like any other row here
+	if read != 10001 {
+		t.Errorf("Expected 10001 lines read, got %d", read)
+	}
+	if written < 800 || written > 1200 {
+		t.Errorf("Expected about 1000 sampled lines, got %d", written)
+	}
+
+	var again strings.Builder
+	sampleLines(strings.NewReader(input), &again, 0, 0.1, 0, 1)
+	if again.String() != out.String() {
+		t.Errorf("Expected the same sample for the same seed")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// selftestSeed is the base seed of the golden vectors (--seed 42)
+const selftestSeed = "2a"
+
+// SelftestVector is a known address for one network, backend and index
+type SelftestVector struct {
+	network     string
+	backend     string
+	hashBackend string
+	index       int
+	expected    string
+}
+
+// selftestVectors covers every network and crypto backend compiled into the binary
+var selftestVectors = []SelftestVector{
+	{"ethereum", backendSDK, hashBackendGeth, 0, "0xFFaD25c5463eCb08ee91650a6530578598142dC6"},
+	{"ethereum", backendSDK, hashBackendGeth, 1, "0xB53fCB3aeAe3851799b4eC244D6C1E9d80dca902"},
+	{"ethereum", backendSDK, hashBackendReused, 0, "0xFFaD25c5463eCb08ee91650a6530578598142dC6"},
+	{"ethereum", backendSDK, hashBackendReused, 1, "0xB53fCB3aeAe3851799b4eC244D6C1E9d80dca902"},
+	{"bitcoin", backendSDK, hashBackendGeth, 0, "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT"},
+	{"bitcoin", backendSDK, hashBackendGeth, 1, "1NXCiQ1RJ523yiZEDkpkvrNh542EZ5JeAW"},
+	{"solana", backendSDK, hashBackendGeth, 0, "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj"},
+	{"solana", backendSDK, hashBackendGeth, 1, "J9h7PhWBTkQLMfo2nf5CMyx7kWiFsu9RxsiXCdiQmVsc"},
+	{"solana", backendNative, hashBackendGeth, 0, "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj"},
+	{"solana", backendNative, hashBackendGeth, 1, "J9h7PhWBTkQLMfo2nf5CMyx7kWiFsu9RxsiXCdiQmVsc"},
+	{"ton", backendSDK, hashBackendGeth, 0, "UQCuIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8d"},
+	{"ton", backendSDK, hashBackendGeth, 1, "UQCnyWZnw0nV9-XB134Wo1SEtr5jpNS0nM09r1GG33lhsvQJ"},
+}
+
+// config names the network and the backend that matters for it
+func (v SelftestVector) config() string {
+	switch v.network {
+	case "ethereum":
+		return v.network + "/" + v.hashBackend
+	case "solana":
+		return v.network + "/" + v.backend
+	}
+	return v.network
+}
+
+// name identifies the vector in the self-test report
+func (v SelftestVector) name() string {
+	return fmt.Sprintf("%s #%d", v.config(), v.index)
+}
+
+// runSelftest implements the selftest subcommand and returns the exit code
+func runSelftest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	differentialCount := fs.Int("differential", 0, "Also derive this many addresses per network with every implementation and compare them")
+	seedInt := fs.Int64("seed", 0, "Random seed as integer for the differential sample (0 for random seed)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+	if *differentialCount < 0 {
+		fatalf("--differential cannot be negative")
+	}
+
+	printBanner("AddrMint v%s - Self-test", version)
+	fmt.Fprintf(os.Stderr, "Platform: %s/%s, %d CPUs\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	fmt.Fprintf(os.Stderr, "Hash backends: %s, %s\n", hashBackendInfo(hashBackendGeth), hashBackendInfo(hashBackendReused))
+	fmt.Fprintf(os.Stderr, "secp256k1: %s\n", secp256k1Info())
+
+	status := 0
+	if failed := selftest(os.Stderr, selftestVectors); failed > 0 {
+		fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, fmt.Sprintf("%d of %d vectors failed", failed, len(selftestVectors))))
+		status = 1
+	} else {
+		fmt.Fprintf(os.Stderr, "All %d vectors passed\n", len(selftestVectors))
+	}
+
+	if *differentialCount > 0 {
+		seed := *seedInt
+		if seed == 0 {
+			seed = rand.Int64N(math.MaxInt64) + 1
+		}
+		fmt.Fprintf(os.Stderr, "Comparing implementations on %s addresses per network from --seed %d\n", formatCount(*differentialCount), seed)
+		if failed := differential(os.Stderr, diffNetworks(), strconv.FormatInt(seed, 16), *differentialCount); failed > 0 {
+			fmt.Fprintln(os.Stderr, colorize(colorBold+colorRed, fmt.Sprintf("%d addresses differ between implementations", failed)))
+			status = 1
+		} else {
+			fmt.Fprintln(os.Stderr, "All implementations agree")
+		}
+	}
+	return status
+}
+
+// selftest generates every vector through the worker code path, reports
+// each result to w and returns the number of failures
+func selftest(w io.Writer, vectors []SelftestVector) int {
+	var generators workerGenerators
+	var buf []byte
+	failed := 0
+
+	for _, v := range vectors {
+		job := Job{index: v.index, network: v.network, backend: v.backend, hashBackend: v.hashBackend}
+		buf = addrmint.DeriveSeed(buf, selftestSeed, v.index, &job.seed)
+
+		got, err := generateAddress(&job, &generators)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(w, "%s %-20s %v\n", colorize(colorBold+colorRed, "FAIL"), v.name(), err)
+		case got != v.expected:
+			failed++
+			fmt.Fprintf(w, "%s %-20s got %s, want %s\n", colorize(colorBold+colorRed, "FAIL"), v.name(), got, v.expected)
+		default:
+			fmt.Fprintf(w, "%s %-20s %s\n", colorize(colorBold, "PASS"), v.name(), got)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSelftestVectors(t *testing.T) {
+	var out bytes.Buffer
+	if failed := selftest(&out, selftestVectors); failed != 0 {
+		t.Fatalf("%d self-test vectors failed:\n%s", failed, out.String())
+	}
+	if got := strings.Count(out.String(), "PASS"); got != len(selftestVectors) {
+		t.Errorf("Expected %d PASS lines, got %d", len(selftestVectors), got)
+	}
+}
+
+func TestSelftestReportsFailure(t *testing.T) {
+	vectors := []SelftestVector{
+		{"ethereum", backendSDK, hashBackendGeth, 0, "0x0000000000000000000000000000000000000000"},
+	}
+
+	var out bytes.Buffer
+	if failed := selftest(&out, vectors); failed != 1 {
+		t.Fatalf("Expected 1 failure, got %d", failed)
+	}
+	if !strings.HasPrefix(out.String(), "FAIL") {
+		t.Errorf("Expected FAIL line, got %q", out.String())
+	}
+}
+
+func TestDifferential(t *testing.T) {
+	var out bytes.Buffer
+	if failed := differential(&out, diffNetworks(), selftestSeed, 200); failed != 0 {
+		t.Fatalf("%d addresses differ between implementations:\n%s", failed, out.String())
+	}
+
+	// An implementation that breaks on some seeds is caught on those seeds
+	broken := DiffImplementation{name: "broken", generate: func(seed *[32]byte) (string, error) {
+		if seed[0]%2 == 0 {
+			return "1BrokenAddress", nil
+		}
+		return referenceBitcoinAddress(seed)
+	}}
+	networks := []DiffNetwork{{"bitcoin", []DiffImplementation{
+		backendImplementation("btcutil", "bitcoin", backendSDK, hashBackendGeth), broken,
+	}}}
+	out.Reset()
+	failed := differential(&out, networks, selftestSeed, 50)
+	if failed == 0 || failed == 50 {
+		t.Fatalf("Expected some of 50 addresses to differ, got %d", failed)
+	}
+	if got := strings.Count(out.String(), "DIFF"); got != min(failed, maxDiffReports) {
+		t.Errorf("Expected %d DIFF lines, got %d:\n%s", min(failed, maxDiffReports), got, out.String())
+	}
+}
+
+func TestReferenceRejectsInvalidKeys(t *testing.T) {
+	var order [32]byte // The secp256k1 group order is not a valid private key
+	copy(order[:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+		0xba, 0xae, 0xdc, 0xe6, 0xaf, 0x48, 0xa0, 0x3b, 0xbf, 0xd2, 0x5e, 0x8c, 0xd0, 0x36, 0x41, 0x41})
+	for _, seed := range [][32]byte{{}, order} {
+		if _, err := referenceEthereumAddress(&seed); err == nil {
+			t.Errorf("Expected %x to be rejected", seed)
+		}
+	}
+}
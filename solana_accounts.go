@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blocto/solana-go-sdk/common"
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// Solana account types for --solana-account
+const (
+	solanaAccountWallet   = "wallet"   // Plain system account
+	solanaAccountNonce    = "nonce"    // Durable nonce account with its authority
+	solanaAccountMultisig = "multisig" // SPL Token multisig account with its signers
+	solanaAccountMint     = "mint"     // Token mint with an owner, its associated token account and metadata PDA
+)
+
+// Token programs a --solana-account mint can belong to
+var tokenPrograms = map[string]common.PublicKey{
+	"spl":        common.TokenProgramID,
+	"token-2022": common.Token2022ProgramID,
+}
+
+// maxMultisigSigners is the most signers an SPL Token multisig can have
+const maxMultisigSigners = 11
+
+// linkedSolanaAddress returns the address of the n-th linked account with
+// the given role
+func linkedSolanaAddress(seed *[32]byte, role string, n int) (string, error) {
+	linked := addrmint.LinkedSeed(seed, role, n)
+	address, err := addrmint.SolanaNativeGenerator{}.Generate(linked[:])
+	return string(address), err
+}
+
+// parseMultisig parses an "M-of-N" signer threshold
+func parseMultisig(s string) (int, int, error) {
+	mText, nText, ok := strings.Cut(s, "-of-")
+	m, errM := strconv.Atoi(mText)
+	n, errN := strconv.Atoi(nText)
+	if !ok || errM != nil || errN != nil {
+		return 0, 0, fmt.Errorf("%q is not of the form M-of-N", s)
+	}
+	if n < 1 || n > maxMultisigSigners || m < 1 || m > n {
+		return 0, 0, fmt.Errorf("%q needs 1 <= M <= N <= %d", s, maxMultisigSigners)
+	}
+	return m, n, nil
+}
+
+// mintAccounts returns the associated token account of owner for mint and
+// the Metaplex metadata PDA of mint
+func mintAccounts(mint, owner, tokenProgram common.PublicKey) (common.PublicKey, common.PublicKey, error) {
+	ata, _, err := common.FindProgramAddress(
+		[][]byte{owner.Bytes(), tokenProgram.Bytes(), mint.Bytes()},
+		common.SPLAssociatedTokenAccountProgramID,
+	)
+	if err != nil {
+		return common.PublicKey{}, common.PublicKey{}, err
+	}
+	metadata, _, err := common.FindProgramAddress(
+		[][]byte{[]byte("metadata"), common.MetaplexTokenMetaProgramID.Bytes(), mint.Bytes()},
+		common.MetaplexTokenMetaProgramID,
+	)
+	return ata, metadata, err
+}
+
+// solanaColumnNames names the linked columns of a Solana account type, in
+// the order its linker writes them
+func solanaColumnNames(account, multisig string) []string {
+	switch account {
+	case solanaAccountNonce:
+		return []string{"nonce_authority"}
+	case solanaAccountMultisig:
+		_, n, _ := parseMultisig(multisig)
+		names := []string{"threshold"}
+		for i := 1; i <= n; i++ {
+			names = append(names, "signer_"+strconv.Itoa(i))
+		}
+		return names
+	case solanaAccountMint:
+		return []string{"owner", "token_account", "metadata"}
+	}
+	return nil
+}
+
+// newSolanaLinker returns the linker for a Solana account type, or nil for
+// plain wallets. The address at each index is the nonce, multisig or mint
+// account itself; the accounts that belong with it are derived from its seed.
+func newSolanaLinker(account, multisig, tokenProgram string) (ColumnLinker, error) {
+	switch account {
+	case solanaAccountWallet:
+		return nil, nil
+	case solanaAccountNonce:
+		return func(seed *[32]byte) (*LinkedColumns, error) {
+			authority, err := linkedSolanaAddress(seed, "nonce-authority", 0)
+			if err != nil {
+				return nil, err
+			}
+			return &LinkedColumns{columns: []string{authority}}, nil
+		}, nil
+	case solanaAccountMultisig:
+		m, n, err := parseMultisig(multisig)
+		if err != nil {
+			return nil, err
+		}
+		threshold := strconv.Itoa(m)
+		return func(seed *[32]byte) (*LinkedColumns, error) {
+			columns := make([]string, 1, n+1)
+			columns[0] = threshold
+			for i := 0; i < n; i++ {
+				signer, err := linkedSolanaAddress(seed, "multisig-signer", i)
+				if err != nil {
+					return nil, err
+				}
+				columns = append(columns, signer)
+			}
+			return &LinkedColumns{columns: columns}, nil
+		}, nil
+	case solanaAccountMint:
+		program, ok := tokenPrograms[tokenProgram]
+		if !ok {
+			return nil, fmt.Errorf("unknown token program %q", tokenProgram)
+		}
+		return func(seed *[32]byte) (*LinkedColumns, error) {
+			mint, err := addrmint.SolanaNativeGenerator{}.Generate(seed[:])
+			if err != nil {
+				return nil, err
+			}
+			owner, err := linkedSolanaAddress(seed, "mint-owner", 0)
+			if err != nil {
+				return nil, err
+			}
+			ata, metadata, err := mintAccounts(common.PublicKeyFromString(mint.String()), common.PublicKeyFromString(owner), program)
+			if err != nil {
+				return nil, err
+			}
+			return &LinkedColumns{columns: []string{owner, ata.ToBase58(), metadata.ToBase58()}}, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown Solana account type %q", account)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/blocto/solana-go-sdk/common"
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+func TestParseMultisig(t *testing.T) {
+	if m, n, err := parseMultisig("2-of-3"); err != nil || m != 2 || n != 3 {
+		t.Errorf("Expected 2-of-3, got %d-of-%d (%v)", m, n, err)
+	}
+	for _, s := range []string{"3-of-2", "0-of-1", "1-of-12", "2of3", "a-of-b"} {
+		if _, _, err := parseMultisig(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestSolanaLinkers(t *testing.T) {
+	var seed [32]byte
+	addrmint.DeriveSeed(nil, selftestSeed, 0, &seed)
+	address := "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj"
+
+	if link, err := newSolanaLinker(solanaAccountWallet, "", ""); link != nil || err != nil {
+		t.Errorf("Wallets should not link accounts")
+	}
+	if _, err := newSolanaLinker("vote", "", ""); err == nil {
+		t.Errorf("Expected an error for an unknown account type")
+	}
+
+	tests := []struct {
+		account string
+		columns int
+	}{
+		{solanaAccountNonce, 1},
+		{solanaAccountMultisig, 4},
+		{solanaAccountMint, 3},
+	}
+	for _, tt := range tests {
+		link, err := newSolanaLinker(tt.account, "2-of-3", "spl")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.account, err)
+		}
+		linked, err := link(&seed)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.account, err)
+		}
+		again, _ := link(&seed)
+		if len(linked.columns) != tt.columns || strings.Join(linked.columns, ",") != strings.Join(again.columns, ",") {
+			t.Errorf("%s: expected %d deterministic columns, got %v and %v", tt.account, tt.columns, linked.columns, again.columns)
+		}
+
+		// Linked accounts are distinct valid addresses
+		seen := map[string]bool{address: true}
+		for _, column := range linked.columns {
+			if column == "2" {
+				continue
+			}
+			if seen[column] || validateAddress("solana", column) != nil {
+				t.Errorf("%s: linked account %s is a duplicate or invalid", tt.account, column)
+			}
+			seen[column] = true
+		}
+	}
+}
+
+func TestResultCollectorLinkedColumns(t *testing.T) {
+	var output bytes.Buffer
+	rc := NewResultCollector(1, 1, nil, false)
+	rc.SetWriter(bufio.NewWriter(&output))
+	rc.SetLayout(&rowLayout{linked: solanaColumnNames(solanaAccountNonce, "")})
+	rc.AddResult(Record{address: "nonce", linked: &LinkedColumns{columns: []string{"authority"}}}, NewProgressBar(1, 10))
+
+	if expected := "authority,nonce\n"; output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
+	}
+}
+
+func TestMintAccounts(t *testing.T) {
+	mint := common.PublicKeyFromString("BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj")
+	owner := common.PublicKeyFromString("J9h7PhWBTkQLMfo2nf5CMyx7kWiFsu9RxsiXCdiQmVsc")
+
+	// Classic SPL accounts must match the SDK's own derivation, and the
+	// metadata PDA the address the Metaplex SDK derives for this mint
+	ata, metadata, err := mintAccounts(mint, owner, common.TokenProgramID)
+	if err != nil {
+		t.Fatalf("mintAccounts failed: %v", err)
+	}
+	expectedATA, _, _ := common.FindAssociatedTokenAddress(owner, mint)
+	expectedMetadata := common.PublicKeyFromString("5iKe1ZzeKJUhBjj4BHjJi31Qdrkw9awsJ2zhGr6djoPx")
+	if ata != expectedATA || metadata != expectedMetadata {
+		t.Errorf("Expected %s and %s, got %s and %s", expectedATA, expectedMetadata, ata, metadata)
+	}
+
+	// Token-2022 associated accounts live at a different address
+	ata2022, _, err := mintAccounts(mint, owner, common.Token2022ProgramID)
+	if err != nil || ata2022 == ata {
+		t.Errorf("Expected a distinct Token-2022 associated token account, got %s (%v)", ata2022, err)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// shardOf returns the shard of a corpus row, partitioning by the FNV-1a hash
+// of its address (the last comma-separated field) so that the same address
+// always lands in the same shard
+func shardOf(line string, shards int) int {
+	key := line[strings.LastIndexByte(line, ',')+1:]
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(shards))
+}
+
+// splitCorpus distributes the rows of in over outs and returns the number of
+// rows written to each
+func splitCorpus(in io.Reader, outs []io.Writer) ([]int, error) {
+	counts := make([]int, len(outs))
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		shard := shardOf(line, len(outs))
+		if _, err := fmt.Fprintln(outs[shard], line); err != nil {
+			return counts, err
+		}
+		counts[shard]++
+	}
+	return counts, scanner.Err()
+}
+
+// shardPath returns the file name of one shard
+func shardPath(prefix string, index int) string {
+	return fmt.Sprintf("%s.%03d", prefix, index)
+}
+
+// mergeManifests combines the manifests of shards into one for the merged
+// corpus. Shards must come from the same network and hash space.
+func mergeManifests(shards []*Manifest, output string, count int) (*Manifest, error) {
+	merged := *shards[0]
+	merged.CreatedAt = time.Now().UTC()
+	merged.Output = output
+	merged.Count = count
+	merged.Shard = nil
+
+	seen := make(map[int]bool)
+	for _, m := range shards {
+		if m.Network != merged.Network {
+			return nil, fmt.Errorf("cannot merge %s and %s shards", merged.Network, m.Network)
+		}
+		if (m.Hashing == nil) != (merged.Hashing == nil) ||
+			(m.Hashing != nil && (m.Hashing.KeyFingerprint != merged.Hashing.KeyFingerprint || m.Hashing.Iterations != merged.Hashing.Iterations)) {
+			return nil, errors.New("cannot merge shards hashed with different keys or iterations")
+		}
+		if m.Shard != nil {
+			seen[m.Shard.Index] = true
+		}
+	}
+
+	// Report shards missing from a split corpus
+	if s := shards[0].Shard; s != nil {
+		for i := 0; i < s.Shards; i++ {
+			if !seen[i] {
+				printWarning("Shard %d of %d from %s is not being merged", i, s.Shards, s.Source)
+			}
+		}
+	}
+	return &merged, nil
+}
+
+// runSplit implements the split subcommand and returns the exit code
+func runSplit(args []string) int {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	shards := fs.Int("shards", 0, "Number of shards to partition the corpus into")
+	prefix := fs.String("prefix", "", "Path prefix of the shard files (default: the input file)")
+	manifestFile := fs.String("manifest", "", "Manifest of the input corpus (default: FILE.manifest.json if it exists)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint split --shards N [--prefix PATH] [--manifest FILE] FILE\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if *shards < 1 {
+		fatalf("--shards must be at least 1")
+	}
+	if fs.NArg() != 1 {
+		fatalf("split takes exactly one input file")
+	}
+	input := fs.Arg(0)
+	if *prefix == "" {
+		*prefix = input
+	}
+
+	// Shards inherit the input's manifest when there is one
+	var manifest *Manifest
+	if *manifestFile == "" {
+		if _, err := os.Stat(manifestPath(input)); err == nil {
+			*manifestFile = manifestPath(input)
+		}
+	}
+	if *manifestFile != "" {
+		m, err := readManifest(*manifestFile)
+		if err != nil {
+			fatalf("Failed to read manifest: %v", err)
+		}
+		manifest = m
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		fatalf("Failed to open input file: %v", err)
+	}
+	defer in.Close()
+
+	files := make([]*os.File, *shards)
+	writers := make([]*bufio.Writer, *shards)
+	outs := make([]io.Writer, *shards)
+	for i := range files {
+		files[i], err = os.Create(shardPath(*prefix, i))
+		if err != nil {
+			fatalf("Failed to create shard file: %v", err)
+		}
+		defer files[i].Close()
+		writers[i] = bufio.NewWriterSize(files[i], 64*1024)
+		outs[i] = writers[i]
+	}
+
+	counts, err := splitCorpus(in, outs)
+	if err != nil {
+		fatalf("Failed to split corpus: %v", err)
+	}
+	for _, w := range writers {
+		if err := w.Flush(); err != nil {
+			fatalf("Failed to write shard: %v", err)
+		}
+	}
+
+	for i, count := range counts {
+		path := shardPath(*prefix, i)
+		if manifest != nil {
+			shardManifest := *manifest
+			shardManifest.CreatedAt = time.Now().UTC()
+			shardManifest.Count = count
+			shardManifest.Output = path
+			shardManifest.Shard = &ShardManifest{Index: i, Shards: *shards, Source: input}
+			if err := writeManifest(manifestPath(path), &shardManifest); err != nil {
+				fatalf("Failed to write shard manifest: %v", err)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "  %s: %s rows\n", path, formatCount(count))
+	}
+	fmt.Fprintf(os.Stderr, "Split %s rows into %d shards\n", formatCount(sum(counts)), *shards)
+	return 0
+}
+
+// runMerge implements the merge subcommand and returns the exit code
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	output := fs.String("output", "", "Path of the merged corpus")
+	manifestFile := fs.String("manifest", "", "Path of the merged manifest (default: OUTPUT.manifest.json when the shards have manifests)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint merge --output FILE [--manifest FILE] SHARD...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if *output == "" {
+		fatalf("--output is required")
+	}
+	if fs.NArg() == 0 {
+		fatalf("merge needs at least one shard file")
+	}
+
+	// Manifests are merged only when every shard has one
+	var manifests []*Manifest
+	for _, shard := range fs.Args() {
+		m, err := readManifest(manifestPath(shard))
+		if errors.Is(err, os.ErrNotExist) {
+			manifests = nil
+			break
+		}
+		if err != nil {
+			fatalf("Failed to read shard manifest: %v", err)
+		}
+		manifests = append(manifests, m)
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		fatalf("Failed to create output file: %v", err)
+	}
+	defer out.Close()
+
+	// Rows are counted as they are copied
+	counter := NewCountingWriter(out)
+	for _, shard := range fs.Args() {
+		in, err := os.Open(shard)
+		if err != nil {
+			fatalf("Failed to open shard: %v", err)
+		}
+		_, err = io.Copy(counter, in)
+		in.Close()
+		if err != nil {
+			fatalf("Failed to merge %s: %v", shard, err)
+		}
+	}
+	rows := int(counter.Rows())
+
+	if manifests != nil {
+		merged, err := mergeManifests(manifests, *output, rows)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if *manifestFile == "" {
+			*manifestFile = manifestPath(*output)
+		}
+		if err := writeManifest(*manifestFile, merged); err != nil {
+			fatalf("Failed to write manifest: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote manifest to %s\n", *manifestFile)
+	}
+
+	fmt.Fprintf(os.Stderr, "Merged %d shards into %s (%s rows)\n", fs.NArg(), *output, formatCount(rows))
+	return 0
+}
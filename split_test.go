@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestShardOf(t *testing.T) {
+	addr := "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"
+	shard := shardOf(addr, 16)
+
+	// Partitioning is by address, so hash-prefixed rows land in the same shard
+	if got := shardOf("dfe26c,"+addr, 16); got != shard {
+		t.Errorf("Expected hash-prefixed row in shard %d, got %d", shard, got)
+	}
+	// Partitioning is stable across calls
+	if got := shardOf(addr, 16); got != shard {
+		t.Errorf("Expected shard %d, got %d", shard, got)
+	}
+}
+
+func TestSplitCorpus(t *testing.T) {
+	var input strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&input, "0x%040x\n", i)
+	}
+
+	bufs := make([]bytes.Buffer, 4)
+	outs := make([]io.Writer, len(bufs))
+	for i := range bufs {
+		outs[i] = &bufs[i]
+	}
+	counts, err := splitCorpus(strings.NewReader(input.String()), outs)
+	if err != nil {
+		t.Fatalf("splitCorpus failed: %v", err)
+	}
+	if sum(counts) != 1000 {
+		t.Errorf("Expected 1000 rows across shards, got %d", sum(counts))
+	}
+
+	for i := range bufs {
+		lines := strings.Split(strings.TrimSuffix(bufs[i].String(), "\n"), "\n")
+		if len(lines) != counts[i] {
+			t.Errorf("Shard %d: expected %d rows, got %d", i, counts[i], len(lines))
+		}
+		if counts[i] < 150 {
+			t.Errorf("Shard %d is badly unbalanced with %d rows", i, counts[i])
+		}
+		for _, line := range lines {
+			if shardOf(line, 4) != i {
+				t.Errorf("Row %s written to shard %d", line, i)
+			}
+		}
+	}
+}
+
+func TestMergeManifests(t *testing.T) {
+	hashing := &HashingManifest{Algorithm: "HMAC-SHA256", Iterations: 1, KeyFingerprint: "4bf5122f344554c5"}
+	shards := []*Manifest{
+		{Network: "ethereum", Count: 2, Hashing: hashing, Shard: &ShardManifest{Index: 0, Shards: 2}},
+		{Network: "ethereum", Count: 3, Hashing: hashing, Shard: &ShardManifest{Index: 1, Shards: 2}},
+	}
+
+	merged, err := mergeManifests(shards, "merged.txt", 5)
+	if err != nil {
+		t.Fatalf("mergeManifests failed: %v", err)
+	}
+	if merged.Count != 5 || merged.Output != "merged.txt" || merged.Shard != nil || merged.Hashing.KeyFingerprint != hashing.KeyFingerprint {
+		t.Errorf("Unexpected merged manifest %+v", merged)
+	}
+
+	// Shards from different hash spaces cannot be merged
+	other := *shards[1]
+	other.Hashing = &HashingManifest{Algorithm: "HMAC-SHA256", Iterations: 1, KeyFingerprint: "0000000000000000"}
+	if _, err := mergeManifests([]*Manifest{shards[0], &other}, "merged.txt", 5); err == nil {
+		t.Errorf("Expected an error merging different key fingerprints")
+	}
+
+	other = *shards[1]
+	other.Network = "bitcoin"
+	if _, err := mergeManifests([]*Manifest{shards[0], &other}, "merged.txt", 5); err == nil {
+		t.Errorf("Expected an error merging different networks")
+	}
+}
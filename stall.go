@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+const onStallDump = "dump" // Print a goroutine dump and keep waiting; onErrorAbort also fails the run
+
+// StallWatchdog watches a run's progress and reacts when it stops moving
+// for longer than the timeout, such as when a sink hangs or the pipeline
+// deadlocks. It prints a goroutine dump once per stall and, unless it only
+// dumps, fails the run.
+type StallWatchdog struct {
+	timeout  time.Duration
+	abort    bool
+	progress func() int64 // Grows while the run makes progress
+	idle     func() bool  // Reports stalls that are expected, such as a paused output
+	out      io.Writer    // Receives the goroutine dump
+	fail     func(elapsed time.Duration)
+	interval time.Duration // How often progress is checked
+}
+
+// NewStallWatchdog creates a watchdog that dumps goroutines to out and,
+// with abort set, calls fail when progress stays the same for timeout.
+// Progress is checked at most once a millisecond, however short the
+// timeout.
+func NewStallWatchdog(timeout time.Duration, abort bool, progress func() int64, out io.Writer, fail func(elapsed time.Duration)) *StallWatchdog {
+	interval := max(min(timeout/4, time.Second), time.Millisecond)
+	return &StallWatchdog{timeout: timeout, abort: abort, progress: progress, out: out, fail: fail, interval: interval}
+}
+
+// Run checks progress until stop is closed
+func (w *StallWatchdog) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	last := w.progress()
+	since := time.Now()
+	dumped := false
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if current := w.progress(); current != last || (w.idle != nil && w.idle()) {
+				last, since, dumped = current, now, false
+				continue
+			}
+			elapsed := now.Sub(since)
+			if elapsed < w.timeout || dumped {
+				continue
+			}
+			dumped = true
+			fmt.Fprintf(w.out, "No progress for %s; goroutine dump follows\n", elapsed.Round(time.Second))
+			w.dump()
+			if w.abort {
+				w.fail(elapsed)
+				return
+			}
+		}
+	}
+}
+
+// dump writes the stacks of all goroutines, with the argument words of
+// their frames dropped so no key material leaks into the dump
+func (w *StallWatchdog) dump() {
+	var stacks strings.Builder
+	pprof.Lookup("goroutine").WriteTo(&stacks, 2)
+	io.WriteString(w.out, redactStack(stacks.String()))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStallWatchdog(t *testing.T) {
+	var progress atomic.Int64
+	var out strings.Builder
+	failed := make(chan time.Duration, 1)
+	watchdog := NewStallWatchdog(40*time.Millisecond, true, progress.Load, &out, func(elapsed time.Duration) {
+		failed <- elapsed
+	})
+	watchdog.interval = 5 * time.Millisecond
+
+	// Progress keeps the watchdog quiet
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		watchdog.Run(stop)
+		close(done)
+	}()
+	for range 20 {
+		progress.Add(1)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(failed) != 0 {
+		t.Fatal("Expected no stall while progress is made")
+	}
+
+	// A stall dumps the goroutines, this test's among them, and fails the run
+	select {
+	case elapsed := <-failed:
+		if elapsed < 40*time.Millisecond {
+			t.Errorf("Expected the stall to last at least the timeout, got %s", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stall to be detected")
+	}
+	<-done
+	close(stop)
+	if !strings.Contains(out.String(), "No progress for") || !strings.Contains(out.String(), "TestStallWatchdog") {
+		t.Errorf("Expected a goroutine dump, got %q", out.String())
+	}
+}
+
+func TestStallWatchdogIdleAndDump(t *testing.T) {
+	var out strings.Builder
+	var paused atomic.Bool
+	paused.Store(true)
+	watchdog := NewStallWatchdog(20*time.Millisecond, false, func() int64 { return 0 }, &out, func(time.Duration) {
+		t.Error("Expected dump mode not to fail the run")
+	})
+	watchdog.interval = 2 * time.Millisecond
+	watchdog.idle = paused.Load
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		watchdog.Run(stop)
+		close(done)
+	}()
+
+	// An expected stall, such as a paused output, is not reported
+	time.Sleep(60 * time.Millisecond)
+	paused.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	close(stop)
+	<-done
+	if dumps := strings.Count(out.String(), "No progress for"); dumps != 1 {
+		t.Errorf("Expected one dump per stall, got %d", dumps)
+	}
+}
+
+func TestStallWatchdogTinyTimeout(t *testing.T) {
+	// A quarter of the timeout rounds down to zero, which a ticker rejects
+	var out strings.Builder
+	failed := make(chan time.Duration, 1)
+	watchdog := NewStallWatchdog(3*time.Nanosecond, true, func() int64 { return 0 }, &out, func(elapsed time.Duration) {
+		failed <- elapsed
+	})
+	if watchdog.interval <= 0 {
+		t.Fatalf("Expected a positive check interval, got %s", watchdog.interval)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go watchdog.Run(stop)
+	select {
+	case <-failed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stall to be detected")
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Character encodings of address bodies, each tested against its own alphabet
+const (
+	encodingHex       = "hex"
+	encodingBase58    = "base58"
+	encodingBech32    = "bech32"
+	encodingBase64URL = "base64url"
+)
+
+// encodingAlphabets lists the characters each encoding can produce
+var encodingAlphabets = map[string]string{
+	encodingHex:       "0123456789abcdef",
+	encodingBase58:    "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz",
+	encodingBech32:    "qpzry9x8gf2tvdw0s3jn54khce6mua7l",
+	encodingBase64URL: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+}
+
+// statsBiasZ is the chi-square z-score above which a distribution is
+// reported as biased; a uniform corpus exceeds it about once in 3.5 million
+const statsBiasZ = 5
+
+// addressBody returns the part of addr whose characters should be uniform
+// for a correctly derived address, with its encoding. Characters fixed by
+// the format (0x, version bytes and bech32 witness versions, TON flags and
+// workchain) and the leading base58 digits, which are biased by the size of
+// the encoded number, are dropped, as is the padding character of Taproot
+// programs. Ethereum checksum casing is folded to lowercase.
+func addressBody(network, addr string) (string, string) {
+	switch network {
+	case "ethereum":
+		return strings.ToLower(strings.TrimPrefix(addr, "0x")), encodingHex
+	case "bitcoin":
+		lower := strings.ToLower(addr)
+		for _, hrp := range []string{"bc1", "tb1", "bcrt1"} {
+			if !strings.HasPrefix(lower, hrp) || len(lower) < len(hrp)+8 {
+				continue
+			}
+			data := lower[len(hrp)+1:]
+			if lower[len(hrp)] == 'p' {
+				// A 256-bit program leaves 1 bit and 4 bits of padding in its last character
+				data = data[:len(data)-7] + data[len(data)-6:]
+			}
+			return data, encodingBech32
+		}
+		if len(addr) < 2 {
+			return "", encodingBase58
+		}
+		return addr[2:], encodingBase58
+	case "solana":
+		if addr == "" {
+			return "", encodingBase58
+		}
+		return addr[1:], encodingBase58
+	case "ton":
+		if len(addr) < 3 {
+			return "", encodingBase64URL
+		}
+		return addr[3:], encodingBase64URL
+	}
+	return addr, ""
+}
+
+// EncodingStats holds the character and prefix frequencies of the address
+// bodies in one encoding
+type EncodingStats struct {
+	encoding   string
+	alphabet   string
+	addresses  int
+	characters int      // Characters counted, excluding unexpected ones
+	unexpected int      // Characters outside the alphabet
+	counts     [256]int // Occurrences of each character
+	prefixes   map[string]int
+}
+
+// add counts the characters and prefix of one address body
+func (s *EncodingStats) add(body string, prefixLength int) {
+	s.addresses++
+	for i := 0; i < len(body); i++ {
+		if strings.IndexByte(s.alphabet, body[i]) < 0 {
+			s.unexpected++
+			continue
+		}
+		s.counts[body[i]]++
+		s.characters++
+	}
+	if len(body) >= prefixLength {
+		s.prefixes[body[:prefixLength]]++
+	}
+}
+
+// charTest returns the chi-square statistic of the character counts
+// against a uniform distribution over the alphabet, with its z-score
+func (s *EncodingStats) charTest() (float64, float64) {
+	counts := make(map[string]int, len(s.alphabet))
+	for i := 0; i < len(s.alphabet); i++ {
+		if n := s.counts[s.alphabet[i]]; n > 0 {
+			counts[s.alphabet[i:i+1]] = n
+		}
+	}
+	chi := chiSquare(counts, float64(len(s.alphabet)), s.characters)
+	return chi, chiSquareZ(chi, len(s.alphabet)-1)
+}
+
+// prefixTest returns the chi-square statistic of the prefix counts against
+// a uniform distribution over every possible prefix, with its z-score
+func (s *EncodingStats) prefixTest(prefixLength int) (float64, float64) {
+	total := 0
+	for _, n := range s.prefixes {
+		total += n
+	}
+	cells := math.Pow(float64(len(s.alphabet)), float64(prefixLength))
+	chi := chiSquare(s.prefixes, cells, total)
+	return chi, chiSquareZ(chi, int(cells)-1)
+}
+
+// entropy returns the Shannon entropy of the characters in bits
+func (s *EncodingStats) entropy() float64 {
+	bits := 0.0
+	for i := 0; i < len(s.alphabet); i++ {
+		if n := s.counts[s.alphabet[i]]; n > 0 {
+			p := float64(n) / float64(s.characters)
+			bits -= p * math.Log2(p)
+		}
+	}
+	return bits
+}
+
+// chiSquare returns Pearson's statistic for total observations spread
+// uniformly over cells, given the counts of the cells that were observed
+func chiSquare(counts map[string]int, cells float64, total int) float64 {
+	expected := float64(total) / cells
+	if expected == 0 {
+		return 0
+	}
+	chi := 0.0
+	for _, n := range counts {
+		d := float64(n) - expected
+		chi += d * d / expected
+	}
+	// Each unobserved cell contributes its full expected count
+	return chi + (cells-float64(len(counts)))*expected
+}
+
+// chiSquareZ converts a chi-square statistic to an approximately standard
+// normal z-score with the Wilson-Hilferty transformation
+func chiSquareZ(chi float64, df int) float64 {
+	if df < 1 {
+		return 0
+	}
+	k := float64(df)
+	variance := 2 / (9 * k)
+	return (math.Cbrt(chi/k) - (1 - variance)) / math.Sqrt(variance)
+}
+
+// CorpusStats summarizes an address corpus
+type CorpusStats struct {
+	rows       int
+	distinct   int
+	duplicates int
+	runs       int              // Sorted runs spilled to disk
+	encodings  []*EncodingStats // In the order first seen
+}
+
+// collectStats reads the address of every non-empty row of in (the last
+// comma-separated field, so hash,address rows work too), counting the
+// characters and prefixes of each address body and counting duplicates by
+// sorting the addresses through sorter
+func collectStats(network string, in io.Reader, prefixLength int, sorter *ExternalSorter) (*CorpusStats, error) {
+	stats := &CorpusStats{}
+	byEncoding := make(map[string]*EncodingStats)
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		addr := line[strings.LastIndexByte(line, ',')+1:]
+		stats.rows++
+
+		body, encoding := addressBody(network, addr)
+		s := byEncoding[encoding]
+		if s == nil {
+			s = &EncodingStats{encoding: encoding, alphabet: encodingAlphabets[encoding], prefixes: make(map[string]int)}
+			byEncoding[encoding] = s
+			stats.encodings = append(stats.encodings, s)
+		}
+		s.add(body, prefixLength)
+
+		if err := sorter.Add(addr); err != nil {
+			return stats, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+
+	// Sorting brings duplicates next to each other
+	previous := ""
+	err := sorter.Merge(func(addr string) error {
+		if stats.distinct > 0 && addr == previous {
+			stats.duplicates++
+			return nil
+		}
+		previous = addr
+		stats.distinct++
+		return nil
+	})
+	stats.runs = sorter.Runs()
+	return stats, err
+}
+
+// percent formats part of total as a percentage
+func percent(part, total float64) string {
+	if total == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(part/total*100, 'f', 3, 64) + "%"
+}
+
+// statsCharRows formats the character frequencies as report rows, with the
+// deviation of each character from its expected count
+func statsCharRows(s *EncodingStats) [][]string {
+	expected := float64(s.characters) / float64(len(s.alphabet))
+	rows := [][]string{{"char", "count", "share", "deviation"}}
+	for i := 0; i < len(s.alphabet); i++ {
+		n := float64(s.counts[s.alphabet[i]])
+		deviation := "-"
+		if expected > 0 {
+			deviation = fmt.Sprintf("%+.2f%%", (n-expected)/expected*100)
+		}
+		rows = append(rows, []string{s.alphabet[i : i+1], formatCount(int(n)), percent(n, float64(s.characters)), deviation})
+	}
+	return rows
+}
+
+// statsPrefixRows formats the top most and least common prefixes as
+// report rows
+func statsPrefixRows(s *EncodingStats, top int) [][]string {
+	type prefixCount struct {
+		prefix string
+		count  int
+	}
+	var counts []prefixCount
+	total := 0
+	for prefix, n := range s.prefixes {
+		counts = append(counts, prefixCount{prefix, n})
+		total += n
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].prefix < counts[j].prefix
+	})
+
+	rows := [][]string{{"prefix", "count", "share", ""}}
+	add := func(c prefixCount, label string) {
+		rows = append(rows, []string{c.prefix, formatCount(c.count), percent(float64(c.count), float64(total)), label})
+	}
+	if len(counts) <= 2*top {
+		for _, c := range counts {
+			add(c, "")
+		}
+		return rows
+	}
+	for _, c := range counts[:top] {
+		add(c, "most common")
+	}
+	for _, c := range counts[len(counts)-top:] {
+		add(c, "least common")
+	}
+	return rows
+}
+
+// writeStatsReport writes the corpus summary and the frequency tables of
+// each encoding, and returns the problems found: duplicates, unexpected
+// characters and biased distributions
+func writeStatsReport(w io.Writer, stats *CorpusStats, prefixLength, top int) ([]string, error) {
+	var problems []string
+	if stats.duplicates > 0 {
+		problems = append(problems, fmt.Sprintf("%s duplicate addresses", formatCount(stats.duplicates)))
+	}
+	fmt.Fprintf(w, "Rows: %s  distinct: %s  duplicates: %s\n", formatCount(stats.rows), formatCount(stats.distinct), formatCount(stats.duplicates))
+
+	for _, s := range stats.encodings {
+		if s.alphabet == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s: %s addresses, %s characters\n", s.encoding, formatCount(s.addresses), formatCount(s.characters))
+		if s.unexpected > 0 {
+			fmt.Fprintf(w, "Unexpected characters: %s\n", formatCount(s.unexpected))
+			problems = append(problems, fmt.Sprintf("%s characters outside the %s alphabet", formatCount(s.unexpected), s.encoding))
+		}
+
+		chi, z := s.charTest()
+		fmt.Fprintf(w, "Entropy: %.4f of %.4f bits per character, chi-square %.1f (%d df, z %.2f)\n",
+			s.entropy(), math.Log2(float64(len(s.alphabet))), chi, len(s.alphabet)-1, z)
+		if z > statsBiasZ {
+			problems = append(problems, fmt.Sprintf("%s character frequencies are biased (z %.1f)", s.encoding, z))
+		}
+		if err := writeBenchTable(w, statsCharRows(s)); err != nil {
+			return problems, err
+		}
+
+		chi, z = s.prefixTest(prefixLength)
+		cells := math.Pow(float64(len(s.alphabet)), float64(prefixLength))
+		fmt.Fprintf(w, "\nPrefixes of %d characters: %s of %s seen, chi-square %.1f (z %.2f)\n",
+			prefixLength, formatCount(len(s.prefixes)), formatCount(int(cells)), chi, z)
+		if z > statsBiasZ {
+			problems = append(problems, fmt.Sprintf("%s prefix frequencies are biased (z %.1f)", s.encoding, z))
+		}
+		if err := writeBenchTable(w, statsPrefixRows(s, top)); err != nil {
+			return problems, err
+		}
+	}
+	return problems, nil
+}
+
+// runStats implements the stats subcommand and returns the exit code
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	network := fs.String("network", "", "Blockchain network of the addresses (ethereum, bitcoin, solana, ton)")
+	prefixLength := fs.Int("prefix-length", 2, "Number of leading characters of each address body in the prefix histogram (1-4)")
+	top := fs.Int("top", 10, "Number of most and least common prefixes to list")
+	output := fs.String("output", "", "File for the report (default: stdout)")
+	memoryMB := fs.Int("memory-mb", 1024, "Memory in MiB for finding duplicates before spilling sorted runs to disk")
+	tempDir := fs.String("temp-dir", os.TempDir(), "Directory for sorted runs spilled to disk")
+	encryptTemp := fs.Bool("encrypt-temp", false, "Encrypt the sorted runs spilled to disk with a key held only in memory")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint stats --network NETWORK [--prefix-length N] [--top N] [--memory-mb N] [--temp-dir DIR] [--encrypt-temp] [--output FILE] [FILE]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if *network != "ethereum" && *network != "bitcoin" && *network != "solana" && *network != "ton" {
+		fatalf("Network must be ethereum, bitcoin, solana, or ton")
+	}
+	if *prefixLength < 1 || *prefixLength > 4 {
+		fatalf("Prefix length must be between 1 and 4")
+	}
+	if *top < 1 {
+		fatalf("--top must be at least 1")
+	}
+	if *memoryMB < 1 {
+		fatalf("Memory limit must be at least 1 MiB")
+	}
+	if fs.NArg() > 1 {
+		fatalf("stats takes at most one input file")
+	}
+
+	// Read from the named file, or stdin when none is given
+	in := os.Stdin
+	if fs.NArg() == 1 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fatalf("Failed to open input file: %v", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create report file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	sorter := NewExternalSorter(*tempDir, int64(*memoryMB)<<20)
+	if *encryptTemp {
+		cipher, err := NewTempCipher()
+		if err != nil {
+			fatalf("Failed to create the temp file key: %v", err)
+		}
+		sorter.SetCipher(cipher)
+	}
+	stats, err := collectStats(*network, in, *prefixLength, sorter)
+	if closeErr := sorter.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fatalf("Failed to read addresses: %v", err)
+	}
+	if stats.rows == 0 {
+		fatalf("No addresses found")
+	}
+
+	writer := bufio.NewWriterSize(out, 64*1024)
+	problems, err := writeStatsReport(writer, stats, *prefixLength, *top)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		fatalf("Failed to write report: %v", err)
+	}
+
+	if stats.runs > 0 {
+		fmt.Fprintf(os.Stderr, "Merged %d sorted runs spilled to %s\n", stats.runs, *tempDir)
+	}
+	for _, problem := range problems {
+		printWarning("%s", problem)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Checked %s addresses: no duplicates or bias found\n", formatCount(stats.rows))
+	return 0
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+func TestAddressBody(t *testing.T) {
+	tests := []struct {
+		network  string
+		addr     string
+		body     string
+		encoding string
+	}{
+		{"ethereum", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f", "0d747f8adfde4bef87cf21fea682083c7149268f", encodingHex},
+		{"bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "w508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", encodingBech32},
+		// The padding character of the Taproot program is dropped
+		{"bitcoin", "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", "5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxkedrcr", encodingBech32},
+		{"bitcoin", "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT", "EXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT", encodingBase58},
+		{"solana", "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj", "G3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj", encodingBase58},
+		{"ton", "UQCuIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8d", "uIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8d", encodingBase64URL},
+	}
+	for _, tt := range tests {
+		body, encoding := addressBody(tt.network, tt.addr)
+		if body != tt.body || encoding != tt.encoding {
+			t.Errorf("addressBody(%s, %s) = %s (%s), expected %s (%s)", tt.network, tt.addr, body, encoding, tt.body, tt.encoding)
+		}
+	}
+}
+
+func TestCollectStats(t *testing.T) {
+	// Generated addresses, some with a hash column
+	var corpus strings.Builder
+	deriver := v1Seeds(t, "c8c5e5a7f326a2b5")
+	var seed [32]byte
+	for i := 0; i < 2000; i++ {
+		deriver.Derive(i, &seed)
+		addr, err := addrmint.EthereumGenerator{}.Generate(seed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			fmt.Fprintf(&corpus, "%08x,", i)
+		}
+		fmt.Fprintln(&corpus, addr)
+	}
+
+	stats, err := collectStats("ethereum", strings.NewReader(corpus.String()), 2, NewExternalSorter(t.TempDir(), 1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.rows != 2000 || stats.duplicates != 0 || len(stats.encodings) != 1 || stats.encodings[0].characters != 2000*40 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+	problems, err := writeStatsReport(&bytes.Buffer{}, stats, 2, 5)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Expected no problems in a generated corpus, got %v (%v)", problems, err)
+	}
+
+	// Duplicating the corpus and skewing a character are both caught
+	biased := corpus.String() + corpus.String() + strings.Repeat("0xffffffffffffffffffffffffffffffffffffffff\n", 50)
+	stats, err = collectStats("ethereum", strings.NewReader(biased), 2, NewExternalSorter(t.TempDir(), 1<<10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.duplicates != 2049 || stats.distinct != 2001 || stats.runs == 0 {
+		t.Errorf("Expected 2049 duplicates of 2001 addresses across spilled runs, got %+v", stats)
+	}
+	problems, err = writeStatsReport(&bytes.Buffer{}, stats, 2, 5)
+	if err != nil || len(problems) != 3 {
+		t.Errorf("Expected duplicates and biased characters and prefixes, got %v (%v)", problems, err)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Output formats for --format that describe each row, beside plain and avro
+const (
+	outputFormatCSV    = "csv"    // Comma-separated rows after a header row
+	outputFormatNDJSON = "ndjson" // One JSON object per line
+	outputFormatJSON   = "json"   // A JSON array of objects
+)
+
+// structuredFormat reports whether format names its fields, in which case
+// every row starts with its index and network
+func structuredFormat(format string) bool {
+	return format == outputFormatCSV || format == outputFormatNDJSON || format == outputFormatJSON || format == outputFormatParquet
+}
+
+// structuredBufferBytes is the encoded size at which StructuredWriter
+// writes its rows out
+const structuredBufferBytes = 64 * 1024
+
+// StructuredWriter writes records as CSV with a header, as NDJSON or as a
+// JSON array, with a field per column. In JSON the index is written as a
+// number and every other column as a string. Close ends a JSON array.
+type StructuredWriter struct {
+	out     io.Writer
+	format  string
+	layout  *rowLayout
+	columns []outputColumn
+	names   [][]byte // JSON object keys of columns, quoted and with their colon
+
+	value []byte // Reused buffer for the value of one column
+	buf   []byte // Encoded rows not written out yet
+	rows  int64
+}
+
+// NewStructuredWriter writes the CSV header or the start of the JSON array
+// to out and returns a writer for the records that follow it
+func NewStructuredWriter(out io.Writer, format string, layout *rowLayout, columns []outputColumn) (*StructuredWriter, error) {
+	sw := &StructuredWriter{out: out, format: format, layout: layout, columns: columns}
+	var header []byte
+	switch format {
+	case outputFormatCSV:
+		for i, c := range columns {
+			if i > 0 {
+				header = append(header, ',')
+			}
+			header = appendCSVField(header, []byte(c.name))
+		}
+		header = append(header, '\n')
+	case outputFormatJSON:
+		header = []byte("[\n")
+		fallthrough
+	case outputFormatNDJSON:
+		for _, c := range columns {
+			sw.names = append(sw.names, append(appendJSONString(nil, []byte(c.name)), ':'))
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if len(header) > 0 {
+		if _, err := out.Write(header); err != nil {
+			return nil, err
+		}
+	}
+	return sw, nil
+}
+
+// WriteRecord encodes one record, writing the buffered rows out once they
+// grow past structuredBufferBytes
+func (sw *StructuredWriter) WriteRecord(record Record) error {
+	if sw.format == outputFormatJSON && sw.rows > 0 {
+		sw.buf = append(sw.buf, ",\n"...)
+	}
+	if sw.format != outputFormatCSV {
+		sw.buf = append(sw.buf, '{')
+	}
+	for i, c := range sw.columns {
+		if i > 0 {
+			sw.buf = append(sw.buf, ',')
+		}
+		if sw.format != outputFormatCSV {
+			sw.buf = append(sw.buf, sw.names[i]...)
+		}
+		var err error
+		if sw.value, err = sw.layout.appendValue(sw.value[:0], c, &record); err != nil {
+			return err
+		}
+		switch {
+		case sw.format == outputFormatCSV:
+			sw.buf = appendCSVField(sw.buf, sw.value)
+		case c.kind == columnIndex:
+			sw.buf = append(sw.buf, sw.value...)
+		default:
+			sw.buf = appendJSONString(sw.buf, sw.value)
+		}
+	}
+	switch sw.format {
+	case outputFormatCSV:
+		sw.buf = append(sw.buf, '\n')
+	case outputFormatNDJSON:
+		sw.buf = append(sw.buf, "}\n"...)
+	default:
+		sw.buf = append(sw.buf, '}')
+	}
+	sw.rows++
+	if len(sw.buf) >= structuredBufferBytes {
+		return sw.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows out
+func (sw *StructuredWriter) Flush() error {
+	if len(sw.buf) == 0 {
+		return nil
+	}
+	_, err := sw.out.Write(sw.buf)
+	sw.buf = sw.buf[:0]
+	return err
+}
+
+// Close writes the buffered rows out and ends the JSON array. CSV and
+// NDJSON need no trailer.
+func (sw *StructuredWriter) Close() error {
+	if err := sw.Flush(); err != nil || sw.format != outputFormatJSON {
+		return err
+	}
+	trailer := "]\n"
+	if sw.rows > 0 {
+		trailer = "\n]\n"
+	}
+	_, err := io.WriteString(sw.out, trailer)
+	return err
+}
+
+// structuredRowOverhead returns how many bytes each row of format takes
+// beyond the plain row of the same columns
+func structuredRowOverhead(format string, columns []outputColumn) int {
+	switch format {
+	case outputFormatCSV:
+		return 0
+	case outputFormatParquet:
+		// Uncompressed, length prefixes replace the separators
+		return 3 * len(columns)
+	}
+	// Braces and the separator after the row, then a key and quotes per field
+	overhead := 2
+	for _, c := range columns {
+		overhead += len(c.name) + 3
+		if c.kind != columnIndex {
+			overhead += 2
+		}
+	}
+	return overhead
+}
+
+// appendCSVField appends value as a CSV field, quoted if it holds a quote
+// or a line break
+func appendCSVField(b, value []byte) []byte {
+	if !bytes.ContainsAny(value, "\"\r\n,") {
+		return append(b, value...)
+	}
+	b = append(b, '"')
+	b = append(b, bytes.ReplaceAll(value, []byte(`"`), []byte(`""`))...)
+	return append(b, '"')
+}
+
+// appendJSONString appends value as a JSON string
+func appendJSONString(b, value []byte) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for _, c := range value {
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStructuredWriter(t *testing.T) {
+	layout := &rowLayout{linked: []string{"ens_name"}, generateHash: true, offset: 7}
+	columns := layout.structuredColumns()
+	// Values are encoded from the records, so commas and quotes are kept
+	written := []Record{
+		{index: 0, network: "ethereum", address: "0xa8b4", linked: &LinkedColumns{columns: []string{"alice.eth"}}},
+		{index: 1, network: "ethereum", address: "0x9ea5", linked: &LinkedColumns{columns: []string{`"bob\,eth`}}},
+	}
+	write := func(sw *StructuredWriter) {
+		for i := range written {
+			if err := sw.WriteRecord(written[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := sw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	sw, err := NewStructuredWriter(&out, outputFormatCSV, layout, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(sw)
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil || len(records) != 3 || strings.Join(records[0], ",") != "index,network,ens_name,hash,address" || records[2][2] != `"bob\,eth` || records[2][0] != "8" {
+		t.Errorf("Unexpected CSV %q (%v)", records, err)
+	}
+
+	for _, format := range []string{outputFormatNDJSON, outputFormatJSON} {
+		out.Reset()
+		sw, err := NewStructuredWriter(&out, format, layout, columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(sw)
+		type row struct {
+			Index   int64  `json:"index"`
+			Network string `json:"network"`
+			ENSName string `json:"ens_name"`
+			Address string `json:"address"`
+		}
+		var decoded []row
+		if format == outputFormatJSON {
+			err = json.Unmarshal(out.Bytes(), &decoded)
+		} else {
+			for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+				var r row
+				if err = json.Unmarshal([]byte(line), &r); err != nil {
+					break
+				}
+				decoded = append(decoded, r)
+			}
+		}
+		if err != nil || len(decoded) != 2 || decoded[0] != (row{7, "ethereum", "alice.eth", "0xa8b4"}) || decoded[1] != (row{8, "ethereum", `"bob\,eth`, "0x9ea5"}) {
+			t.Errorf("%s: unexpected rows %+v (%v) in %s", format, decoded, err, out.String())
+		}
+	}
+
+	// An empty run is still a JSON array
+	out.Reset()
+	sw, _ = NewStructuredWriter(&out, outputFormatJSON, layout, columns)
+	sw.Close()
+	var empty []any
+	if err := json.Unmarshal(out.Bytes(), &empty); err != nil || len(empty) != 0 {
+		t.Errorf("Expected an empty array, got %q", out.String())
+	}
+}
+
+func TestResultCollectorEncoder(t *testing.T) {
+	var output, encoded bytes.Buffer
+	layout := &rowLayout{offset: 100}
+	rc := NewResultCollector(2, 1, nil, false)
+	rc.SetWriter(bufio.NewWriter(&output))
+	rc.SetLayout(layout)
+	sw, _ := NewStructuredWriter(&encoded, outputFormatCSV, layout, layout.structuredColumns())
+	rc.SetEncoder(sw)
+
+	pb := NewProgressBar(2, 10)
+	rc.AddResult(Record{index: 1, network: "bitcoin", address: "address1"}, pb)
+	rc.AddResult(Record{index: 0, network: "bitcoin", address: "address0"}, pb)
+
+	// Records are encoded in order, and plain rows still reach the writer
+	if expected := "index,network,address\n100,bitcoin,address0\n101,bitcoin,address1\n"; encoded.String() != expected {
+		t.Errorf("Expected encoded output %q, got %q", expected, encoded.String())
+	}
+	if expected := "address0\naddress1\n"; output.String() != expected {
+		t.Errorf("Expected plain rows %q, got %q", expected, output.String())
+	}
+}
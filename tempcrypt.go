@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// tempChunkBytes is the plaintext sealed in each chunk of an encrypted temp file
+const tempChunkBytes = 64 * 1024
+
+// tempKeyEnv hands the temp file key from the parent process to its shards,
+// keeping it out of their command lines
+const tempKeyEnv = "ADDRMINT_TEMP_KEY"
+
+// errTempCorrupt is returned for encrypted temp files that fail to
+// authenticate, including truncated ones
+var errTempCorrupt = errors.New("encrypted temp file is corrupt or truncated")
+
+// TempCipher encrypts temp files with AES-256-GCM under a key that only
+// lives in memory for the run. Files are sealed in chunks whose nonces count
+// up from a random per-file prefix, and the last chunk is marked as such, so
+// reordered, altered or truncated files fail to read instead of yielding
+// wrong rows. A nil *TempCipher leaves files in plaintext.
+type TempCipher struct {
+	key  []byte
+	aead cipher.AEAD
+}
+
+// NewTempCipher creates a cipher with a new random key
+func NewTempCipher() (*TempCipher, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return newTempCipherKey(key)
+}
+
+// tempCipherFromEnv creates the cipher whose key the parent process passed
+// in tempKeyEnv
+func tempCipherFromEnv() (*TempCipher, error) {
+	key, err := hex.DecodeString(os.Getenv(tempKeyEnv))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must hold a 32-byte hex key", tempKeyEnv)
+	}
+	return newTempCipherKey(key)
+}
+
+func newTempCipherKey(key []byte) (*TempCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &TempCipher{key: key, aead: aead}, nil
+}
+
+// Env returns the environment variable that passes the key to a child
+func (c *TempCipher) Env() string {
+	return tempKeyEnv + "=" + hex.EncodeToString(c.key)
+}
+
+// Writer returns a writer that encrypts into w. Close seals the last chunk
+// and must be called once everything is written; it does not close w.
+func (c *TempCipher) Writer(w io.Writer) io.WriteCloser {
+	if c == nil {
+		return nopWriteCloser{w}
+	}
+	return &sealWriter{c: c, w: w, buf: make([]byte, 0, tempChunkBytes)}
+}
+
+// Reader returns a reader that decrypts a file written through Writer
+func (c *TempCipher) Reader(r io.Reader) io.Reader {
+	if c == nil {
+		return r
+	}
+	return &openReader{c: c, r: r}
+}
+
+// tempNonce returns the nonce of a file's chunk
+func tempNonce(prefix *[8]byte, chunk uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix[:])
+	binary.BigEndian.PutUint32(nonce[8:], chunk)
+	return nonce
+}
+
+// tempAAD marks whether a chunk is the last of its file
+func tempAAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// sealWriter encrypts a temp file chunk by chunk. The file starts with the
+// nonce prefix; every chunk is its sealed length and the sealed bytes.
+type sealWriter struct {
+	c       *TempCipher
+	w       io.Writer
+	prefix  [8]byte
+	chunk   uint32
+	started bool
+	buf     []byte
+	sealed  []byte // Reused output of seal
+}
+
+// Write buffers p, sealing every full chunk
+func (s *sealWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		take := min(len(p), tempChunkBytes-len(s.buf))
+		s.buf = append(s.buf, p[:take]...)
+		p = p[take:]
+		if len(s.buf) == tempChunkBytes {
+			if err := s.seal(false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close seals what is left as the last chunk
+func (s *sealWriter) Close() error {
+	return s.seal(true)
+}
+
+func (s *sealWriter) seal(last bool) error {
+	if !s.started {
+		rand.Read(s.prefix[:])
+		if _, err := s.w.Write(s.prefix[:]); err != nil {
+			return err
+		}
+		s.started = true
+	}
+	out := binary.BigEndian.AppendUint32(s.sealed[:0], uint32(len(s.buf)+s.c.aead.Overhead()))
+	out = s.c.aead.Seal(out, tempNonce(&s.prefix, s.chunk), s.buf, tempAAD(last))
+	s.sealed = out
+	s.chunk++
+	s.buf = s.buf[:0]
+	_, err := s.w.Write(out)
+	return err
+}
+
+// openReader decrypts a file written by sealWriter
+type openReader struct {
+	c       *TempCipher
+	r       io.Reader
+	prefix  [8]byte
+	chunk   uint32
+	started bool
+	done    bool   // The last chunk has been read
+	plain   []byte // Decrypted bytes not yet returned
+	sealed  []byte
+}
+
+func (o *openReader) Read(p []byte) (int, error) {
+	for len(o.plain) == 0 {
+		if o.done {
+			return 0, io.EOF
+		}
+		if err := o.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, o.plain)
+	o.plain = o.plain[n:]
+	return n, nil
+}
+
+// next reads and opens the next chunk
+func (o *openReader) next() error {
+	if !o.started {
+		if _, err := io.ReadFull(o.r, o.prefix[:]); err != nil {
+			return errTempCorrupt
+		}
+		o.started = true
+	}
+	var size [4]byte
+	if _, err := io.ReadFull(o.r, size[:]); err != nil {
+		return errTempCorrupt
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > tempChunkBytes+uint32(o.c.aead.Overhead()) {
+		return errTempCorrupt
+	}
+	o.sealed = append(o.sealed[:0], make([]byte, n)...)
+	if _, err := io.ReadFull(o.r, o.sealed); err != nil {
+		return errTempCorrupt
+	}
+	nonce := tempNonce(&o.prefix, o.chunk)
+	plain, err := o.c.aead.Open(o.plain[:0], nonce, o.sealed, tempAAD(false))
+	if err != nil {
+		plain, err = o.c.aead.Open(o.plain[:0], nonce, o.sealed, tempAAD(true))
+		if err != nil {
+			return errTempCorrupt
+		}
+		o.done = true
+	}
+	o.plain = plain
+	o.chunk++
+	return nil
+}
+
+// nopWriteCloser leaves plaintext temp files unchanged
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
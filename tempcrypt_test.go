@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTempCipher(t *testing.T) {
+	c, err := NewTempCipher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 10, tempChunkBytes, 3*tempChunkBytes + 17} {
+		plain := bytes.Repeat([]byte("0xabc\n"), size/6+1)[:size]
+		var file bytes.Buffer
+		w := c.Writer(&file)
+		// Writes of odd sizes straddle the chunks
+		for rest := plain; len(rest) > 0; {
+			n := min(len(rest), 1000)
+			w.Write(rest[:n])
+			rest = rest[n:]
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if size > 0 && bytes.Contains(file.Bytes(), []byte("0xabc")) {
+			t.Errorf("%d bytes: plaintext found in the encrypted file", size)
+		}
+		got, err := io.ReadAll(c.Reader(bytes.NewReader(file.Bytes())))
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("%d bytes: round trip failed with %v", size, err)
+		}
+
+		// Truncated and altered files fail to read
+		if _, err := io.ReadAll(c.Reader(bytes.NewReader(file.Bytes()[:file.Len()-1]))); err != errTempCorrupt {
+			t.Errorf("%d bytes: expected a truncated file to fail, got %v", size, err)
+		}
+		altered := bytes.Clone(file.Bytes())
+		altered[len(altered)/2] ^= 1
+		if _, err := io.ReadAll(c.Reader(bytes.NewReader(altered))); err != errTempCorrupt {
+			t.Errorf("%d bytes: expected an altered file to fail, got %v", size, err)
+		}
+	}
+
+	// Another key can't read the file
+	var file bytes.Buffer
+	w := c.Writer(&file)
+	w.Write([]byte("secret\n"))
+	w.Close()
+	other, _ := NewTempCipher()
+	if _, err := io.ReadAll(other.Reader(&file)); err != errTempCorrupt {
+		t.Errorf("Expected another key to fail, got %v", err)
+	}
+
+	// A child process reads the files with the key passed in its environment
+	name, value, _ := strings.Cut(c.Env(), "=")
+	t.Setenv(name, value)
+	child, err := tempCipherFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Reset()
+	w = c.Writer(&file)
+	w.Write([]byte("secret\n"))
+	w.Close()
+	if got, err := io.ReadAll(child.Reader(&file)); err != nil || string(got) != "secret\n" {
+		t.Errorf("Expected the child to read the file, got %q, %v", got, err)
+	}
+}
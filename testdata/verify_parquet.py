@@ -0,0 +1,49 @@
+#!/usr/bin/env python3
+"""Checks addresses.parquet with pyarrow, a reader independent of AddrMint.
+
+The file is written by TestParquetGolden in parquet_test.go. Run this
+script after regenerating it:
+
+    go test -run TestParquetGolden -update-parquet-golden .
+    python3 testdata/verify_parquet.py
+"""
+
+import hashlib
+import os
+import sys
+
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+path = os.path.join(os.path.dirname(os.path.abspath(__file__)), "addresses.parquet")
+table = pq.read_table(path)
+
+expected_schema = pa.schema([
+    pa.field("index", pa.int64(), nullable=False),
+    pa.field("network", pa.string(), nullable=False),
+    pa.field("entity_name", pa.string(), nullable=False),
+    pa.field("hash", pa.string(), nullable=False),
+    pa.field("address", pa.string(), nullable=False),
+])
+if not table.schema.equals(expected_schema):
+    sys.exit(f"unexpected schema:\n{table.schema}")
+
+metadata = pq.ParquetFile(path).metadata
+if metadata.num_row_groups != 2:
+    sys.exit(f"expected 2 row groups, got {metadata.num_row_groups}")
+
+# The records of parquetTestRecords(5)
+expected = []
+for i in range(5):
+    address = "0x%040x" % (i * i)
+    expected.append({
+        "index": 1000 + i,
+        "network": "ethereum",
+        "entity_name": 'Yilmaz, "Mateo" %d\n' % i,
+        "hash": hashlib.sha256(address.encode()).hexdigest()[:6],
+        "address": address,
+    })
+rows = table.to_pylist()
+if rows != expected:
+    sys.exit(f"unexpected rows:\n{rows}")
+print(f"{path}: {len(rows)} rows in {metadata.num_row_groups} row groups match")
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mr-tron/base58"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+// errChecksumMismatch is returned for addresses with an incorrect checksum
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// validateAddress checks that addr is a well-formed mainnet address for the
+// given network, including its checksum where the format has one
+func validateAddress(network, addr string) error {
+	switch network {
+	case "ethereum":
+		return validateEthereumAddress(addr)
+	case "bitcoin":
+		return validateBitcoinAddress(addr)
+	case "solana":
+		return validateSolanaAddress(addr)
+	case "ton":
+		return validateTonAddress(addr)
+	}
+	return fmt.Errorf("unsupported network: %s", network)
+}
+
+// validateEthereumAddress accepts 0x-prefixed hex addresses; mixed-case
+// addresses must carry a valid EIP-55 checksum
+func validateEthereumAddress(addr string) error {
+	if !strings.HasPrefix(addr, "0x") || !common.IsHexAddress(addr) {
+		return fmt.Errorf("invalid Ethereum address %q: expected 0x followed by 40 hex digits", addr)
+	}
+	digits := addr[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return nil
+	}
+	if common.HexToAddress(addr).Hex() != addr {
+		return fmt.Errorf("invalid Ethereum address %q: %w", addr, errChecksumMismatch)
+	}
+	return nil
+}
+
+// validateBitcoinAddress accepts any mainnet Base58Check or bech32 address
+func validateBitcoinAddress(addr string) error {
+	decoded, err := btcutil.DecodeAddress(addr, &chaincfg.MainNetParams)
+	if err != nil {
+		return fmt.Errorf("invalid Bitcoin address %q: %w", addr, err)
+	}
+	if !decoded.IsForNet(&chaincfg.MainNetParams) {
+		return fmt.Errorf("invalid Bitcoin address %q: not a mainnet address", addr)
+	}
+	return nil
+}
+
+// validateSolanaAddress accepts base58 encoded 32-byte public keys
+func validateSolanaAddress(addr string) error {
+	decoded, err := base58.Decode(addr)
+	if err != nil {
+		return fmt.Errorf("invalid Solana address %q: %w", addr, err)
+	}
+	if len(decoded) != 32 {
+		return fmt.Errorf("invalid Solana address %q: expected 32 bytes, got %d", addr, len(decoded))
+	}
+	return nil
+}
+
+// validateTonAddress accepts user-friendly TON addresses with a valid CRC
+func validateTonAddress(addr string) error {
+	if _, err := address.ParseAddr(addr); err != nil {
+		return fmt.Errorf("invalid TON address %q: %w", addr, err)
+	}
+	return nil
+}
+
+// fixAddress returns the canonical form of addr when only its casing is
+// wrong: EIP-55 checksum casing for Ethereum and lowercase for bech32
+// Bitcoin addresses. It returns addr unchanged when there is nothing to fix.
+func fixAddress(network, addr string) string {
+	switch network {
+	case "ethereum":
+		if strings.HasPrefix(addr, "0x") && common.IsHexAddress(addr) {
+			return common.HexToAddress(addr).Hex()
+		}
+	case "bitcoin":
+		if lower := strings.ToLower(addr); strings.HasPrefix(lower, "bc1") && validateBitcoinAddress(lower) == nil {
+			return lower
+		}
+	}
+	return addr
+}
+
+// ValidateStats counts the outcome of validating an address list
+type ValidateStats struct {
+	total   int
+	valid   int
+	fixed   int
+	invalid int
+}
+
+// validateList checks every non-empty line of in. With fix set, rows whose
+// casing can be repaired are corrected; every row is written to out and each
+// change or remaining error is described in report.
+func validateList(network string, in io.Reader, out io.Writer, report io.Writer, fix bool) (ValidateStats, error) {
+	var stats ValidateStats
+	scanner := bufio.NewScanner(in)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		addr := strings.TrimSpace(scanner.Text())
+		if addr == "" {
+			continue
+		}
+		stats.total++
+
+		if fix {
+			if fixed := fixAddress(network, addr); fixed != addr {
+				fmt.Fprintf(report, "line %d: %s -> %s\n", lineNumber, addr, fixed)
+				addr = fixed
+				stats.fixed++
+			}
+		}
+		if err := validateAddress(network, addr); err != nil {
+			fmt.Fprintf(report, "line %d: %v\n", lineNumber, err)
+			stats.invalid++
+		} else {
+			stats.valid++
+		}
+		fmt.Fprintln(out, addr)
+	}
+	return stats, scanner.Err()
+}
+
+// runValidate implements the validate subcommand and returns the exit code
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	network := fs.String("network", "", "Blockchain network of the addresses (ethereum, bitcoin, solana, ton)")
+	fix := fs.Bool("fix", false, "Repair EIP-55 checksum casing and bech32 case, writing the corrected list to --output")
+	output := fs.String("output", "", "File for the corrected address list (required with --fix)")
+	reportFile := fs.String("report", "", "File for the report of changes and errors (default: stderr)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint validate --network NETWORK [--fix --output FILE] [--report FILE] [FILE]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+
+	if *network == "" {
+		fatalf("Network is required. Use --network ethereum|bitcoin|solana|ton")
+	}
+	if *network != "ethereum" && *network != "bitcoin" && *network != "solana" && *network != "ton" {
+		fatalf("Network must be ethereum, bitcoin, solana, or ton")
+	}
+	if *fix && *output == "" {
+		fatalf("--fix requires --output")
+	}
+	if fs.NArg() > 1 {
+		fatalf("validate takes at most one input file")
+	}
+
+	// Read from the named file, or stdin when none is given
+	in := os.Stdin
+	if fs.NArg() == 1 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fatalf("Failed to open input file: %v", err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	// Without --output the list is only checked
+	out := bufio.NewWriter(io.Discard)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = bufio.NewWriter(file)
+	}
+
+	report := os.Stderr
+	if *reportFile != "" {
+		file, err := os.Create(*reportFile)
+		if err != nil {
+			fatalf("Failed to create report file: %v", err)
+		}
+		defer file.Close()
+		report = file
+	}
+
+	stats, err := validateList(*network, in, out, report, *fix)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		fatalf("Failed to validate addresses: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Checked %s addresses: %s valid, %s fixed, %s invalid\n",
+		formatCount(stats.total), formatCount(stats.valid), formatCount(stats.fixed), formatCount(stats.invalid))
+	if stats.invalid > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// roundTripJobs lists every network and backend combination to cross-check,
+// and every Bitcoin address type
+var roundTripJobs = append([]Job{
+	{network: "ethereum", backend: backendSDK, hashBackend: hashBackendGeth},
+	{network: "ethereum", backend: backendSDK, hashBackend: hashBackendReused},
+	{network: "bitcoin", backend: backendSDK, hashBackend: hashBackendGeth},
+	{network: "solana", backend: backendSDK, hashBackend: hashBackendGeth},
+	{network: "solana", backend: backendNative, hashBackend: hashBackendGeth},
+	{network: "ton", backend: backendSDK, hashBackend: hashBackendGeth},
+}, bitcoinTypeJobs()...)
+
+// bitcoinTypeJobs returns a job for each of addrmint.BitcoinTypes
+func bitcoinTypeJobs() []Job {
+	var jobs []Job
+	for _, addressType := range addrmint.BitcoinTypes {
+		types, err := addrmint.SingleBitcoinType(addressType)
+		if err != nil {
+			panic(err)
+		}
+		jobs = append(jobs, Job{network: "bitcoin", backend: backendSDK, hashBackend: hashBackendGeth, btcTypes: types})
+	}
+	return jobs
+}
+
+// TestDeriveValidateRoundTrip checks for random keys that deriving again
+// reproduces the address and that validation accepts it
+func TestDeriveValidateRoundTrip(t *testing.T) {
+	config := &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}
+
+	for _, template := range roundTripJobs {
+		template := template
+		var generators, fresh workerGenerators
+
+		property := func(seed [32]byte) bool {
+			job := template
+			job.seed = seed
+
+			addr, err := generateAddress(&job, &generators)
+			if err != nil {
+				// Keys outside the curve order are rejected, not mis-encoded
+				return addr == ""
+			}
+			again, err := generateAddress(&job, &fresh)
+			if err != nil || again != addr {
+				t.Logf("%s: derive mismatch for %x: %s != %s (%v)", job.network, seed, again, addr, err)
+				return false
+			}
+			if err := validateAddress(job.network, addr); err != nil {
+				t.Logf("%s: %v", job.network, err)
+				return false
+			}
+			return true
+		}
+
+		if err := quick.Check(property, config); err != nil {
+			name := template.network
+			if template.btcTypes != nil {
+				name += "/" + strings.Join(template.btcTypes.Types(), ",")
+			}
+			t.Errorf("%s/%s/%s: %v", name, template.backend, template.hashBackend, err)
+		}
+	}
+}
+
+// TestValidateRejectsCorruptedAddresses checks that a single changed
+// character is caught by each network's checksum or length rules
+func TestValidateRejectsCorruptedAddresses(t *testing.T) {
+	config := &quick.Config{MaxCount: 100, Rand: rand.New(rand.NewSource(2))}
+	var generators workerGenerators
+
+	for _, network := range []string{"ethereum", "bitcoin", "ton"} {
+		network := network
+		property := func(seed [32]byte, pos uint8) bool {
+			job := Job{network: network, seed: seed}
+			addr, err := generateAddress(&job, &generators)
+			if err != nil {
+				return true
+			}
+			corrupted := corruptAddress(network, addr, int(pos))
+			if corrupted == addr {
+				return true
+			}
+			return validateAddress(network, corrupted) != nil
+		}
+
+		if err := quick.Check(property, config); err != nil {
+			t.Errorf("%s: %v", network, err)
+		}
+	}
+}
+
+// corruptAddress changes one character of addr; for Ethereum it flips the
+// case of a letter so that only the EIP-55 checksum can detect it
+func corruptAddress(network, addr string, pos int) string {
+	b := []byte(addr)
+	if network == "ethereum" {
+		for i := 0; i < len(b)-2; i++ {
+			j := 2 + (pos+i)%(len(b)-2)
+			if c := b[j]; c >= 'a' && c <= 'f' {
+				b[j] = c - 'a' + 'A'
+				return string(b)
+			} else if c >= 'A' && c <= 'F' {
+				b[j] = c - 'A' + 'a'
+				return string(b)
+			}
+		}
+		return addr
+	}
+
+	// Skip the version prefix and replace the character with a different one
+	// from the same alphabet so the address still decodes
+	j := 2 + pos%(len(b)-2)
+	alphabet := "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	k := strings.IndexByte(alphabet, b[j])
+	if k < 0 {
+		return addr
+	}
+	b[j] = alphabet[(k+1)%len(alphabet)]
+	return string(b)
+}
+
+func TestValidateAddress(t *testing.T) {
+	tests := []struct {
+		network string
+		addr    string
+		valid   bool
+	}{
+		{"ethereum", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f", true},
+		{"ethereum", "0x0d747f8adfde4bef87cf21fea682083c7149268f", true},
+		{"ethereum", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268F", false},
+		{"ethereum", "0d747F8AdFdE4beF87CF21FEa682083C7149268f", false},
+		{"ethereum", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268", false},
+		{"bitcoin", "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT", true},
+		{"bitcoin", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", true},
+		{"bitcoin", "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorU", false},
+		{"bitcoin", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", false},
+		{"solana", "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj", true},
+		{"solana", "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3", false},
+		{"solana", "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ers0j", false},
+		{"ton", "UQCuIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8d", true},
+		{"ton", "UQCuIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8e", false},
+		{"dogecoin", "DBXu2kgc3xtvCUWFcxFE3r9hEYgmuaaCyD", false},
+	}
+
+	for _, tt := range tests {
+		err := validateAddress(tt.network, tt.addr)
+		if tt.valid && err != nil {
+			t.Errorf("validateAddress(%s, %s): unexpected error %v", tt.network, tt.addr, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("validateAddress(%s, %s): expected error", tt.network, tt.addr)
+		}
+	}
+}
+
+func TestFixAddress(t *testing.T) {
+	tests := []struct {
+		network  string
+		addr     string
+		expected string
+	}{
+		{"ethereum", "0x0d747f8adfde4bef87cf21fea682083c7149268f", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"},
+		{"ethereum", "0x0D747F8ADFDE4BEF87CF21FEA682083C7149268F", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"},
+		{"ethereum", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f", "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f"},
+		{"ethereum", "0xzz", "0xzz"},
+		{"bitcoin", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{"bitcoin", "bc1qW508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		// Base58 addresses are case-sensitive and never rewritten
+		{"bitcoin", "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT", "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT"},
+		{"solana", "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj", "BG3Agp4dLAVw2hakYdzHpCPEzZp4dwCmokLrAR3Ersqj"},
+	}
+
+	for _, tt := range tests {
+		if got := fixAddress(tt.network, tt.addr); got != tt.expected {
+			t.Errorf("fixAddress(%s, %s) = %s, expected %s", tt.network, tt.addr, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateListFix(t *testing.T) {
+	input := strings.Join([]string{
+		"0x0d747f8adfde4bef87cf21fea682083c7149268f",
+		"",
+		"0x0d747F8AdFdE4beF87CF21FEa682083C7149268f",
+		"not an address",
+	}, "\n")
+
+	var out, report strings.Builder
+	stats, err := validateList("ethereum", strings.NewReader(input), &out, &report, true)
+	if err != nil {
+		t.Fatalf("validateList failed: %v", err)
+	}
+
+	expected := ValidateStats{total: 3, valid: 2, fixed: 1, invalid: 1}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+	expectedOut := "0x0d747F8AdFdE4beF87CF21FEa682083C7149268f\n0x0d747F8AdFdE4beF87CF21FEa682083C7149268f\nnot an address\n"
+	if out.String() != expectedOut {
+		t.Errorf("Expected output %q, got %q", expectedOut, out.String())
+	}
+	if !strings.Contains(report.String(), "line 1: 0x0d747f8adfde4bef87cf21fea682083c7149268f -> 0x0d747F8AdFdE4beF87CF21FEa682083C7149268f") {
+		t.Errorf("Report missing fix for line 1: %q", report.String())
+	}
+	if !strings.Contains(report.String(), "line 4: invalid Ethereum address") {
+		t.Errorf("Report missing error for line 4: %q", report.String())
+	}
+}
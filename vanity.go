@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// Address alphabets used to check that a vanity pattern can match at all
+const (
+	hexAlphabet       = "0123456789abcdefABCDEF"
+	base58Alphabet    = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base64URLAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+// VanityPattern describes the address a vanity search looks for
+type VanityPattern struct {
+	network       string
+	prefix        string // Characters right after the fixed address prefix (0x, 1, UQ)
+	suffix        string
+	caseSensitive bool
+}
+
+// fixedPrefix returns the part every address of the network starts with
+func fixedPrefix(network string) string {
+	switch network {
+	case "ethereum":
+		return "0x"
+	case "bitcoin":
+		return "1"
+	case "ton":
+		return "UQ"
+	}
+	return ""
+}
+
+// difficulty returns the expected number of attempts to find one address
+// matching the pattern. Characters are treated as uniformly distributed,
+// which is exact for hex and a close estimate for base58 and base64.
+func (p VanityPattern) difficulty() (float64, error) {
+	var alphabet string
+	var prefixable bool
+	switch p.network {
+	case "ethereum":
+		alphabet, prefixable = hexAlphabet, true
+	case "bitcoin", "solana":
+		alphabet, prefixable = base58Alphabet, true
+	case "ton":
+		// The characters after UQ encode flags and the workchain, so only
+		// suffixes are uniformly distributed
+		alphabet, prefixable = base64URLAlphabet, false
+	default:
+		return 0, fmt.Errorf("unsupported network: %s", p.network)
+	}
+	if p.prefix == "" && p.suffix == "" {
+		return 0, errors.New("a prefix or suffix is required")
+	}
+	if p.prefix != "" && !prefixable {
+		return 0, fmt.Errorf("%s addresses only support suffix patterns", p.network)
+	}
+
+	attempts := 1.0
+	for _, c := range p.prefix + p.suffix {
+		if !strings.ContainsRune(alphabet, c) {
+			return 0, fmt.Errorf("%q can never appear in a %s address", c, p.network)
+		}
+		switch p.network {
+		case "ethereum":
+			attempts *= 16
+			// EIP-55 casing halves the odds of each letter
+			if p.caseSensitive && c > '9' {
+				attempts *= 2
+			}
+		case "ton":
+			attempts *= 64
+		default:
+			attempts *= 58
+		}
+	}
+	return attempts, nil
+}
+
+// VanityEstimate is the expected cost of a vanity search at a given rate
+type VanityEstimate struct {
+	attempts float64       // Expected attempts for one match
+	rate     float64       // Addresses per second
+	expected time.Duration // Expected time for one match
+	p50      time.Duration // Time by which a match is found with 50% probability
+	p90      time.Duration // Time by which a match is found with 90% probability
+}
+
+// estimateVanity computes the expected search time for a difficulty at the
+// given rate. The number of attempts until a match is geometric.
+func estimateVanity(attempts, rate float64) VanityEstimate {
+	quantile := func(probability float64) time.Duration {
+		n := math.Log(1-probability) / math.Log1p(-1/attempts)
+		return secondsToDuration(n / rate)
+	}
+	return VanityEstimate{
+		attempts: attempts,
+		rate:     rate,
+		expected: secondsToDuration(attempts / rate),
+		p50:      quantile(0.5),
+		p90:      quantile(0.9),
+	}
+}
+
+// secondsToDuration converts seconds to a duration, saturating instead of
+// overflowing for searches that would take centuries
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds >= float64(math.MaxInt64)/float64(time.Second) {
+		return math.MaxInt64
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// successProbability returns the chance of at least one match within budget
+func (e VanityEstimate) successProbability(budget time.Duration) float64 {
+	tries := e.rate * budget.Seconds()
+	return -math.Expm1(tries * math.Log1p(-1/e.attempts))
+}
+
+// measureRate generates addresses on all workers for the given duration and
+// returns the combined addresses per second
+func measureRate(network, backend, hashBackend string, workers int, duration time.Duration) float64 {
+	var generated atomic.Int64
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(duration)
+	start := time.Now()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			var generators workerGenerators
+			var buf []byte
+			job := Job{network: network, backend: backend, hashBackend: hashBackend}
+			for i := w; time.Now().Before(deadline); i += workers {
+				buf = addrmint.DeriveSeed(buf, "vanity-estimate", i, &job.seed)
+				if _, err := generateAddress(&job, &generators); err == nil {
+					generated.Add(1)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	return float64(generated.Load()) / time.Since(start).Seconds()
+}
+
+// searchStride tries indices start, start+stride, ... until stop is closed
+// or limit attempts have been reserved, sending every hit try reports to
+// hits. Attempts are reserved chunk at a time, bounding both contention on
+// the shared counter and the latency of stopping. Attempts actually made
+// are added to done.
+func searchStride[T any](start, stride int, chunk int64, reserved, done *atomic.Int64, limit int64, stop <-chan struct{}, hits chan<- T, try func(i int) (T, bool)) {
+	i := start
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		reserve := chunk
+		if limit > 0 {
+			previous := reserved.Add(reserve) - reserve
+			if previous >= limit {
+				return
+			}
+			reserve = min(reserve, limit-previous)
+		}
+
+		for n := int64(0); n < reserve; n, i = n+1, i+stride {
+			hit, ok := try(i)
+			if !ok {
+				continue
+			}
+			select {
+			case hits <- hit:
+			case <-stop:
+				done.Add(n + 1)
+				return
+			}
+		}
+		done.Add(reserve)
+	}
+}
+
+// startStrideSearch searches on workers goroutines that interleave indices,
+// so every attempt uses a distinct seed. Each worker tries indices with the
+// function newTry makes for it, which can hold the worker's scratch state.
+// The returned channel is closed once every worker has stopped.
+func startStrideSearch[T any](workers int, chunk, limit int64, done *atomic.Int64, stop <-chan struct{}, newTry func() func(i int) (T, bool)) <-chan T {
+	var reserved atomic.Int64
+	hits := make(chan T)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			searchStride(w, workers, chunk, &reserved, done, limit, stop, hits, newTry())
+		}(w)
+	}
+	go func() {
+		wg.Wait()
+		close(hits)
+	}()
+	return hits
+}
+
+// formatDuration renders long durations in days and years
+func formatDuration(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < day:
+		return d.Round(time.Second).String()
+	case d < 365*day:
+		return fmt.Sprintf("%.1f days", d.Hours()/24)
+	case d < math.MaxInt64:
+		return fmt.Sprintf("%.1f years", d.Hours()/24/365)
+	}
+	return "more than 290 years"
+}
+
+// runVanity implements the vanity subcommand and returns the exit code.
+// Without estimate or zeros it searches for matching addresses.
+func runVanity(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "estimate":
+			return runVanityEstimate(args[1:])
+		case "zeros":
+			return runVanityZeros(args[1:])
+		}
+	}
+	return runVanitySearch(args)
+}
+
+// runVanityEstimate reports the expected cost of a vanity pattern
+func runVanityEstimate(args []string) int {
+	fs := flag.NewFlagSet("vanity estimate", flag.ExitOnError)
+	network := fs.String("network", "", "Blockchain network (ethereum, bitcoin, solana, ton)")
+	prefix := fs.String("prefix", "", "Characters the address starts with, after 0x for Ethereum and 1 for Bitcoin")
+	suffix := fs.String("suffix", "", "Characters the address ends with")
+	caseSensitive := fs.Bool("case-sensitive", false, "Match Ethereum letters with their EIP-55 checksum case")
+	budget := fs.Duration("budget", time.Hour, "Time budget for the success probability")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of workers the search would use")
+	cryptoBackend := fs.String("crypto-backend", backendSDK, "Crypto backend for key derivation (sdk, native)")
+	hashBackend := fs.String("hash-backend", hashBackendGeth, "Hash backend for Ethereum address hashing (geth, reused)")
+	measure := fs.Duration("measure", 2*time.Second, "How long to measure the generation rate")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	numberFormatFlag := fs.String("number-format", numberFormatGrouped, "Number format for the report (grouped, si, raw)")
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+	if err := setupNumberFormat(*numberFormatFlag); err != nil {
+		fatalf("Invalid --number-format: %v", err)
+	}
+
+	// Patterns may be given with the fixed address prefix
+	pattern := VanityPattern{
+		network:       *network,
+		prefix:        strings.TrimPrefix(*prefix, fixedPrefix(*network)),
+		suffix:        *suffix,
+		caseSensitive: *caseSensitive,
+	}
+	attempts, err := pattern.difficulty()
+	if err != nil {
+		fatalf("Invalid pattern: %v", err)
+	}
+	if *workers < 1 || *measure <= 0 {
+		fatalf("Workers and measure duration must be positive")
+	}
+
+	fmt.Fprintf(os.Stderr, "Measuring %s generation rate with %d workers for %s...\n", *network, *workers, *measure)
+	rate := measureRate(*network, *cryptoBackend, *hashBackend, *workers, *measure)
+	estimate := estimateVanity(attempts, rate)
+
+	fmt.Printf("Pattern:              %s%s...%s\n", fixedPrefix(*network), pattern.prefix, pattern.suffix)
+	if attempts < 1e15 {
+		fmt.Printf("Expected attempts:    %s\n", formatCount(int(attempts)))
+	} else {
+		fmt.Printf("Expected attempts:    %.3g\n", attempts)
+	}
+	fmt.Printf("Measured rate:        %s addresses/sec\n", formatRate(rate))
+	fmt.Printf("Expected time:        %s\n", formatDuration(estimate.expected))
+	fmt.Printf("50%% chance within:    %s\n", formatDuration(estimate.p50))
+	fmt.Printf("90%% chance within:    %s\n", formatDuration(estimate.p90))
+	fmt.Printf("Chance within %s: %.2f%%\n", *budget, 100*estimate.successProbability(*budget))
+	return 0
+}
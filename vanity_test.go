@@ -0,0 +1,156 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+func TestVanityPatternDifficulty(t *testing.T) {
+	tests := []struct {
+		pattern  VanityPattern
+		attempts float64
+	}{
+		{VanityPattern{network: "ethereum", prefix: "dead"}, 1 << 16},
+		{VanityPattern{network: "ethereum", prefix: "00", suffix: "ff"}, 1 << 16},
+		{VanityPattern{network: "ethereum", prefix: "dEad", caseSensitive: true}, 1 << 20},
+		{VanityPattern{network: "bitcoin", prefix: "Love"}, 58 * 58 * 58 * 58},
+		{VanityPattern{network: "solana", suffix: "Sun"}, 58 * 58 * 58},
+		{VanityPattern{network: "ton", suffix: "_ton"}, 64 * 64 * 64 * 64},
+	}
+	for _, tt := range tests {
+		attempts, err := tt.pattern.difficulty()
+		if err != nil {
+			t.Errorf("%+v: unexpected error %v", tt.pattern, err)
+		} else if attempts != tt.attempts {
+			t.Errorf("%+v: expected %.0f attempts, got %.0f", tt.pattern, tt.attempts, attempts)
+		}
+	}
+}
+
+func TestVanityPatternInfeasible(t *testing.T) {
+	patterns := []VanityPattern{
+		{network: "ethereum", prefix: "beefy"},
+		{network: "bitcoin", prefix: "l0ve"},
+		{network: "solana", suffix: "O"},
+		{network: "ton", prefix: "abc"},
+		{network: "ethereum"},
+		{network: "dogecoin", prefix: "D"},
+	}
+	for _, p := range patterns {
+		if _, err := p.difficulty(); err == nil {
+			t.Errorf("%+v: expected an error", p)
+		}
+	}
+}
+
+func TestEstimateVanity(t *testing.T) {
+	e := estimateVanity(1<<16, 1000)
+	if e.expected != time.Duration(65.536*float64(time.Second)) {
+		t.Errorf("Expected 65.536s, got %s", e.expected)
+	}
+
+	// The median of a geometric distribution is about ln(2) times the mean
+	if ratio := e.p50.Seconds() / e.expected.Seconds(); math.Abs(ratio-math.Ln2) > 0.001 {
+		t.Errorf("Expected p50 to be ln(2) of the mean, got ratio %.4f", ratio)
+	}
+	if p := e.successProbability(e.p90); math.Abs(p-0.9) > 0.001 {
+		t.Errorf("Expected 90%% success within p90, got %.4f", p)
+	}
+
+	// Searches that would take forever saturate instead of overflowing
+	if e := estimateVanity(1e40, 1); e.expected != math.MaxInt64 || formatDuration(e.expected) != "more than 290 years" {
+		t.Errorf("Expected a saturated duration, got %s", e.expected)
+	}
+}
+
+func TestVanityMatcher(t *testing.T) {
+	const eth = "0xdEAD47F8AdFdE4beF87CF21FEa682083C714beeF"
+	tests := []struct {
+		matcher VanityMatcher
+		addr    string
+		match   bool
+	}{
+		{VanityMatcher{pattern: VanityPattern{network: "ethereum", prefix: "dead", suffix: "BEEF"}}, eth, true},
+		{VanityMatcher{pattern: VanityPattern{network: "ethereum", prefix: "dead", caseSensitive: true}}, eth, false},
+		{VanityMatcher{pattern: VanityPattern{network: "ethereum", prefix: "dEAD", suffix: "beeF", caseSensitive: true}}, eth, true},
+		{VanityMatcher{pattern: VanityPattern{network: "ethereum", prefix: "dead"}, regex: regexp.MustCompile(`^0x[a-fA-F]{4}47`)}, eth, true},
+		{VanityMatcher{pattern: VanityPattern{network: "ethereum"}, regex: regexp.MustCompile(`(?i)c0ffee`)}, eth, false},
+		{VanityMatcher{pattern: VanityPattern{network: "bitcoin", prefix: "KEX"}}, "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT", true},
+		{VanityMatcher{pattern: VanityPattern{network: "bitcoin", prefix: "kex"}}, "1KEXpRQzhPCfB6xfYo5KZKZmsrGp7cAorT", false},
+		{VanityMatcher{pattern: VanityPattern{network: "ton", suffix: "8j8d"}}, "UQCuIc_0N6oN7YyCH_yGZFFlEtUq8hvdkVQk6bACNPEN8j8d", true},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher.match(tt.addr); got != tt.match {
+			t.Errorf("%+v matching %s: expected %t, got %t", tt.matcher.pattern, tt.addr, tt.match, got)
+		}
+	}
+}
+
+func TestVanitySearchHits(t *testing.T) {
+	search := &VanitySearch{
+		baseSeed: "2a",
+		network:  "solana",
+		matcher:  &VanityMatcher{pattern: VanityPattern{network: "solana", suffix: "A"}},
+	}
+	var done atomic.Int64
+	hits := startStrideSearch(1, vanityChunk, 1000, &done, make(chan struct{}), search.attempt)
+
+	var found []VanityHit
+	for hit := range hits {
+		found = append(found, hit)
+	}
+	if done.Load() != 1000 {
+		t.Errorf("Expected 1000 attempts, got %d", done.Load())
+	}
+	if len(found) == 0 {
+		t.Fatal("Expected at least one hit in 1000 attempts")
+	}
+
+	// Every hit's key must be the seed of its index and derive its address
+	for _, hit := range found {
+		var seed [32]byte
+		addrmint.DeriveSeed(nil, "2a", hit.index, &seed)
+		addr, err := addrmint.SolanaGenerator{}.Generate(hit.key[:])
+		if err != nil || seed != hit.key || string(addr) != hit.address || !strings.HasSuffix(hit.address, "A") {
+			t.Errorf("Hit %d: key derives %s, reported %s (%v)", hit.index, addr, hit.address, err)
+		}
+	}
+}
+
+func TestStrideSearch(t *testing.T) {
+	// Every index is a hit, so the hits show which indices were tried
+	everyIndex := func() func(i int) (int, bool) {
+		return func(i int) (int, bool) { return i, true }
+	}
+	var done atomic.Int64
+	seen := make(map[int]bool)
+	for i := range startStrideSearch(3, 4, 100, &done, make(chan struct{}), everyIndex) {
+		if seen[i] {
+			t.Errorf("Index %d was tried twice", i)
+		}
+		seen[i] = true
+	}
+	if len(seen) != 100 || done.Load() != 100 {
+		t.Errorf("Expected 100 attempts, got %d hits and %d attempts", len(seen), done.Load())
+	}
+
+	// Without a limit the search runs until stopped
+	done.Store(0)
+	stop := make(chan struct{})
+	hits := startStrideSearch(3, 4, 0, &done, stop, everyIndex)
+	for range 10 {
+		<-hits
+	}
+	close(stop)
+	for range hits {
+	}
+	if done.Load() < 10 {
+		t.Errorf("Expected at least 10 attempts, got %d", done.Load())
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+)
+
+// VanityMatcher tests addresses against a vanity pattern and an optional
+// regular expression, all of which must match
+type VanityMatcher struct {
+	pattern VanityPattern
+	regex   *regexp.Regexp // Matched against the whole address
+}
+
+// hasFold reports whether s has affix at the given end, ignoring case
+func hasFold(s, affix string, suffix bool) bool {
+	if len(s) < len(affix) {
+		return false
+	}
+	if suffix {
+		return strings.EqualFold(s[len(s)-len(affix):], affix)
+	}
+	return strings.EqualFold(s[:len(affix)], affix)
+}
+
+// match reports whether addr matches. Ethereum letters match in any case
+// unless the pattern is case-sensitive; other encodings are case-sensitive.
+func (m *VanityMatcher) match(addr string) bool {
+	body := strings.TrimPrefix(addr, fixedPrefix(m.pattern.network))
+	if m.pattern.network == "ethereum" && !m.pattern.caseSensitive {
+		if !hasFold(body, m.pattern.prefix, false) || !hasFold(body, m.pattern.suffix, true) {
+			return false
+		}
+	} else if !strings.HasPrefix(body, m.pattern.prefix) || !strings.HasSuffix(body, m.pattern.suffix) {
+		return false
+	}
+	return m.regex == nil || m.regex.MatchString(addr)
+}
+
+// VanityHit is an address found by a vanity search
+type VanityHit struct {
+	index   int
+	address string
+	key     [32]byte // Private key, the Ed25519 seed for Solana and TON
+}
+
+// VanitySearch brute-forces addresses of a network until they match
+type VanitySearch struct {
+	baseSeed    string
+	network     string
+	backend     string
+	hashBackend string
+	matcher     *VanityMatcher
+}
+
+// vanityChunk is the number of attempts a worker reserves at a time
+const vanityChunk = 256
+
+// attempt returns a function that tries one index for a worker, with its
+// own generator
+func (s *VanitySearch) attempt() func(i int) (VanityHit, bool) {
+	var generators workerGenerators
+	seedBuf := make([]byte, 0, len(s.baseSeed)+20)
+	job := Job{network: s.network, backend: s.backend, hashBackend: s.hashBackend}
+	return func(i int) (VanityHit, bool) {
+		seedBuf = addrmint.DeriveSeed(seedBuf, s.baseSeed, i, &job.seed)
+		addr, err := generateAddress(&job, &generators)
+		if err != nil || !s.matcher.match(addr) {
+			return VanityHit{}, false
+		}
+		return VanityHit{index: i, address: addr, key: job.seed}, true
+	}
+}
+
+// writeVanityHit writes an address row, followed by its private key in hex
+// when includeKey is set
+func writeVanityHit(w io.Writer, hit VanityHit, includeKey bool) error {
+	if includeKey {
+		_, err := fmt.Fprintf(w, "%s,0x%s\n", hit.address, hex.EncodeToString(hit.key[:]))
+		return err
+	}
+	_, err := fmt.Fprintln(w, hit.address)
+	return err
+}
+
+// runVanitySearch searches for addresses matching a prefix, suffix and
+// regular expression until --count are found
+func runVanitySearch(args []string) int {
+	fs := flag.NewFlagSet("vanity", flag.ExitOnError)
+	network := fs.String("network", "", "Blockchain network (ethereum, bitcoin, solana, ton)")
+	prefix := fs.String("prefix", "", "Characters the address starts with, after 0x for Ethereum and 1 for Bitcoin")
+	suffix := fs.String("suffix", "", "Characters the address ends with")
+	regex := fs.String("regex", "", "Regular expression the whole address must also match")
+	caseSensitive := fs.Bool("case-sensitive", false, "Match Ethereum letters with their EIP-55 checksum case")
+	count := fs.Int("count", 1, "Number of addresses to find")
+	includeKey := fs.Bool("include-key", false, "Write each address's private key in hex after it")
+	seedInt := fs.Int64("seed", 0, "Random seed as integer (0 for random seed)")
+	maxAttempts := fs.Int64("max-attempts", 0, "Stop after this many attempts (0 for no limit)")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of worker goroutines")
+	cryptoBackend := fs.String("crypto-backend", backendSDK, "Crypto backend for key derivation (sdk, native)")
+	hashBackend := fs.String("hash-backend", hashBackendGeth, "Hash backend for Ethereum address hashing (geth, reused)")
+	output := fs.String("output", "", "Output file path (default: stdout)")
+	auditLog := fs.String("audit-log", "", "Append a hash-chained record of the written keys to this file (with --include-key)")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	numberFormatFlag := fs.String("number-format", numberFormatGrouped, "Number format for the summary (grouped, si, raw)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint vanity --network NETWORK [--prefix CHARS] [--suffix CHARS] [--regex EXPR] [--count N] [--include-key] [--max-attempts N] [--output FILE]\n")
+		fmt.Fprintf(fs.Output(), "       addrmint vanity (estimate | zeros) [flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+	if err := setupNumberFormat(*numberFormatFlag); err != nil {
+		fatalf("Invalid --number-format: %v", err)
+	}
+
+	// Patterns may be given with the fixed address prefix
+	matcher := &VanityMatcher{pattern: VanityPattern{
+		network:       *network,
+		prefix:        strings.TrimPrefix(*prefix, fixedPrefix(*network)),
+		suffix:        *suffix,
+		caseSensitive: *caseSensitive,
+	}}
+	if *network != "ethereum" && *network != "bitcoin" && *network != "solana" && *network != "ton" {
+		fatalf("Network must be ethereum, bitcoin, solana, or ton")
+	}
+	// A regular expression alone needs no prefix or suffix, but has no known difficulty
+	attempts := 0.0
+	if *regex == "" || matcher.pattern.prefix != "" || matcher.pattern.suffix != "" {
+		var err error
+		if attempts, err = matcher.pattern.difficulty(); err != nil {
+			fatalf("Invalid pattern: %v", err)
+		}
+	}
+	if *regex != "" {
+		var err error
+		if matcher.regex, err = regexp.Compile(*regex); err != nil {
+			fatalf("Invalid --regex: %v", err)
+		}
+	}
+	if *count < 1 || *workers < 1 {
+		fatalf("Count and workers must be positive")
+	}
+	if *maxAttempts < 0 {
+		fatalf("Max attempts cannot be negative")
+	}
+	if *includeKey {
+		if err := checkPrivateKeyOutput(); err != nil {
+			fatalf("--include-key writes private keys: %v", err)
+		}
+	} else if *auditLog != "" {
+		fatalf("--audit-log requires --include-key")
+	}
+
+	search := &VanitySearch{network: *network, backend: *cryptoBackend, hashBackend: *hashBackend, matcher: matcher}
+	if *seedInt == 0 {
+		var seed [32]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			fatalf("Failed to generate random seed: %v", err)
+		}
+		search.baseSeed = hex.EncodeToString(seed[:])
+	} else {
+		search.baseSeed = strconv.FormatInt(*seedInt, 16)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	difficulty := "unknown with --regex"
+	if attempts > 0 {
+		difficulty = "1 in " + formatCount(int(min(attempts, 1e18)))
+		if *regex != "" {
+			difficulty = "at least " + difficulty
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Searching %s addresses on %d workers (difficulty %s)\n", *network, *workers, difficulty)
+
+	var tried atomic.Int64
+	stop := make(chan struct{})
+	start := time.Now()
+	hits := startStrideSearch(*workers, vanityChunk, *maxAttempts, &tried, stop, search.attempt)
+
+	// Report the rate while the search runs, which may take hours
+	statusDone := make(chan struct{})
+	var status sync.WaitGroup
+	if isTerminal(os.Stderr) {
+		status.Add(1)
+		go func() {
+			defer status.Done()
+			ticker := time.NewTicker(2 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					n := tried.Load()
+					fmt.Fprintf(os.Stderr, "\r%s attempts, %s attempts/sec", formatCount(int(n)), formatRate(float64(n)/time.Since(start).Seconds()))
+				case <-statusDone:
+					return
+				}
+			}
+		}()
+	}
+
+	// Matches are written as they are found, so an interrupted search keeps them
+	digest := sha256.New()
+	counted := NewCountingWriter(io.MultiWriter(out, digest))
+	writer := bufio.NewWriter(counted)
+	found := 0
+	for hit := range hits {
+		err := writeVanityHit(writer, hit, *includeKey)
+		if err == nil {
+			err = writer.Flush()
+		}
+		if err != nil {
+			fatalf("Failed to write output: %v", err)
+		}
+		if found++; found == *count {
+			close(stop)
+			break
+		}
+	}
+	// Wait for the workers, dropping hits they found while stopping
+	for range hits {
+	}
+	close(statusDone)
+	status.Wait()
+	elapsed := time.Since(start)
+	if isTerminal(os.Stderr) {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if *auditLog != "" {
+		destination := AuditDestination{Path: cmp.Or(*output, "stdout"), SHA256: hex.EncodeToString(digest.Sum(nil)), Bytes: counted.Bytes()}
+		recordAudit(*auditLog, newAuditEntry("vanity", "private keys", destination))
+	}
+
+	n := int(tried.Load())
+	fmt.Fprintf(os.Stderr, "Found %s of %s in %s attempts (%s attempts/sec)\n",
+		formatCount(found), formatCount(*count), formatCount(n), formatRate(float64(n)/elapsed.Seconds()))
+	if found < *count {
+		printWarning("Reached --max-attempts before finding every address")
+		return 1
+	}
+	return 0
+}
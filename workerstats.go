@@ -0,0 +1,25 @@
+package main
+
+import "slices"
+
+// stragglerShare is the share of the median worker's output below which a
+// worker is reported as a straggler
+const stragglerShare = 0.5
+
+// stragglers returns the workers, numbered from 1, that generated less
+// than stragglerShare of the median worker's count. counts[0] is unused.
+func stragglers(counts []int) []int {
+	if len(counts) < 3 {
+		return nil
+	}
+	sorted := slices.Clone(counts[1:])
+	slices.Sort(sorted)
+	median := float64(sorted[len(sorted)/2])
+	var slow []int
+	for w := 1; w < len(counts); w++ {
+		if float64(counts[w]) < stragglerShare*median {
+			slow = append(slow, w)
+		}
+	}
+	return slow
+}
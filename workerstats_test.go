@@ -0,0 +1,24 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStragglers(t *testing.T) {
+	tests := []struct {
+		counts   []int
+		expected []int
+	}{
+		{[]int{0, 100, 98, 103, 40}, []int{4}},
+		{[]int{0, 100, 100, 100, 100}, nil},
+		{[]int{0, 100, 100, 0}, []int{3}},
+		{[]int{0, 100, 0, 0}, nil},
+		{[]int{0, 100}, nil},
+	}
+	for _, tt := range tests {
+		if got := stragglers(tt.counts); !slices.Equal(got, tt.expected) {
+			t.Errorf("stragglers(%v) = %v, expected %v", tt.counts, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cipherowl-ai/AddrMint/pkg/addrmint"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ZeroHit is an address found with enough leading zero bytes
+type ZeroHit struct {
+	index   int
+	zeros   int
+	address common.Address
+	secret  [32]byte // Private key, or CREATE2 salt when mining contract addresses
+}
+
+// ZeroMiner searches for Ethereum addresses starting with zero bytes. With a
+// deployer it mines CREATE2 salts instead of private keys, which skips the
+// elliptic curve multiplication and is much faster.
+type ZeroMiner struct {
+	baseSeed     string
+	minZeros     int
+	deployer     *common.Address
+	initCodeHash [32]byte
+}
+
+// leadingZeroBytes counts the zero bytes an address starts with
+func leadingZeroBytes(address *common.Address) int {
+	for i, b := range address {
+		if b != 0 {
+			return i
+		}
+	}
+	return len(address)
+}
+
+// create2Address computes keccak256(0xff || deployer || salt || initCodeHash)[12:]
+// into out using the caller's buffer and Keccak-256 state
+func create2Address(buf []byte, keccak crypto.KeccakState, deployer *common.Address, salt, initCodeHash *[32]byte, out *common.Address) []byte {
+	buf = append(buf[:0], 0xff)
+	buf = append(buf, deployer[:]...)
+	buf = append(buf, salt[:]...)
+	buf = append(buf, initCodeHash[:]...)
+	var digest [32]byte
+	keccak.Reset()
+	keccak.Write(buf)
+	keccak.Read(digest[:])
+	copy(out[:], digest[12:])
+	return buf
+}
+
+// zeroChunk is the number of attempts a worker reserves at a time
+const zeroChunk = 1024
+
+// attempt returns a function that tries one index for a worker, with its
+// own Keccak-256 state and buffers
+func (m *ZeroMiner) attempt() func(i int) (ZeroHit, bool) {
+	keccak := crypto.NewKeccakState()
+	seedBuf := make([]byte, 0, len(m.baseSeed)+20)
+	var buf []byte
+	return func(i int) (ZeroHit, bool) {
+		hit := ZeroHit{index: i}
+		seedBuf = addrmint.DeriveSeed(seedBuf, m.baseSeed, i, &hit.secret)
+		if m.deployer != nil {
+			buf = create2Address(buf, keccak, m.deployer, &hit.secret, &m.initCodeHash, &hit.address)
+		} else if !ethereumAddressBytes(hit.secret[:], keccak, &hit.address) {
+			return hit, false
+		}
+		hit.zeros = leadingZeroBytes(&hit.address)
+		return hit, hit.zeros >= m.minZeros
+	}
+}
+
+// ethereumAddressBytes derives the address of a private key into out and
+// reports whether the key is valid
+func ethereumAddressBytes(seedBytes []byte, keccak crypto.KeccakState, out *common.Address) bool {
+	privateKey, err := crypto.ToECDSA(seedBytes)
+	if err != nil {
+		return false
+	}
+	pubBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+	var digest [32]byte
+	keccak.Reset()
+	keccak.Write(pubBytes[1:])
+	keccak.Read(digest[:])
+	copy(out[:], digest[12:])
+	return true
+}
+
+// rankZeroHits orders hits by zero count, most zeros first, then by index
+func rankZeroHits(hits []ZeroHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].zeros != hits[j].zeros {
+			return hits[i].zeros > hits[j].zeros
+		}
+		return hits[i].index < hits[j].index
+	})
+}
+
+// writeZeroHits writes zeros,address,secret rows
+func writeZeroHits(w io.Writer, hits []ZeroHit) error {
+	for _, hit := range hits {
+		if _, err := fmt.Fprintf(w, "%d,%s,0x%s\n", hit.zeros, hit.address.Hex(), hex.EncodeToString(hit.secret[:])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runVanityZeros mines Ethereum addresses or CREATE2 salts with leading zero bytes
+func runVanityZeros(args []string) int {
+	fs := flag.NewFlagSet("vanity zeros", flag.ExitOnError)
+	zeroBytes := fs.Int("bytes", 1, "Minimum number of leading zero bytes")
+	count := fs.Int("count", 1, "Number of addresses to find")
+	deployer := fs.String("deployer", "", "Mine CREATE2 salts for contracts deployed by this address instead of private keys")
+	initCodeHash := fs.String("init-code-hash", "", "Keccak-256 of the contract init code (required with --deployer)")
+	seedInt := fs.Int64("seed", 0, "Random seed as integer (0 for random seed)")
+	maxAttempts := fs.Int64("max-attempts", 0, "Stop after this many attempts (0 for no limit)")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of worker goroutines")
+	output := fs.String("output", "", "Output file path (default: stdout)")
+	auditLog := fs.String("audit-log", "", "Append a hash-chained record of the written keys or salts to this file")
+	noColor := fs.Bool("no-color", false, "Disable colored output (also honours NO_COLOR)")
+	numberFormatFlag := fs.String("number-format", numberFormatGrouped, "Number format for the summary (grouped, si, raw)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: addrmint vanity zeros --bytes N [--count N] [--deployer ADDRESS --init-code-hash HASH] [--max-attempts N] [--output FILE] [--audit-log FILE]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prepareConsole()
+	setupColor(*noColor)
+	if err := setupNumberFormat(*numberFormatFlag); err != nil {
+		fatalf("Invalid --number-format: %v", err)
+	}
+
+	if *zeroBytes < 1 || *zeroBytes > common.AddressLength {
+		fatalf("--bytes must be between 1 and %d", common.AddressLength)
+	}
+	if *count < 1 || *workers < 1 {
+		fatalf("Count and workers must be positive")
+	}
+	if *maxAttempts < 0 {
+		fatalf("Max attempts cannot be negative")
+	}
+
+	miner := &ZeroMiner{minZeros: *zeroBytes}
+	if *deployer != "" {
+		if !common.IsHexAddress(*deployer) {
+			fatalf("Invalid --deployer address: %s", *deployer)
+		}
+		address := common.HexToAddress(*deployer)
+		miner.deployer = &address
+		hash, err := hex.DecodeString(strings.TrimPrefix(*initCodeHash, "0x"))
+		if err != nil || len(hash) != len(miner.initCodeHash) {
+			fatalf("--init-code-hash must be 32 bytes of hex")
+		}
+		copy(miner.initCodeHash[:], hash)
+	} else if *initCodeHash != "" {
+		fatalf("--init-code-hash requires --deployer")
+	} else if err := checkPrivateKeyOutput(); err != nil {
+		fatalf("vanity zeros writes private keys: %v (CREATE2 salts can still be mined with --deployer)", err)
+	}
+
+	if *seedInt == 0 {
+		var seed [32]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			fatalf("Failed to generate random seed: %v", err)
+		}
+		miner.baseSeed = hex.EncodeToString(seed[:])
+	} else {
+		miner.baseSeed = strconv.FormatInt(*seedInt, 16)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	target := "addresses"
+	if miner.deployer != nil {
+		target = "CREATE2 salts"
+	}
+	fmt.Fprintf(os.Stderr, "Mining %s with %d leading zero bytes on %d workers (1 in 2^%d attempts matches)\n",
+		target, *zeroBytes, *workers, 8**zeroBytes)
+
+	var attempts atomic.Int64
+	stop := make(chan struct{})
+	start := time.Now()
+	hits := startStrideSearch(*workers, zeroChunk, *maxAttempts, &attempts, stop, miner.attempt)
+
+	var found []ZeroHit
+	for hit := range hits {
+		found = append(found, hit)
+		if len(found) == *count {
+			close(stop)
+			break
+		}
+	}
+	// Wait for the workers, dropping hits they found while stopping
+	for range hits {
+	}
+	elapsed := time.Since(start)
+
+	rankZeroHits(found)
+	digest := sha256.New()
+	counted := NewCountingWriter(io.MultiWriter(out, digest))
+	writer := bufio.NewWriterSize(counted, 64*1024)
+	err := writeZeroHits(writer, found)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		fatalf("Failed to write output: %v", err)
+	}
+	if *auditLog != "" {
+		produced := "private keys"
+		if miner.deployer != nil {
+			produced = "CREATE2 salts"
+		}
+		destination := AuditDestination{Path: cmp.Or(*output, "stdout"), SHA256: hex.EncodeToString(digest.Sum(nil)), Bytes: counted.Bytes()}
+		recordAudit(*auditLog, newAuditEntry("vanity zeros", produced, destination))
+	}
+
+	tried := int(attempts.Load())
+	fmt.Fprintf(os.Stderr, "Found %s of %s in %s attempts (%s attempts/sec)\n",
+		formatCount(len(found)), formatCount(*count), formatCount(tried), formatRate(float64(tried)/elapsed.Seconds()))
+	if len(found) < *count {
+		printWarning("Reached --max-attempts before finding every address")
+		return 1
+	}
+	return 0
+}
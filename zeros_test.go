@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLeadingZeroBytes(t *testing.T) {
+	tests := map[string]int{
+		"0x1000000000000000000000000000000000000000": 0,
+		"0x0010000000000000000000000000000000000000": 1,
+		"0x0000000001000000000000000000000000000000": 4,
+		"0x0000000000000000000000000000000000000000": 20,
+	}
+	for hex, expected := range tests {
+		address := common.HexToAddress(hex)
+		if zeros := leadingZeroBytes(&address); zeros != expected {
+			t.Errorf("%s: expected %d zero bytes, got %d", hex, expected, zeros)
+		}
+	}
+}
+
+func TestCreate2Address(t *testing.T) {
+	// Example 0 from EIP-1014
+	var deployer common.Address
+	var salt, initCodeHash [32]byte
+	copy(initCodeHash[:], crypto.Keccak256([]byte{0x00}))
+
+	var address common.Address
+	create2Address(nil, crypto.NewKeccakState(), &deployer, &salt, &initCodeHash, &address)
+	if expected := "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"; address.Hex() != expected {
+		t.Errorf("Expected %s, got %s", expected, address.Hex())
+	}
+}
+
+func TestZeroMinerHits(t *testing.T) {
+	miner := &ZeroMiner{baseSeed: "2a", minZeros: 1}
+	var done atomic.Int64
+	hits := startStrideSearch(1, zeroChunk, 3000, &done, make(chan struct{}), miner.attempt)
+
+	var found []ZeroHit
+	for hit := range hits {
+		found = append(found, hit)
+	}
+	if done.Load() != 3000 {
+		t.Errorf("Expected 3000 attempts, got %d", done.Load())
+	}
+	if len(found) == 0 {
+		t.Fatal("Expected at least one hit in 3000 attempts")
+	}
+
+	// Every hit's private key must derive its address
+	for _, hit := range found {
+		key, err := crypto.ToECDSA(hit.secret[:])
+		if err != nil {
+			t.Fatalf("Invalid private key: %v", err)
+		}
+		if address := crypto.PubkeyToAddress(key.PublicKey); address != hit.address || hit.zeros < 1 {
+			t.Errorf("Hit %d: key derives %s, reported %s with %d zeros", hit.index, address.Hex(), hit.address.Hex(), hit.zeros)
+		}
+	}
+
+	rankZeroHits(found)
+	for i := 1; i < len(found); i++ {
+		if found[i].zeros > found[i-1].zeros {
+			t.Errorf("Hits are not ranked by zero count: %d before %d", found[i-1].zeros, found[i].zeros)
+		}
+	}
+}